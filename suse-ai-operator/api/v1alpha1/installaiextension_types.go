@@ -19,19 +19,219 @@ package v1alpha1
 import (
 	apixv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// Condition types reported on InstallAIExtension status.
+const (
+	// ConditionServiceResolved reports whether the Service serving the
+	// extension assets was found and a port could be selected.
+	ConditionServiceResolved = "ServiceResolved"
+
+	// ConditionServerReady reports whether the extension server has ready
+	// endpoints and serves the plugin assets and index.yaml.
+	ConditionServerReady = "ServerReady"
+
+	// ConditionHealthy reports whether the registered plugin endpoint keeps
+	// serving the extension assets.
+	ConditionHealthy = "Healthy"
+
+	// ConditionReleased reports the state of the Helm release, including
+	// locks held by other operations and recovery of stuck releases.
+	ConditionReleased = "Released"
+
+	// ConditionPlanned reports whether the plan computed in Plan mode is
+	// up to date with the spec.
+	ConditionPlanned = "Planned"
+
+	// ConditionChartVerified reports whether the chart matches the digest
+	// it is pinned to in spec.helm.digest and the signature required by
+	// spec.helm.verify.
+	ConditionChartVerified = "ChartVerified"
+
+	// ConditionPolicyViolation reports whether the chart violates the
+	// operator's registry and repository policy. Extensions violating it
+	// are not installed or upgraded.
+	ConditionPolicyViolation = "PolicyViolation"
+
+	// ConditionRancherRegistered reports whether the ClusterRepo and
+	// UIPlugin were applied. It is False while another field manager owns
+	// fields the operator sets.
+	ConditionRancherRegistered = "RancherRegistered"
+
+	// ConditionSuspended reports whether reconciliation is suspended with
+	// spec.suspend.
+	ConditionSuspended = "Suspended"
+)
+
+// Condition reasons reported on InstallAIExtension status.
+const (
+	ReasonServiceResolved = "ServiceResolved"
+	ReasonServiceNotFound = "ServiceNotFound"
+	ReasonPortNotFound    = "PortNotFound"
+	ReasonTLSNotReady     = "TLSNotReady"
+
+	ReasonServerReady         = "ServerReady"
+	ReasonWaitingForEndpoints = "WaitingForEndpoints"
+	ReasonWaitingForPlugin    = "WaitingForPlugin"
+	ReasonReadinessTimeout    = "ReadinessTimeout"
+
+	ReasonProbeSucceeded = "ProbeSucceeded"
+	ReasonProbeFailed    = "ProbeFailed"
+
+	ReasonReleaseDeployed   = "ReleaseDeployed"
+	ReasonReleaseLocked     = "ReleaseLocked"
+	ReasonReleaseRecovered  = "ReleaseRecovered"
+	ReasonReleaseFailed     = "ReleaseFailed"
+	ReasonUpgradeRolledBack = "UpgradeRolledBack"
+
+	ReasonPlanReady  = "PlanReady"
+	ReasonPlanFailed = "PlanFailed"
+
+	ReasonDigestVerified       = "DigestVerified"
+	ReasonDigestMismatch       = "DigestMismatch"
+	ReasonSignatureVerified    = "SignatureVerified"
+	ReasonSignatureInvalid     = "SignatureInvalid"
+	ReasonVerificationRequired = "VerificationRequired"
+
+	ReasonPolicyViolated   = "PolicyViolated"
+	ReasonPolicyCompliant  = "PolicyCompliant"
+	ReasonPolicyUnreadable = "PolicyUnreadable"
+
+	ReasonRancherApplied = "RancherApplied"
+	ReasonFieldConflict  = "FieldConflict"
+
+	ReasonServiceAccountRequired  = "ServiceAccountRequired"
+	ReasonServiceAccountNotFound  = "ServiceAccountNotFound"
+	ReasonInsufficientPermissions = "InsufficientPermissions"
+
+	ReasonSuspended = "Suspended"
+	ReasonResumed   = "Resumed"
+
+	ReasonReinstalling = "Reinstalling"
+
+	ReasonReconcileFailed      = "ReconcileFailed"
+	ReasonRetryBudgetExhausted = "RetryBudgetExhausted"
+	ReasonRetryRequested       = "RetryRequested"
+)
+
+// Reconcile modes.
+const (
+	// ModeApply installs the extension and registers it in Rancher.
+	ModeApply = "Apply"
+	// ModePlan computes what Apply would do and applies nothing.
+	ModePlan = "Plan"
+
+	// AnnotationMode overrides spec.mode when set to Plan or Apply.
+	AnnotationMode = "ai-platform.suse.com/mode"
+)
+
+// Annotations requesting one-off actions. Each action runs once for every
+// new value, and the value handled last is recorded in status.
+const (
+	// AnnotationReconcileAt forces a full reconcile, rendering the chart
+	// and updating the UIPlugin, when set to a new value such as the
+	// current time.
+	AnnotationReconcileAt = "ai-platform.suse.com/reconcile-at"
+
+	// AnnotationReinstall uninstalls and reinstalls the Helm release when
+	// set to a new token. The extension and its UIPlugin are kept.
+	AnnotationReinstall = "ai-platform.suse.com/reinstall"
+
+	// AnnotationRetry resets the failure count and retries an extension
+	// whose retries stopped, when set to a new token.
+	AnnotationRetry = "ai-platform.suse.com/retry"
+)
+
+// Failure classes reported in status.failureClass.
+const (
+	// FailureTransient errors, such as registry timeouts, are retried
+	// with backoff while the retry budget lasts.
+	FailureTransient = "Transient"
+	// FailureDependency errors wait for something outside the extension,
+	// such as the Rancher CRDs or a Secret, and are retried until it is
+	// ready.
+	FailureDependency = "Dependency"
+	// FailureTerminal errors, such as a chart that does not exist, are
+	// not retried until the spec changes.
+	FailureTerminal = "Terminal"
+)
+
+// Lifecycle phases reported in status.phase.
+const (
+	// PhasePending is a new extension the operator has not acted on yet.
+	PhasePending = "Pending"
+	// PhaseResolving checks the chart against the policy and loads what
+	// the release needs before Helm runs.
+	PhaseResolving = "Resolving"
+	// PhaseInstalling installs the Helm release for the first time.
+	PhaseInstalling = "Installing"
+	// PhaseUpgrading upgrades the Helm release to a changed spec.
+	PhaseUpgrading = "Upgrading"
+	// PhaseRegistering waits for the extension server and registers it
+	// in Rancher.
+	PhaseRegistering = "Registering"
+	// PhaseReady is an extension that is installed, registered and
+	// healthy.
+	PhaseReady = "Ready"
+	// PhaseDegraded is a registered extension whose upgrade was rolled
+	// back or whose health checks fail.
+	PhaseDegraded = "Degraded"
+	// PhaseDeleting uninstalls the extension.
+	PhaseDeleting = "Deleting"
+	// PhaseFailed is an extension whose retries stopped until the spec
+	// changes.
+	PhaseFailed = "Failed"
+	// PhaseSuspended is an extension with spec.suspend set.
+	PhaseSuspended = "Suspended"
+	// PhasePlanned and PhasePlanFailed report the result of Plan mode.
+	PhasePlanned    = "Planned"
+	PhasePlanFailed = "PlanFailed"
+)
+
+// MaxPhaseHistory is the number of phase transitions kept in
+// status.phaseHistory.
+const MaxPhaseHistory = 10
+
+// LabelShard assigns an extension to the operator instance started with
+// the same --shard.
+const LabelShard = "ai-platform.suse.com/shard"
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // InstallAIExtensionSpec defines the desired state of InstallAIExtension
 type InstallAIExtensionSpec struct {
+	// Mode selects whether the extension is applied or only planned. In
+	// Plan mode the chart is rendered with a dry run and the Helm diff and
+	// Rancher objects that Apply would write are published in status.plan
+	// and a ConfigMap. Defaults to Apply.
+	// +kubebuilder:validation:Enum=Plan;Apply
+	// +kubebuilder:default=Apply
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// Suspend stops the operator from changing the Helm release and the
+	// Rancher objects of the extension until it is unset, so they can be
+	// edited by hand. Deleting a suspended extension still uninstalls it.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
 	Helm *HelmSpec `json:"helm,omitempty"`
 
+	// ServiceAccountName is a service account in the extension namespace
+	// that Helm impersonates to install, upgrade and uninstall the
+	// release, so the chart can only create what the service account is
+	// allowed to. Empty uses the operator's own permissions.
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
 	// +kubebuilder:validation:Required
 	Extension ExtensionSpec `json:"extension"`
 }
 
+// +kubebuilder:validation:XValidation:rule="has(self.version) || has(self.digest)",message="one of version or digest must be set"
 type HelmSpec struct {
 	Name string `json:"name"`
 	// URL of the Helm repository or OCI registry.
@@ -41,9 +241,129 @@ type HelmSpec struct {
 	//
 	// +kubebuilder:validation:MinLength=1
 	// +kubebuilder:validation:Pattern=`^(oci://|https?://).+`
-	URL     string                 `json:"url"`
-	Version string                 `json:"version"`
-	Values  map[string]apixv1.JSON `json:"values,omitempty"`
+	URL string `json:"url"`
+
+	// Version of the chart, an exact version or a semver range.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Digest pins the chart to a sha256 digest, as an alternative or a
+	// complement to Version. For OCI charts this is the manifest digest
+	// reported by the registry and helm push: without a version the chart
+	// is pulled by digest, with one the tag must still resolve to it. For
+	// HTTP repositories it is the digest of the chart archive.
+	// +kubebuilder:validation:Pattern=`^sha256:[a-f0-9]{64}$`
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	Values map[string]apixv1.JSON `json:"values,omitempty"`
+
+	// Verify checks the chart signature before it is installed or
+	// upgraded. A chart that fails verification is never rendered.
+	// +optional
+	Verify *ChartVerification `json:"verify,omitempty"`
+
+	// Options tune the Helm install and upgrade actions.
+	// +optional
+	Options *HelmOptions `json:"options,omitempty"`
+
+	// Remediation configures how failed Helm operations are handled.
+	// +optional
+	Remediation *HelmRemediation `json:"remediation,omitempty"`
+}
+
+// ChartVerification selects how the chart signature is verified.
+type ChartVerification struct {
+	// Provider is Helm to check the .prov provenance file published with
+	// the chart against an OpenPGP keyring, or Cosign to check a cosign
+	// signature of the OCI chart artifact against a public key.
+	// +kubebuilder:validation:Enum=Helm;Cosign
+	Provider string `json:"provider"`
+
+	// SecretName is the Secret in the extension namespace holding the
+	// keyring or the PEM public key.
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// Key of the Secret. Defaults to keyring.gpg for Helm and cosign.pub
+	// for Cosign.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// HelmRemediation configures how failed Helm operations are handled.
+type HelmRemediation struct {
+	// RollbackOnUpgradeFailure rolls the release back to the last deployed
+	// revision when an upgrade fails. The failed spec is not retried until
+	// it changes.
+	// +optional
+	RollbackOnUpgradeFailure bool `json:"rollbackOnUpgradeFailure,omitempty"`
+
+	// StuckReleaseTimeout is the age after which a release left in a
+	// pending or uninstalling state is considered abandoned and recovered.
+	// It is never shorter than the Helm operation timeout. Defaults to 15m.
+	// +optional
+	StuckReleaseTimeout *metav1.Duration `json:"stuckReleaseTimeout,omitempty"`
+
+	// StuckReleaseStrategy selects how a stuck or failed release is
+	// recovered. Rollback returns to the last deployed revision and falls
+	// back to Reinstall when there is none; Reinstall uninstalls the
+	// release and installs it again.
+	// +kubebuilder:validation:Enum=Rollback;Reinstall
+	// +kubebuilder:default=Rollback
+	// +optional
+	StuckReleaseStrategy string `json:"stuckReleaseStrategy,omitempty"`
+}
+
+// HelmOptions are applied uniformly to install, upgrade and the dry-run
+// upgrade used for change detection.
+type HelmOptions struct {
+	// Timeout for each Helm operation. Defaults to 10m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Wait until all resources are ready before marking the release as
+	// successful. Defaults to true.
+	// +optional
+	Wait *bool `json:"wait,omitempty"`
+
+	// WaitForJobs waits until all Jobs have completed. Requires Wait.
+	// +optional
+	WaitForJobs *bool `json:"waitForJobs,omitempty"`
+
+	// Atomic rolls back (or uninstalls, on install) a failed operation.
+	// Implies Wait.
+	// +optional
+	Atomic *bool `json:"atomic,omitempty"`
+
+	// +optional
+	SkipCRDs *bool `json:"skipCRDs,omitempty"`
+
+	// +optional
+	DisableHooks *bool `json:"disableHooks,omitempty"`
+
+	// MaxHistory limits the number of release revisions kept.
+	// Defaults to 10, 0 means unlimited.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxHistory *int32 `json:"maxHistory,omitempty"`
+
+	// Force resource updates through a replacement strategy.
+	// +optional
+	Force *bool `json:"force,omitempty"`
+
+	// CleanupOnFail deletes new resources created by a failed upgrade.
+	// +optional
+	CleanupOnFail *bool `json:"cleanupOnFail,omitempty"`
+
+	// CreateNamespace creates the release namespace on install.
+	// +optional
+	CreateNamespace *bool `json:"createNamespace,omitempty"`
+
+	// DependencyUpdate updates the chart dependencies when they are
+	// missing from the chart archive.
+	// +optional
+	DependencyUpdate *bool `json:"dependencyUpdate,omitempty"`
 }
 
 type ExtensionSpec struct {
@@ -53,12 +373,328 @@ type ExtensionSpec struct {
 	// +kubebuilder:validation:MinLength=1
 	Version  string            `json:"version"`
 	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Service selects the Service serving the extension assets.
+	// When omitted, the Services labeled with the Helm release instance are used.
+	// +optional
+	Service *ServiceSelector `json:"service,omitempty"`
+
+	// ReadinessTimeout bounds how long the operator waits for the extension
+	// server to serve the plugin before reporting a ReadinessTimeout.
+	// Defaults to 5m.
+	// +optional
+	ReadinessTimeout *metav1.Duration `json:"readinessTimeout,omitempty"`
+
+	// TLS configures HTTPS between Rancher and the extension Service.
+	// When set, the Service is always addressed with https://.
+	// +optional
+	TLS *ExtensionTLSSpec `json:"tls,omitempty"`
+}
+
+// ExtensionTLSSpec configures how Rancher and the operator trust the
+// extension server's certificate.
+type ExtensionTLSSpec struct {
+	// CABundleSecretRef references a Secret key in the extension namespace
+	// holding the PEM CA bundle that signed the serving certificate.
+	// Defaults to the ca.crt key of Certificate.SecretName when a
+	// certificate is issued by the operator.
+	// +optional
+	CABundleSecretRef *SecretKeySelector `json:"caBundleSecretRef,omitempty"`
+
+	// Certificate asks the operator to issue a serving certificate for the
+	// extension Service.
+	// +optional
+	Certificate *ServingCertificateSpec `json:"certificate,omitempty"`
+}
+
+// SecretKeySelector selects a key of a Secret in the extension namespace.
+type SecretKeySelector struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// +kubebuilder:default="ca.crt"
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// ServingCertificateSpec describes the serving certificate issued for the
+// extension Service. Exactly one of CASecretName or IssuerRef must be set.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.caSecretName) != has(self.issuerRef)",message="exactly one of caSecretName or issuerRef must be set"
+type ServingCertificateSpec struct {
+	// SecretName is the kubernetes.io/tls Secret the certificate is written
+	// to. The extension chart is expected to mount it.
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// CASecretName references a kubernetes.io/tls Secret holding the CA
+	// the operator signs the serving certificate with.
+	// +optional
+	CASecretName string `json:"caSecretName,omitempty"`
+
+	// IssuerRef references a cert-manager compatible issuer. The operator
+	// creates a Certificate object and the issuer fills SecretName.
+	// +optional
+	IssuerRef *IssuerReference `json:"issuerRef,omitempty"`
+}
+
+// IssuerReference references a cert-manager compatible issuer.
+type IssuerReference struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// +kubebuilder:default="Issuer"
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// +kubebuilder:default="cert-manager.io"
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// ServiceSelector identifies the Service and port serving the extension.
+// When several Services match, the one with the lexicographically
+// smallest name is used.
+//
+// +kubebuilder:validation:XValidation:rule="!(has(self.name) && has(self.selector))",message="name and selector are mutually exclusive"
+type ServiceSelector struct {
+	// Name of the Service in the extension namespace.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Selector matches Services in the extension namespace by label.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Port is the name or number of the Service port to use.
+	// Defaults to the port named "http", or the first declared port.
+	// +optional
+	Port *intstr.IntOrString `json:"port,omitempty"`
 }
 
 // InstallAIExtensionStatus defines the observed state of InstallAIExtension.
 type InstallAIExtensionStatus struct {
+	// Phase is the lifecycle phase of the extension.
 	Phase   string `json:"phase,omitempty"`
 	Message string `json:"message,omitempty"`
+
+	// LastPhaseTransitionTime is when Phase last changed.
+	// +optional
+	LastPhaseTransitionTime *metav1.Time `json:"lastPhaseTransitionTime,omitempty"`
+
+	// ObservedGeneration is the spec generation last rolled out: installed
+	// and registered in Rancher.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// PhaseHistory lists the last phase transitions, oldest first.
+	// +kubebuilder:validation:MaxItems=10
+	// +listType=atomic
+	// +optional
+	PhaseHistory []PhaseTransition `json:"phaseHistory,omitempty"`
+
+	// ServiceURL is the URL of the Service registered in Rancher.
+	// +optional
+	ServiceURL string `json:"serviceURL,omitempty"`
+
+	// PluginEndpoint is the endpoint registered in the UIPlugin.
+	// +optional
+	PluginEndpoint string `json:"pluginEndpoint,omitempty"`
+
+	// Health is the result of the periodic probes of PluginEndpoint.
+	// +optional
+	Health *HealthStatus `json:"health,omitempty"`
+
+	// Deployed describes the Helm release revision currently running.
+	// +optional
+	Deployed *DeployedRelease `json:"deployed,omitempty"`
+
+	// FailedUpgrade records the last upgrade that failed and was rolled
+	// back. It is cleared by the next successful upgrade.
+	// +optional
+	FailedUpgrade *FailedUpgrade `json:"failedUpgrade,omitempty"`
+
+	// LastUpgradeDiff summarizes what the last upgrade changed in the
+	// release manifest.
+	// +optional
+	LastUpgradeDiff *UpgradeDiff `json:"lastUpgradeDiff,omitempty"`
+
+	// Plan is the result of the last reconcile in Plan mode.
+	// +optional
+	Plan *PlanStatus `json:"plan,omitempty"`
+
+	// Verification records the signature verification of the chart
+	// currently deployed.
+	// +optional
+	Verification *VerificationStatus `json:"verification,omitempty"`
+
+	// LastHandledReconcileAt is the last value of the
+	// ai-platform.suse.com/reconcile-at annotation that was reconciled.
+	// +optional
+	LastHandledReconcileAt string `json:"lastHandledReconcileAt,omitempty"`
+
+	// LastHandledReinstall is the last ai-platform.suse.com/reinstall
+	// token the release was reinstalled for.
+	// +optional
+	LastHandledReinstall string `json:"lastHandledReinstall,omitempty"`
+
+	// FailureCount is the number of consecutive failed reconciles. It is
+	// reset by a successful reconcile, a spec change and the
+	// ai-platform.suse.com/retry annotation.
+	// +optional
+	FailureCount int32 `json:"failureCount,omitempty"`
+
+	// LastError is the error of the last failed reconcile.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// FailureClass classifies LastError.
+	// +kubebuilder:validation:Enum=Transient;Dependency;Terminal
+	// +optional
+	FailureClass string `json:"failureClass,omitempty"`
+
+	// FailedGeneration is the spec generation LastError was reported for.
+	// +optional
+	FailedGeneration int64 `json:"failedGeneration,omitempty"`
+
+	// NextRetryTime is when the failed reconcile is retried. It is unset
+	// when retries stopped, after a terminal error or once the retry
+	// budget is spent.
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// LastHandledRetry is the last ai-platform.suse.com/retry token that
+	// reset the failure count.
+	// +optional
+	LastHandledRetry string `json:"lastHandledRetry,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// PhaseTransition records a change of status.phase.
+type PhaseTransition struct {
+	// Phase is the phase entered.
+	Phase string `json:"phase"`
+	// Reason is a CamelCase reason for the transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Time is when the phase was entered.
+	Time metav1.Time `json:"time"`
+}
+
+// DeployedRelease describes the Helm release revision currently running.
+type DeployedRelease struct {
+	Revision     int    `json:"revision,omitempty"`
+	ChartVersion string `json:"chartVersion,omitempty"`
+	// Namespace is the namespace the release is installed in. The
+	// extension stays there when the operator's extension namespace
+	// changes.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// ChartDigest is the digest of the chart this revision was installed
+	// from: the manifest digest for OCI charts, the archive digest
+	// otherwise.
+	ChartDigest string `json:"chartDigest,omitempty"`
+	// ExtensionVersion is the UIPlugin version served by this revision.
+	ExtensionVersion string `json:"extensionVersion,omitempty"`
+	// DesiredStateHash is the hash of the chart reference, version, values
+	// and options this revision was applied from. Reconciles skip the
+	// dry-run render while it matches the spec.
+	DesiredStateHash string `json:"desiredStateHash,omitempty"`
+	// LastRenderTime is when the chart was last rendered and compared
+	// against the release, either for a change or a drift check.
+	// +optional
+	LastRenderTime *metav1.Time `json:"lastRenderTime,omitempty"`
+}
+
+// FailedUpgrade records an upgrade that failed and was rolled back.
+type FailedUpgrade struct {
+	// Revision is the failed Helm release revision.
+	Revision     int    `json:"revision,omitempty"`
+	ChartVersion string `json:"chartVersion,omitempty"`
+	// RolledBackTo is the revision created by the rollback.
+	RolledBackTo int    `json:"rolledBackTo,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	// ObservedGeneration is the spec generation that failed. It is not
+	// retried until the generation changes.
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	Time               metav1.Time `json:"time,omitempty"`
+}
+
+// UpgradeDiff summarizes the objects an upgrade added, changed and removed.
+type UpgradeDiff struct {
+	// Revision is the Helm release revision created by the upgrade.
+	Revision int   `json:"revision,omitempty"`
+	Added    int32 `json:"added"`
+	Changed  int32 `json:"changed"`
+	Removed  int32 `json:"removed"`
+	// Summary lists the first changed objects.
+	Summary string `json:"summary,omitempty"`
+	// ConfigMapName is the ConfigMap in the extension namespace holding the
+	// full diff, with Secret values redacted.
+	// +optional
+	ConfigMapName string      `json:"configMapName,omitempty"`
+	Time          metav1.Time `json:"time,omitempty"`
+}
+
+// PlanStatus summarizes what applying the spec would do.
+type PlanStatus struct {
+	// Action is Install, Upgrade or None.
+	Action              string `json:"action,omitempty"`
+	ChartVersion        string `json:"chartVersion,omitempty"`
+	ChartDigest         string `json:"chartDigest,omitempty"`
+	CurrentChartVersion string `json:"currentChartVersion,omitempty"`
+	ExtensionVersion    string `json:"extensionVersion,omitempty"`
+	Added               int32  `json:"added"`
+	Changed             int32  `json:"changed"`
+	Removed             int32  `json:"removed"`
+	Summary             string `json:"summary,omitempty"`
+	// ServiceURL is the URL the ClusterRepo would point at.
+	ServiceURL string `json:"serviceURL,omitempty"`
+	// ConfigMapName is the ConfigMap in the extension namespace holding the
+	// rendered manifest, the full diff and the Rancher objects.
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// Warnings lists parts of the plan that could not be fully computed.
+	// +optional
+	Warnings []string `json:"warnings,omitempty"`
+	// ObservedGeneration is the spec generation the plan was computed for.
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	Time               metav1.Time `json:"time,omitempty"`
+}
+
+// VerificationStatus records a verified chart signature.
+type VerificationStatus struct {
+	// Provider is Helm or Cosign.
+	Provider string `json:"provider,omitempty"`
+	// SignedBy identifies the key that signed the chart: the OpenPGP
+	// identity and key ID for Helm, the key fingerprint for Cosign.
+	SignedBy string `json:"signedBy,omitempty"`
+	// ChartDigest is the digest of the verified chart.
+	ChartDigest string `json:"chartDigest,omitempty"`
+	// Revision is the Helm release revision rendered from the chart.
+	Revision int         `json:"revision,omitempty"`
+	Time     metav1.Time `json:"time,omitempty"`
+}
+
+// HealthStatus records the periodic health probes of the plugin endpoint.
+// It only changes with the health of the endpoint; the time and latency
+// of every probe are exported as metrics.
+type HealthStatus struct {
+	// LastSuccessTime is when probes started succeeding, on the first
+	// probe or after failures.
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,209 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types reported on OperatorConfig status.
+const (
+	// ConditionConfigApplied reports whether the spec passed validation
+	// and is the configuration the operator runs with.
+	ConditionConfigApplied = "Applied"
+)
+
+// Condition reasons reported on OperatorConfig status.
+const (
+	ReasonConfigApplied = "ConfigApplied"
+	ReasonConfigInvalid = "ConfigInvalid"
+)
+
+// OperatorConfigSpec overrides the operator settings given with flags.
+// Unset fields keep the flag values. Once a change is applied, every
+// extension is reconciled with it, without restarting the operator.
+type OperatorConfigSpec struct {
+	// ExtensionNamespace is the namespace new extensions are installed
+	// in. Extensions already deployed stay in their namespace.
+	// +optional
+	ExtensionNamespace string `json:"extensionNamespace,omitempty"`
+
+	// HelmDefaults are the Helm options of extensions that do not set
+	// them in spec.helm.options. Options an extension sets take
+	// precedence.
+	// +optional
+	HelmDefaults *HelmOptions `json:"helmDefaults,omitempty"`
+
+	// IndexCacheTTL is how long a fetched extension index.yaml is reused.
+	// Zero fetches it on every reconcile.
+	// +optional
+	IndexCacheTTL *metav1.Duration `json:"indexCacheTTL,omitempty"`
+
+	// ResyncInterval is how often unchanged releases are rendered and
+	// compared against the cluster. Overrides --drift-check-interval.
+	// +optional
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+
+	// Policy restricts where extension charts are installed from. It is
+	// combined with the policy given with flags and --policy-configmap.
+	// +optional
+	Policy *OperatorPolicy `json:"policy,omitempty"`
+
+	// Proxy is used to download charts, provenance files and signatures.
+	// +optional
+	Proxy *ProxySpec `json:"proxy,omitempty"`
+
+	// CABundle references PEM certificates trusted, in addition to the
+	// system roots, when downloading charts.
+	// +optional
+	CABundle *CABundleSource `json:"caBundle,omitempty"`
+
+	// Features toggles optional operator behavior.
+	// +optional
+	Features *FeatureToggles `json:"features,omitempty"`
+}
+
+// OperatorPolicy lists where extension charts may be installed from.
+// Empty lists allow everything.
+type OperatorPolicy struct {
+	// AllowedURLPrefixes lists prefixes of spec.helm.url that are allowed.
+	// +optional
+	AllowedURLPrefixes []string `json:"allowedURLPrefixes,omitempty"`
+	// AllowedRegistries lists the registry or repository hosts that are
+	// allowed.
+	// +optional
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// AllowedCharts lists the chart names, or shell patterns, that are
+	// allowed.
+	// +optional
+	AllowedCharts []string `json:"allowedCharts,omitempty"`
+	// RequireSignature lists the charts that must set spec.helm.verify.
+	// +optional
+	RequireSignature []PolicySignatureRule `json:"requireSignature,omitempty"`
+}
+
+// PolicySignatureRule requires charts matching URLPrefix and Registry to
+// be verified. A rule without either applies to every chart.
+type PolicySignatureRule struct {
+	// +optional
+	URLPrefix string `json:"urlPrefix,omitempty"`
+	// +optional
+	Registry string `json:"registry,omitempty"`
+	// Provider is the verification provider the chart must use.
+	// +kubebuilder:validation:Enum=Helm;Cosign
+	// +optional
+	Provider string `json:"provider,omitempty"`
+}
+
+// ProxySpec configures the HTTP proxy used for chart downloads.
+type ProxySpec struct {
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	// NoProxy is a comma separated list of hosts, domains and CIDRs
+	// reached directly.
+	// +optional
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// CABundleSource references a key of a ConfigMap or Secret in the
+// operator namespace. Exactly one of ConfigMapName and SecretName is set.
+// +kubebuilder:validation:XValidation:rule="has(self.configMapName) != has(self.secretName)",message="exactly one of configMapName or secretName must be set"
+type CABundleSource struct {
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// Key holding the certificates. Defaults to ca.crt.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// FeatureToggles turns optional operator behavior on or off. Unset
+// toggles keep the flag values.
+type FeatureToggles struct {
+	// RequireChartVerification refuses extensions without
+	// spec.helm.verify.
+	// +optional
+	RequireChartVerification *bool `json:"requireChartVerification,omitempty"`
+
+	// RequireServiceAccount refuses extensions without
+	// spec.serviceAccountName, so no chart is installed with the
+	// operator's own permissions.
+	// +optional
+	RequireServiceAccount *bool `json:"requireServiceAccount,omitempty"`
+
+	// DriftDetection renders unchanged releases every resyncInterval.
+	// +optional
+	DriftDetection *bool `json:"driftDetection,omitempty"`
+
+	// HealthChecks probes the plugin endpoint of registered extensions.
+	// +optional
+	HealthChecks *bool `json:"healthChecks,omitempty"`
+}
+
+// OperatorConfigStatus reports whether the configuration was applied.
+type OperatorConfigStatus struct {
+	// ObservedGeneration is the generation last validated.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AppliedGeneration is the generation the operator runs with. It
+	// lags ObservedGeneration while the spec is invalid.
+	// +optional
+	AppliedGeneration int64 `json:"appliedGeneration,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+
+// OperatorConfig is the Schema for the operatorconfigs API
+type OperatorConfig struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the operator settings
+	// +required
+	Spec OperatorConfigSpec `json:"spec"`
+
+	// status defines the observed state of OperatorConfig
+	// +optional
+	Status OperatorConfigStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// OperatorConfigList contains a list of OperatorConfig
+type OperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OperatorConfig{}, &OperatorConfigList{})
+}
@@ -22,9 +22,60 @@ package v1alpha1
 
 import (
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundleSource) DeepCopyInto(out *CABundleSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundleSource.
+func (in *CABundleSource) DeepCopy() *CABundleSource {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundleSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChartVerification) DeepCopyInto(out *ChartVerification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChartVerification.
+func (in *ChartVerification) DeepCopy() *ChartVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(ChartVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeployedRelease) DeepCopyInto(out *DeployedRelease) {
+	*out = *in
+	if in.LastRenderTime != nil {
+		in, out := &in.LastRenderTime, &out.LastRenderTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeployedRelease.
+func (in *DeployedRelease) DeepCopy() *DeployedRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(DeployedRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtensionSpec) DeepCopyInto(out *ExtensionSpec) {
 	*out = *in
@@ -35,6 +86,21 @@ func (in *ExtensionSpec) DeepCopyInto(out *ExtensionSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessTimeout != nil {
+		in, out := &in.ReadinessTimeout, &out.ReadinessTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ExtensionTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtensionSpec.
@@ -47,6 +113,195 @@ func (in *ExtensionSpec) DeepCopy() *ExtensionSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtensionTLSSpec) DeepCopyInto(out *ExtensionTLSSpec) {
+	*out = *in
+	if in.CABundleSecretRef != nil {
+		in, out := &in.CABundleSecretRef, &out.CABundleSecretRef
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = new(ServingCertificateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtensionTLSSpec.
+func (in *ExtensionTLSSpec) DeepCopy() *ExtensionTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtensionTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailedUpgrade) DeepCopyInto(out *FailedUpgrade) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailedUpgrade.
+func (in *FailedUpgrade) DeepCopy() *FailedUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(FailedUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FeatureToggles) DeepCopyInto(out *FeatureToggles) {
+	*out = *in
+	if in.RequireChartVerification != nil {
+		in, out := &in.RequireChartVerification, &out.RequireChartVerification
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RequireServiceAccount != nil {
+		in, out := &in.RequireServiceAccount, &out.RequireServiceAccount
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DriftDetection != nil {
+		in, out := &in.DriftDetection, &out.DriftDetection
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HealthChecks != nil {
+		in, out := &in.HealthChecks, &out.HealthChecks
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FeatureToggles.
+func (in *FeatureToggles) DeepCopy() *FeatureToggles {
+	if in == nil {
+		return nil
+	}
+	out := new(FeatureToggles)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthStatus) DeepCopyInto(out *HealthStatus) {
+	*out = *in
+	if in.LastSuccessTime != nil {
+		in, out := &in.LastSuccessTime, &out.LastSuccessTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthStatus.
+func (in *HealthStatus) DeepCopy() *HealthStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmOptions) DeepCopyInto(out *HelmOptions) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Wait != nil {
+		in, out := &in.Wait, &out.Wait
+		*out = new(bool)
+		**out = **in
+	}
+	if in.WaitForJobs != nil {
+		in, out := &in.WaitForJobs, &out.WaitForJobs
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Atomic != nil {
+		in, out := &in.Atomic, &out.Atomic
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SkipCRDs != nil {
+		in, out := &in.SkipCRDs, &out.SkipCRDs
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DisableHooks != nil {
+		in, out := &in.DisableHooks, &out.DisableHooks
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MaxHistory != nil {
+		in, out := &in.MaxHistory, &out.MaxHistory
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Force != nil {
+		in, out := &in.Force, &out.Force
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CleanupOnFail != nil {
+		in, out := &in.CleanupOnFail, &out.CleanupOnFail
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CreateNamespace != nil {
+		in, out := &in.CreateNamespace, &out.CreateNamespace
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DependencyUpdate != nil {
+		in, out := &in.DependencyUpdate, &out.DependencyUpdate
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmOptions.
+func (in *HelmOptions) DeepCopy() *HelmOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmRemediation) DeepCopyInto(out *HelmRemediation) {
+	*out = *in
+	if in.StuckReleaseTimeout != nil {
+		in, out := &in.StuckReleaseTimeout, &out.StuckReleaseTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmRemediation.
+func (in *HelmRemediation) DeepCopy() *HelmRemediation {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmRemediation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmSpec) DeepCopyInto(out *HelmSpec) {
 	*out = *in
@@ -57,6 +312,21 @@ func (in *HelmSpec) DeepCopyInto(out *HelmSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Verify != nil {
+		in, out := &in.Verify, &out.Verify
+		*out = new(ChartVerification)
+		**out = **in
+	}
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = new(HelmOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Remediation != nil {
+		in, out := &in.Remediation, &out.Remediation
+		*out = new(HelmRemediation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmSpec.
@@ -75,7 +345,7 @@ func (in *InstallAIExtension) DeepCopyInto(out *InstallAIExtension) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallAIExtension.
@@ -152,6 +422,58 @@ func (in *InstallAIExtensionSpec) DeepCopy() *InstallAIExtensionSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InstallAIExtensionStatus) DeepCopyInto(out *InstallAIExtensionStatus) {
 	*out = *in
+	if in.LastPhaseTransitionTime != nil {
+		in, out := &in.LastPhaseTransitionTime, &out.LastPhaseTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PhaseHistory != nil {
+		in, out := &in.PhaseHistory, &out.PhaseHistory
+		*out = make([]PhaseTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(HealthStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Deployed != nil {
+		in, out := &in.Deployed, &out.Deployed
+		*out = new(DeployedRelease)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailedUpgrade != nil {
+		in, out := &in.FailedUpgrade, &out.FailedUpgrade
+		*out = new(FailedUpgrade)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastUpgradeDiff != nil {
+		in, out := &in.LastUpgradeDiff, &out.LastUpgradeDiff
+		*out = new(UpgradeDiff)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(PlanStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(VerificationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstallAIExtensionStatus.
@@ -163,3 +485,343 @@ func (in *InstallAIExtensionStatus) DeepCopy() *InstallAIExtensionStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuerReference) DeepCopyInto(out *IssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuerReference.
+func (in *IssuerReference) DeepCopy() *IssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfig) DeepCopyInto(out *OperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfig.
+func (in *OperatorConfig) DeepCopy() *OperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigList) DeepCopyInto(out *OperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigList.
+func (in *OperatorConfigList) DeepCopy() *OperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigSpec) DeepCopyInto(out *OperatorConfigSpec) {
+	*out = *in
+	if in.HelmDefaults != nil {
+		in, out := &in.HelmDefaults, &out.HelmDefaults
+		*out = new(HelmOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IndexCacheTTL != nil {
+		in, out := &in.IndexCacheTTL, &out.IndexCacheTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(OperatorPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxySpec)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(CABundleSource)
+		**out = **in
+	}
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = new(FeatureToggles)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigSpec.
+func (in *OperatorConfigSpec) DeepCopy() *OperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorConfigStatus) DeepCopyInto(out *OperatorConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorConfigStatus.
+func (in *OperatorConfigStatus) DeepCopy() *OperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorPolicy) DeepCopyInto(out *OperatorPolicy) {
+	*out = *in
+	if in.AllowedURLPrefixes != nil {
+		in, out := &in.AllowedURLPrefixes, &out.AllowedURLPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedRegistries != nil {
+		in, out := &in.AllowedRegistries, &out.AllowedRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedCharts != nil {
+		in, out := &in.AllowedCharts, &out.AllowedCharts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequireSignature != nil {
+		in, out := &in.RequireSignature, &out.RequireSignature
+		*out = make([]PolicySignatureRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorPolicy.
+func (in *OperatorPolicy) DeepCopy() *OperatorPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PhaseTransition) DeepCopyInto(out *PhaseTransition) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PhaseTransition.
+func (in *PhaseTransition) DeepCopy() *PhaseTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(PhaseTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanStatus) DeepCopyInto(out *PlanStatus) {
+	*out = *in
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanStatus.
+func (in *PlanStatus) DeepCopy() *PlanStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicySignatureRule) DeepCopyInto(out *PolicySignatureRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicySignatureRule.
+func (in *PolicySignatureRule) DeepCopy() *PolicySignatureRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicySignatureRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxySpec) DeepCopyInto(out *ProxySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxySpec.
+func (in *ProxySpec) DeepCopy() *ProxySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeySelector.
+func (in *SecretKeySelector) DeepCopy() *SecretKeySelector {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeySelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceSelector) DeepCopyInto(out *ServiceSelector) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceSelector.
+func (in *ServiceSelector) DeepCopy() *ServiceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServingCertificateSpec) DeepCopyInto(out *ServingCertificateSpec) {
+	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(IssuerReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServingCertificateSpec.
+func (in *ServingCertificateSpec) DeepCopy() *ServingCertificateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServingCertificateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeDiff) DeepCopyInto(out *UpgradeDiff) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeDiff.
+func (in *UpgradeDiff) DeepCopy() *UpgradeDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationStatus) DeepCopyInto(out *VerificationStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationStatus.
+func (in *VerificationStatus) DeepCopy() *VerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
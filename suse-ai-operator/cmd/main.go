@@ -20,15 +20,22 @@ import (
 	"crypto/tls"
 	"flag"
 	"os"
+	"path/filepath"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -38,6 +45,12 @@ import (
 	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
 	"github.com/SUSE/suse-ai-operator/internal/config"
 	aiextensionctrl "github.com/SUSE/suse-ai-operator/internal/controller/installaiextension"
+	operatorconfigctrl "github.com/SUSE/suse-ai-operator/internal/controller/operatorconfig"
+	"github.com/SUSE/suse-ai-operator/internal/health"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/infra/pluginserver"
+	"github.com/SUSE/suse-ai-operator/internal/policy"
+	webhookv1alpha1 "github.com/SUSE/suse-ai-operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -63,6 +76,18 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
+	var healthCheckInterval time.Duration
+	var healthFailureThreshold int
+	var driftCheckInterval time.Duration
+	var chartCacheDir, chartCacheMaxSize string
+	var requireChartVerification, requireServiceAccount bool
+	var enableWebhooks bool
+	var operatorConfigName, allowedExtensionNamespaces string
+	var indexCacheTTL time.Duration
+	var shardName, watchSelector string
+	var maxConcurrentReconciles, retryBudget int
+	var backoffBase, backoffMax, cacheSyncPeriod time.Duration
+	var policyURLPrefixes, policyRegistries, policyCharts, policyConfigMap string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -80,6 +105,59 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&healthCheckInterval, "health-check-interval", health.DefaultInterval,
+		"How often registered extension plugin endpoints are probed. Set to 0 to disable health checks.")
+	flag.IntVar(&healthFailureThreshold, "health-failure-threshold", health.DefaultFailureThreshold,
+		"Consecutive failed probes after which an extension is reported unhealthy.")
+	flag.DurationVar(&driftCheckInterval, "drift-check-interval", time.Hour,
+		"How often unchanged Helm releases are rendered and compared against the cluster. "+
+			"Set to 0 to only render when the desired state changes.")
+	flag.StringVar(&chartCacheDir, "chart-cache-dir", filepath.Join(os.TempDir(), "suse-ai-operator"),
+		"Writable directory for the chart cache and Helm's repository and registry files. "+
+			"Set to an empty string to disable the chart cache.")
+	flag.StringVar(&chartCacheMaxSize, "chart-cache-max-size", "1Gi",
+		"Maximum size of the chart archives kept in the chart cache.")
+	flag.BoolVar(&requireChartVerification, "require-chart-verification", false,
+		"If set, extensions without spec.helm.verify are not installed or upgraded.")
+	flag.BoolVar(&requireServiceAccount, "require-service-account", false,
+		"If set, extensions without spec.serviceAccountName are not installed or upgraded.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"If set, the admission webhook validating InstallAIExtensions is served. "+
+			"Requires a certificate in --webhook-cert-path.")
+	flag.StringVar(&policyURLPrefixes, "policy-allowed-url-prefixes", "",
+		"Comma separated prefixes of spec.helm.url that charts may be installed from.")
+	flag.StringVar(&policyRegistries, "policy-allowed-registries", "",
+		"Comma separated registry and repository hosts that charts may be installed from.")
+	flag.StringVar(&policyCharts, "policy-allowed-charts", "",
+		"Comma separated chart names, or shell patterns, that may be installed.")
+	flag.StringVar(&operatorConfigName, "operator-config", "default",
+		"Name of the cluster-scoped OperatorConfig overriding these flags. Changes apply without a restart. "+
+			"Set to an empty string to only use flags.")
+	flag.StringVar(&allowedExtensionNamespaces, "allowed-extension-namespaces", "",
+		"Comma separated namespaces, besides EXTENSION_NAMESPACE, that the OperatorConfig may set as "+
+			"spec.extensionNamespace. The operator needs the same permissions in each.")
+	flag.DurationVar(&indexCacheTTL, "index-cache-ttl", 0,
+		"How long extension index.yaml files are reused across reconciles. Set to 0 to fetch them on every reconcile.")
+	flag.StringVar(&shardName, "shard", "",
+		"Only reconcile extensions labeled "+aiplatformv1alpha1.LabelShard+"=<shard>. "+
+			"Run one operator instance per shard; each elects its own leader.")
+	flag.StringVar(&watchSelector, "watch-selector", "",
+		"Label selector of the extensions this instance reconciles, such as vendor=suse. "+
+			"Combined with --shard. Other extensions are not loaded into the cache.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"How many extensions are reconciled at once.")
+	flag.DurationVar(&backoffBase, "reconcile-backoff-base", aiextensionctrl.DefaultBackoffBase,
+		"Delay before retrying a failed reconcile of an extension, doubled on every further failure.")
+	flag.DurationVar(&backoffMax, "reconcile-backoff-max", aiextensionctrl.DefaultBackoffMax,
+		"Maximum delay between retries of a failing extension.")
+	flag.IntVar(&retryBudget, "retry-budget", aiextensionctrl.DefaultRetryBudget,
+		"Consecutive transient failures of an extension retried before retries stop until the spec changes "+
+			"or the ai-platform.suse.com/retry annotation is set. Set to 0 to retry forever.")
+	flag.DurationVar(&cacheSyncPeriod, "cache-sync-period", 10*time.Hour,
+		"How often every cached extension is reconciled again, even without changes.")
+	flag.StringVar(&policyConfigMap, "policy-configmap", "",
+		"ConfigMap, as namespace/name, holding an operator policy under policy.yaml. "+
+			"It is combined with the policy flags and read on every check.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -155,13 +233,55 @@ func main() {
 		metricsServerOptions.KeyName = metricsCertKey
 	}
 
+	shard, err := config.NewShard(shardName, watchSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid shard")
+		os.Exit(1)
+	}
+
+	extensionNamespace := config.GetExtensionNamespace()
+	operatorNamespace := config.GetOperatorNamespace()
+	policyConfigMapRef := policy.ParseConfigMapRef(policyConfigMap, extensionNamespace)
+
+	// Secrets and ConfigMaps are read straight from the API server, so
+	// they are never listed cluster-wide. Only the ones watched for the
+	// OperatorConfig CA bundle and the policy are cached, from the
+	// namespaces holding them.
+	configMapNamespaces := map[string]cache.Config{operatorNamespace: {}}
+	if policyConfigMapRef.Name != "" {
+		configMapNamespaces[policyConfigMapRef.Namespace] = cache.Config{}
+	}
+	cacheOptions := cache.Options{
+		SyncPeriod: &cacheSyncPeriod,
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Secret{}:    {Namespaces: map[string]cache.Config{operatorNamespace: {}}},
+			&corev1.ConfigMap{}: {Namespaces: configMapNamespaces},
+		},
+	}
+
+	clientOptions := client.Options{
+		Cache: &client.CacheOptions{
+			DisableFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}},
+		},
+	}
+
+	// Extensions outside the shard are filtered by the API server and
+	// never reach the cache.
+	if shard.Selector != nil {
+		setupLog.Info("Reconciling the extensions of a shard", "selector", shard.String(),
+			"leaderElectionID", shard.LeaderElectionID())
+		cacheOptions.ByObject[&aiplatformv1alpha1.InstallAIExtension{}] = cache.ByObject{Label: shard.Selector}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  cacheOptions,
+		Client:                 clientOptions,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "77d8cb24.suse.com",
+		LeaderElectionID:       shard.LeaderElectionID(),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -179,16 +299,103 @@ func main() {
 		os.Exit(1)
 	}
 
+	prober := pluginserver.NewProber(nil)
+
+	var chartCache *helmClient.ChartCache
+	if chartCacheDir != "" {
+		maxSize, err := resource.ParseQuantity(chartCacheMaxSize)
+		if err != nil {
+			setupLog.Error(err, "invalid chart cache size", "size", chartCacheMaxSize)
+			os.Exit(1)
+		}
+		chartCache, err = helmClient.NewChartCache(chartCacheDir, maxSize.Value())
+		if err != nil {
+			setupLog.Error(err, "unable to set up chart cache", "dir", chartCacheDir)
+			os.Exit(1)
+		}
+	}
+
+	settings := config.NewStore(config.Settings{
+		ExtensionNamespace:         extensionNamespace,
+		AllowedExtensionNamespaces: policy.SplitList(allowedExtensionNamespaces),
+		IndexCacheTTL:              indexCacheTTL,
+		DriftCheckInterval:         driftCheckInterval,
+		RequireChartVerification:   requireChartVerification,
+		RequireServiceAccount:      requireServiceAccount,
+		HealthChecks:               healthCheckInterval > 0,
+	})
+
+	// Reconciles every extension once an OperatorConfig change is applied.
+	var settingsChanged chan event.GenericEvent
+	if operatorConfigName != "" {
+		settingsChanged = make(chan event.GenericEvent, 1)
+		if err := (&operatorconfigctrl.OperatorConfigReconciler{
+			Client:    mgr.GetClient(),
+			Recorder:  mgr.GetEventRecorderFor("operator-config-controller"),
+			Settings:  settings,
+			Name:      operatorConfigName,
+			Namespace: operatorNamespace,
+			Changed:   settingsChanged,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "OperatorConfig")
+			os.Exit(1)
+		}
+	}
+
+	policies := &policy.Loader{
+		Reader: mgr.GetAPIReader(),
+		Static: &policy.Policy{
+			AllowedURLPrefixes: policy.SplitList(policyURLPrefixes),
+			AllowedRegistries:  policy.SplitList(policyRegistries),
+			AllowedCharts:      policy.SplitList(policyCharts),
+		},
+		Dynamic:   settings.Policy,
+		ConfigMap: policyConfigMapRef,
+	}
+
 	if err := (&aiextensionctrl.InstallAIExtensionReconciler{
-		Client:             mgr.GetClient(),
-		Scheme:             mgr.GetScheme(),
-		Recorder:           mgr.GetEventRecorderFor("install-ai-extension-controller"),
-		Config:             mgr.GetConfig(),
-		ExtensionNamespace: config.GetExtensionNamespace(),
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Recorder:   mgr.GetEventRecorderFor("install-ai-extension-controller"),
+		Config:     mgr.GetConfig(),
+		Prober:     prober,
+		Settings:   settings,
+		ChartCache: chartCache,
+		IndexCache: helmClient.NewIndexCache(),
+		Policies:   policies,
+
+		SettingsChanged: settingsChanged,
+
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		RateLimiter:             aiextensionctrl.NewRateLimiter(backoffBase, backoffMax),
+		RetryBackoffBase:        backoffBase,
+		RetryBackoffMax:         backoffMax,
+		RetryBudget:             retryBudget,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "InstallAIExtension")
 		os.Exit(1)
 	}
+
+	if enableWebhooks {
+		if err := webhookv1alpha1.SetupInstallAIExtensionWebhookWithManager(mgr, policies, settings, shard.Selector); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "InstallAIExtension")
+			os.Exit(1)
+		}
+	}
+
+	// The checker always runs so the OperatorConfig can turn health checks
+	// on. Without an interval it ticks at the default one.
+	if err := mgr.Add(&health.Checker{
+		Client:           mgr.GetClient(),
+		Prober:           prober,
+		Recorder:         mgr.GetEventRecorderFor("install-ai-extension-health"),
+		Settings:         settings,
+		Interval:         healthCheckInterval,
+		FailureThreshold: int32(healthFailureThreshold),
+	}); err != nil {
+		setupLog.Error(err, "unable to set up extension health checker")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -10,3 +10,13 @@ func GetExtensionNamespace() string {
 	}
 	return DefaultExtensionNamespace
 }
+
+// GetOperatorNamespace returns the namespace the operator runs in, where
+// the OperatorConfig CA bundle is read from. It falls back to the
+// extension namespace outside a pod.
+func GetOperatorNamespace() string {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
+	}
+	return GetExtensionNamespace()
+}
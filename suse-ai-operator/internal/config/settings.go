@@ -0,0 +1,213 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/policy"
+)
+
+// Settings are the operator settings in effect: the flag values,
+// overridden by the OperatorConfig.
+type Settings struct {
+	ExtensionNamespace string
+	// AllowedExtensionNamespaces are the namespaces, besides the flag
+	// value of ExtensionNamespace, the OperatorConfig may select. The
+	// operator is only granted access to these.
+	AllowedExtensionNamespaces []string
+	// HelmDefaults are the options of extensions that do not set
+	// spec.helm.options. Nil uses the Helm client defaults.
+	HelmDefaults *aiplatformv1alpha1.HelmOptions
+	// IndexCacheTTL is how long extension index files are reused across
+	// reconciles. Zero fetches them on every reconcile.
+	IndexCacheTTL time.Duration
+	// DriftCheckInterval is how often an unchanged release is rendered
+	// and compared against the cluster. Zero disables drift checks.
+	DriftCheckInterval       time.Duration
+	RequireChartVerification bool
+	// RequireServiceAccount refuses extensions that would be installed
+	// with the operator's own permissions.
+	RequireServiceAccount bool
+	HealthChecks          bool
+	// Policy is combined with the policy loaded from flags and the policy
+	// ConfigMap.
+	Policy *policy.Policy
+	// Transport downloads charts. Nil uses Helm's default transports.
+	Transport *http.Transport
+}
+
+// Store holds the settings in effect. It is safe for concurrent use.
+type Store struct {
+	mu      sync.RWMutex
+	base    Settings
+	current Settings
+}
+
+// NewStore returns a Store running with base, the flag values.
+func NewStore(base Settings) *Store {
+	return &Store{base: base, current: base}
+}
+
+// Get returns the settings in effect.
+func (s *Store) Get() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Base returns the flag values.
+func (s *Store) Base() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.base
+}
+
+// Set replaces the settings in effect.
+func (s *Store) Set(settings Settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = settings
+}
+
+// Reset reverts to the flag values.
+func (s *Store) Reset() {
+	s.Set(s.Base())
+}
+
+// Policy returns the policy of the settings in effect.
+func (s *Store) Policy() *policy.Policy {
+	return s.Get().Policy
+}
+
+// Resolve overrides base with spec. caBundle holds the certificates
+// spec.caBundle references. All validation errors are returned joined,
+// and base is returned unchanged when there are any.
+func Resolve(base Settings, spec *aiplatformv1alpha1.OperatorConfigSpec, caBundle []byte) (Settings, error) {
+	out := base
+	var errs []error
+
+	if ns := spec.ExtensionNamespace; ns != "" {
+		if msgs := validation.IsDNS1123Label(ns); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("extensionNamespace %q is invalid: %v", ns, msgs))
+		} else if ns != base.ExtensionNamespace && !slices.Contains(base.AllowedExtensionNamespaces, ns) {
+			errs = append(errs, fmt.Errorf(
+				"extensionNamespace %q is not allowed, the operator has no access to it; allowed: %s",
+				ns, strings.Join(append([]string{base.ExtensionNamespace}, base.AllowedExtensionNamespaces...), ", ")))
+		}
+		out.ExtensionNamespace = ns
+	}
+
+	if spec.HelmDefaults != nil {
+		out.HelmDefaults = spec.HelmDefaults.DeepCopy()
+	}
+
+	if d := spec.IndexCacheTTL; d != nil {
+		if d.Duration < 0 {
+			errs = append(errs, errors.New("indexCacheTTL must not be negative"))
+		}
+		out.IndexCacheTTL = d.Duration
+	}
+
+	if d := spec.ResyncInterval; d != nil {
+		if d.Duration < 0 {
+			errs = append(errs, errors.New("resyncInterval must not be negative"))
+		}
+		out.DriftCheckInterval = d.Duration
+	}
+
+	if p := spec.Policy; p != nil {
+		rules := make([]policy.SignatureRule, 0, len(p.RequireSignature))
+		for _, r := range p.RequireSignature {
+			rules = append(rules, policy.SignatureRule{URLPrefix: r.URLPrefix, Registry: r.Registry, Provider: r.Provider})
+		}
+		out.Policy = base.Policy.Merge(&policy.Policy{
+			AllowedURLPrefixes: p.AllowedURLPrefixes,
+			AllowedRegistries:  p.AllowedRegistries,
+			AllowedCharts:      p.AllowedCharts,
+			RequireSignature:   rules,
+		})
+	}
+
+	if f := spec.Features; f != nil {
+		if f.RequireChartVerification != nil {
+			out.RequireChartVerification = *f.RequireChartVerification
+		}
+		if f.RequireServiceAccount != nil {
+			out.RequireServiceAccount = *f.RequireServiceAccount
+		}
+		if f.DriftDetection != nil && !*f.DriftDetection {
+			out.DriftCheckInterval = 0
+		}
+		if f.HealthChecks != nil {
+			out.HealthChecks = *f.HealthChecks
+		}
+	}
+
+	if spec.Proxy != nil || len(caBundle) > 0 {
+		transport, err := newTransport(spec.Proxy, caBundle)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		out.Transport = transport
+	}
+
+	if len(errs) > 0 {
+		return base, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// newTransport returns a transport using proxy instead of the proxy
+// environment, and trusting caBundle in addition to the system roots.
+func newTransport(proxy *aiplatformv1alpha1.ProxySpec, caBundle []byte) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+
+	if proxy != nil {
+		for name, value := range map[string]string{"httpProxy": proxy.HTTPProxy, "httpsProxy": proxy.HTTPSProxy} {
+			if value == "" {
+				continue
+			}
+			if u, err := url.Parse(value); err != nil || u.Host == "" {
+				return nil, fmt.Errorf("proxy.%s %q is not a valid URL", name, value)
+			}
+		}
+
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  proxy.HTTPProxy,
+			HTTPSProxy: proxy.HTTPSProxy,
+			NoProxy:    proxy.NoProxy,
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if len(caBundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("caBundle contains no PEM certificates")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return transport, nil
+}
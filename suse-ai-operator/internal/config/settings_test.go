@@ -0,0 +1,102 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("Resolve", func() {
+	base := Settings{
+		ExtensionNamespace:         "cattle-ui-plugin-system",
+		AllowedExtensionNamespaces: []string{"ui-extensions"},
+		IndexCacheTTL:              time.Minute,
+		DriftCheckInterval:         time.Hour,
+		HealthChecks:               true,
+	}
+
+	DescribeTable("overrides the flag values",
+		func(spec aiplatformv1alpha1.OperatorConfigSpec, modify func(*Settings)) {
+			expected := base
+			modify(&expected)
+
+			settings, err := Resolve(base, &spec, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(settings).To(Equal(expected))
+		},
+		Entry("keeps the flag values for an empty spec",
+			aiplatformv1alpha1.OperatorConfigSpec{},
+			func(*Settings) {}),
+		Entry("selects the flag extension namespace",
+			aiplatformv1alpha1.OperatorConfigSpec{ExtensionNamespace: "cattle-ui-plugin-system"},
+			func(*Settings) {}),
+		Entry("selects an allowed extension namespace",
+			aiplatformv1alpha1.OperatorConfigSpec{ExtensionNamespace: "ui-extensions"},
+			func(s *Settings) { s.ExtensionNamespace = "ui-extensions" }),
+		Entry("sets the Helm defaults",
+			aiplatformv1alpha1.OperatorConfigSpec{HelmDefaults: &aiplatformv1alpha1.HelmOptions{Atomic: ptr.To(true)}},
+			func(s *Settings) { s.HelmDefaults = &aiplatformv1alpha1.HelmOptions{Atomic: ptr.To(true)} }),
+		Entry("sets the intervals",
+			aiplatformv1alpha1.OperatorConfigSpec{
+				IndexCacheTTL:  &metav1.Duration{},
+				ResyncInterval: &metav1.Duration{Duration: 5 * time.Minute},
+			},
+			func(s *Settings) {
+				s.IndexCacheTTL = 0
+				s.DriftCheckInterval = 5 * time.Minute
+			}),
+		Entry("turns off drift detection",
+			aiplatformv1alpha1.OperatorConfigSpec{
+				ResyncInterval: &metav1.Duration{Duration: 5 * time.Minute},
+				Features:       &aiplatformv1alpha1.FeatureToggles{DriftDetection: ptr.To(false)},
+			},
+			func(s *Settings) { s.DriftCheckInterval = 0 }),
+		Entry("sets the features",
+			aiplatformv1alpha1.OperatorConfigSpec{Features: &aiplatformv1alpha1.FeatureToggles{
+				RequireChartVerification: ptr.To(true),
+				RequireServiceAccount:    ptr.To(true),
+				HealthChecks:             ptr.To(false),
+			}},
+			func(s *Settings) {
+				s.RequireChartVerification = true
+				s.RequireServiceAccount = true
+				s.HealthChecks = false
+			}),
+	)
+
+	DescribeTable("rejects an invalid spec",
+		func(spec aiplatformv1alpha1.OperatorConfigSpec, caBundle []byte, messages ...string) {
+			settings, err := Resolve(base, &spec, caBundle)
+			Expect(err).To(HaveOccurred())
+			for _, message := range messages {
+				Expect(err.Error()).To(ContainSubstring(message))
+			}
+			Expect(settings).To(Equal(base))
+		},
+		Entry("with an invalid extension namespace",
+			aiplatformv1alpha1.OperatorConfigSpec{ExtensionNamespace: "UI_Extensions"}, nil,
+			`extensionNamespace "UI_Extensions" is invalid`),
+		Entry("with an extension namespace the operator has no access to",
+			aiplatformv1alpha1.OperatorConfigSpec{ExtensionNamespace: "kube-system"}, nil,
+			`extensionNamespace "kube-system" is not allowed`,
+			"allowed: cattle-ui-plugin-system, ui-extensions"),
+		Entry("with a proxy that is not a URL",
+			aiplatformv1alpha1.OperatorConfigSpec{Proxy: &aiplatformv1alpha1.ProxySpec{HTTPSProxy: "proxy"}}, nil,
+			`proxy.httpsProxy "proxy" is not a valid URL`),
+		Entry("with a CA bundle that holds no certificates",
+			aiplatformv1alpha1.OperatorConfigSpec{}, []byte("not a certificate"),
+			"caBundle contains no PEM certificates"),
+		Entry("and reports every error",
+			aiplatformv1alpha1.OperatorConfigSpec{
+				ExtensionNamespace: "kube-system",
+				IndexCacheTTL:      &metav1.Duration{Duration: -time.Second},
+				ResyncInterval:     &metav1.Duration{Duration: -time.Second},
+			}, nil,
+			"is not allowed", "indexCacheTTL must not be negative", "resyncInterval must not be negative"),
+	)
+})
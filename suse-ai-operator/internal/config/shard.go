@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+// DefaultLeaderElectionID is the leader election ID of an unsharded
+// operator.
+const DefaultLeaderElectionID = "77d8cb24.suse.com"
+
+// Shard scopes an operator instance to the extensions matching Selector.
+type Shard struct {
+	// Name is the --shard value, empty when only a selector is set.
+	Name string
+	// Selector is nil for an unsharded operator.
+	Selector labels.Selector
+}
+
+// NewShard combines --shard and --watch-selector. A shard name selects
+// the extensions labeled with it, in addition to the selector.
+func NewShard(name, selector string) (Shard, error) {
+	if name == "" && selector == "" {
+		return Shard{}, nil
+	}
+
+	if name != "" {
+		if msgs := validation.IsValidLabelValue(name); len(msgs) > 0 {
+			return Shard{}, fmt.Errorf("invalid shard %q: %v", name, msgs)
+		}
+	}
+
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return Shard{}, fmt.Errorf("invalid watch selector %q: %w", selector, err)
+	}
+	if name != "" {
+		req, err := labels.NewRequirement(aiplatformv1alpha1.LabelShard, "=", []string{name})
+		if err != nil {
+			return Shard{}, err
+		}
+		sel = sel.Add(*req)
+	}
+
+	return Shard{Name: name, Selector: sel}, nil
+}
+
+// LeaderElectionID returns a leader election ID unique to the shard, so
+// instances of different shards all lead at once.
+func (s Shard) LeaderElectionID() string {
+	if s.Selector == nil {
+		return DefaultLeaderElectionID
+	}
+
+	prefix := "shard-" + s.Name
+	if s.Name == "" || len(validation.IsDNS1123Label(prefix)) > 0 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(s.Selector.String()))
+		prefix = fmt.Sprintf("shard-%08x", h.Sum32())
+	}
+	return prefix + "." + DefaultLeaderElectionID
+}
+
+func (s Shard) String() string {
+	if s.Selector == nil {
+		return "<all>"
+	}
+	return s.Selector.String()
+}
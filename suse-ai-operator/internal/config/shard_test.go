@@ -0,0 +1,60 @@
+package config
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var _ = Describe("Shard", func() {
+	id := regexp.QuoteMeta(DefaultLeaderElectionID)
+
+	DescribeTable("NewShard",
+		func(name, selector, expectedSelector, expectedID string, matching, other labels.Set) {
+			shard, err := NewShard(name, selector)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(shard.String()).To(Equal(expectedSelector))
+			Expect(shard.LeaderElectionID()).To(MatchRegexp("^" + expectedID + "$"))
+			if shard.Selector != nil {
+				Expect(shard.Selector.Matches(matching)).To(BeTrue())
+				Expect(shard.Selector.Matches(other)).To(BeFalse())
+			}
+		},
+		Entry("watches everything without a shard or selector",
+			"", "", "<all>", id, nil, nil),
+		Entry("selects the extensions of a shard",
+			"gpu", "", "ai-platform.suse.com/shard=gpu", `shard-gpu\.`+id,
+			labels.Set{"ai-platform.suse.com/shard": "gpu"},
+			labels.Set{"ai-platform.suse.com/shard": "cpu"}),
+		Entry("selects the extensions matching a selector",
+			"", "tier in (prod)", "tier in (prod)", `shard-[0-9a-f]{8}\.`+id,
+			labels.Set{"tier": "prod"},
+			labels.Set{"tier": "dev"}),
+		Entry("combines a shard and a selector",
+			"gpu", "tier=prod", "ai-platform.suse.com/shard=gpu,tier=prod", `shard-gpu\.`+id,
+			labels.Set{"ai-platform.suse.com/shard": "gpu", "tier": "prod"},
+			labels.Set{"ai-platform.suse.com/shard": "gpu", "tier": "dev"}),
+		Entry("hashes a shard name that is not a DNS label",
+			"GPU_Nodes", "", "ai-platform.suse.com/shard=GPU_Nodes", `shard-[0-9a-f]{8}\.`+id,
+			labels.Set{"ai-platform.suse.com/shard": "GPU_Nodes"},
+			labels.Set{}),
+	)
+
+	DescribeTable("rejects",
+		func(name, selector, message string) {
+			_, err := NewShard(name, selector)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("an invalid shard name", "gpu nodes", "", `invalid shard "gpu nodes"`),
+		Entry("an invalid selector", "", "tier in prod", `invalid watch selector "tier in prod"`),
+	)
+	It("gives shards with different selectors different leader election IDs", func() {
+		prod, err := NewShard("", "tier=prod")
+		Expect(err).NotTo(HaveOccurred())
+		dev, err := NewShard("", "tier=dev")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prod.LeaderElectionID()).NotTo(Equal(dev.LeaderElectionID()))
+	})
+})
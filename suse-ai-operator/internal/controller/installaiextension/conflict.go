@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/infra/rancher"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// recordFieldConflict reports that the ClusterRepo or UIPlugin was not
+// applied because another field manager owns fields the operator sets.
+func (r *InstallAIExtensionReconciler) recordFieldConflict(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	conflict *rancher.FieldConflictError,
+) {
+	log := logging.FromContext(ctx, "rancher").WithValues(logging.KeyExtension, ext.Name)
+
+	r.event(ext, corev1.EventTypeWarning, aiplatformv1alpha1.ReasonFieldConflict, "%s", conflict.Error())
+
+	if err := r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		setCondition(latest, aiplatformv1alpha1.ConditionRancherRegistered, metav1.ConditionFalse,
+			aiplatformv1alpha1.ReasonFieldConflict, conflict.Error())
+	}); err != nil {
+		log.Error(err, "Failed to record field conflict")
+	}
+}
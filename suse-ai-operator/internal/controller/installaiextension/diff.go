@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+const (
+	labelExtension    = "ai-platform.suse.com/extension"
+	labelDiffRevision = "ai-platform.suse.com/upgrade-revision"
+
+	diffKeyText = "diff.txt"
+	diffKeyJSON = "diff.json"
+
+	// maxDiffBytes keeps each diff well below the ConfigMap size limit.
+	maxDiffBytes = 400 * 1024
+)
+
+// recordUpgradeDiff publishes what an upgrade changed: the full diff in a
+// ConfigMap per revision and a summary in an event. It returns the status
+// summary. Failures are logged, they never fail the reconcile.
+func (r *InstallAIExtensionReconciler) recordUpgradeDiff(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	namespace string,
+	result *helmClient.ReleaseResult,
+	keep int,
+) *aiplatformv1alpha1.UpgradeDiff {
+
+	log := logging.FromContext(ctx, "diff").WithValues(
+		logging.KeyExtension, ext.Name,
+		"revision", result.Revision,
+	)
+
+	diff := result.Diff
+	added, removed, changed := diff.Count()
+
+	summary := &aiplatformv1alpha1.UpgradeDiff{
+		Revision: result.Revision,
+		Added:    int32(added),
+		Changed:  int32(changed),
+		Removed:  int32(removed),
+		Summary:  diff.Summary(),
+		Time:     metav1.Now(),
+	}
+
+	r.event(ext, corev1.EventTypeNormal, "UpgradeDiff",
+		"Upgraded to revision %d: %s", result.Revision, summary.Summary)
+
+	name, err := r.writeDiffConfigMap(ctx, ext, namespace, result.Revision, diff)
+	if err != nil {
+		log.Error(err, "Failed to store upgrade diff")
+		return summary
+	}
+	summary.ConfigMapName = name
+
+	if err := r.pruneDiffConfigMaps(ctx, ext, namespace, keep); err != nil {
+		log.Error(err, "Failed to prune old upgrade diffs")
+	}
+
+	return summary
+}
+
+func (r *InstallAIExtensionReconciler) writeDiffConfigMap(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	namespace string,
+	revision int,
+	diff *helmClient.ManifestDiff,
+) (string, error) {
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return "", err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-upgrade-%d", ext.Name, revision),
+			Namespace: namespace,
+		},
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[labelExtension] = ext.Name
+		cm.Labels[labelDiffRevision] = strconv.Itoa(revision)
+
+		cm.Data = map[string]string{
+			diffKeyText: truncate(diff.String(), maxDiffBytes),
+		}
+		if len(data) <= maxDiffBytes {
+			cm.Data[diffKeyJSON] = string(data)
+		}
+
+		return controllerutil.SetControllerReference(ext, cm, r.Scheme)
+	})
+
+	return cm.Name, err
+}
+
+// pruneDiffConfigMaps keeps the diffs of the latest keep upgrades.
+func (r *InstallAIExtensionReconciler) pruneDiffConfigMaps(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	namespace string,
+	keep int,
+) error {
+
+	if keep <= 0 {
+		return nil
+	}
+
+	var list corev1.ConfigMapList
+	if err := r.List(ctx, &list,
+		client.InNamespace(namespace),
+		client.MatchingLabels{labelExtension: ext.Name},
+		client.HasLabels{labelDiffRevision},
+	); err != nil {
+		return err
+	}
+
+	if len(list.Items) <= keep {
+		return nil
+	}
+
+	revision := func(cm *corev1.ConfigMap) int {
+		n, _ := strconv.Atoi(cm.Labels[labelDiffRevision])
+		return n
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return revision(&list.Items[i]) > revision(&list.Items[j])
+	})
+
+	for i := keep; i < len(list.Items); i++ {
+		if err := r.Delete(ctx, &list.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// truncatedMarker ends a value cut by truncate.
+const truncatedMarker = "\n... (truncated)\n"
+
+// truncate cuts s to at most limit bytes, marker included, without
+// splitting a UTF-8 character.
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	n := max(limit-len(truncatedMarker), 0)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncatedMarker
+}
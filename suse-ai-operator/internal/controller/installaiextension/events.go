@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/infra/rancher"
+)
+
+// Reasons of events that have no matching condition reason.
+const (
+	eventFinalizerAdded       = "FinalizerAdded"
+	eventFinalizerRemoved     = "FinalizerRemoved"
+	eventChartResolved        = "ChartResolved"
+	eventInstalled            = "Installed"
+	eventUpgraded             = "Upgraded"
+	eventDriftCorrected       = "DriftCorrected"
+	eventUpgradeSkipped       = "UpgradeSkipped"
+	eventUninstalled          = "Uninstalled"
+	eventCleanupCompleted     = "CleanupCompleted"
+	eventWaitingForDependency = "WaitingForDependency"
+	eventDependencyNotReady   = "DependencyNotReady"
+)
+
+// event records an event on ext, if a recorder is set.
+func (r *InstallAIExtensionReconciler) event(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	eventType, reason, messageFmt string,
+	args ...interface{},
+) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(ext, eventType, reason, messageFmt, args...)
+}
+
+// recordReleaseEvents reports what EnsureRelease did to the release of
+// ext. A rendered release that needed no upgrade is reported as skipped
+// when the spec changed; a release whose render was skipped on a matching
+// hash is not reported.
+func (r *InstallAIExtensionReconciler) recordReleaseEvents(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	spec helmClient.ReleaseSpec,
+	result *helmClient.ReleaseResult,
+) {
+	if !result.Rendered {
+		return
+	}
+
+	if rolloutPending(ext) {
+		digest := result.ChartDigest
+		if digest == "" {
+			digest = "unknown"
+		}
+		r.event(ext, corev1.EventTypeNormal, eventChartResolved,
+			"Resolved chart %s to version %s (digest %s)", spec.ChartRef, result.ChartVersion, digest)
+	}
+
+	switch result.Action {
+	case helmClient.ActionInstalled, helmClient.ActionReinstalled:
+		r.event(ext, corev1.EventTypeNormal, eventInstalled,
+			"Installed release %s/%s with chart version %s, extension version %s (revision %d)",
+			spec.Namespace, spec.Name, result.ChartVersion, ext.Spec.Extension.Version, result.Revision)
+	case helmClient.ActionUpgraded:
+		if driftCorrected(ext, result) {
+			r.event(ext, corev1.EventTypeNormal, eventDriftCorrected,
+				"Reverted changes made outside the operator to release %s/%s (revision %d): %s",
+				spec.Namespace, spec.Name, result.Revision, result.Diff.Summary())
+			return
+		}
+		previous := deployedExtensionVersion(ext, nil)
+		if previous == "" {
+			previous = "unknown"
+		}
+		r.event(ext, corev1.EventTypeNormal, eventUpgraded,
+			"Upgraded release %s/%s from chart version %s to %s, extension version %s to %s (revision %d)",
+			spec.Namespace, spec.Name, result.PreviousChartVersion, result.ChartVersion,
+			previous, ext.Spec.Extension.Version, result.Revision)
+	case helmClient.ActionUnchanged:
+		// Only a spec change is expected to upgrade; periodic drift
+		// checks that find nothing are not reported.
+		if !rolloutPending(ext) {
+			return
+		}
+		r.event(ext, corev1.EventTypeNormal, eventUpgradeSkipped,
+			"Release %s/%s already matches chart version %s (revision %d), no upgrade needed",
+			spec.Namespace, spec.Name, result.ChartVersion, result.Revision)
+	}
+}
+
+// recordRancherChanges reports the ClusterRepo and UIPlugin that were
+// created, updated or deleted. Unchanged objects are not reported.
+func (r *InstallAIExtensionReconciler) recordRancherChanges(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	changes []rancher.Change,
+) {
+	for _, change := range changes {
+		if change.Action == rancher.ActionUnchanged {
+			continue
+		}
+		name := change.Name
+		if change.Namespace != "" {
+			name = change.Namespace + "/" + change.Name
+		}
+		r.event(ext, corev1.EventTypeNormal, change.Kind+string(change.Action),
+			"%s %s %s", change.Action, change.Kind, name)
+	}
+}
+
+// recordWaiting reports that the reconcile is waiting for something
+// outside the operator. Repeats of the same wait only increase the count
+// of the event.
+func (r *InstallAIExtensionReconciler) recordWaiting(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	messageFmt string,
+	args ...interface{},
+) {
+	r.event(ext, corev1.EventTypeNormal, eventWaitingForDependency, messageFmt, args...)
+}
+
+// recordRetriedFailure reports a failed reconcile that will be retried.
+// Repeated errors are aggregated: a new error is reported right away,
+// the same error again only after 2, 4, 8, ... consecutive failures, with
+// the count in the message.
+func (r *InstallAIExtensionReconciler) recordRetriedFailure(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	class string,
+	failures int32,
+	delay time.Duration,
+	err error,
+) {
+	repeated := ext.Status.FailedGeneration == ext.Generation && ext.Status.LastError == err.Error()
+	if repeated && failures&(failures-1) != 0 {
+		return
+	}
+
+	reason := aiplatformv1alpha1.ReasonReconcileFailed
+	if class == aiplatformv1alpha1.FailureDependency {
+		reason = eventDependencyNotReady
+	}
+
+	message := err.Error()
+	if failures > 1 {
+		message = fmt.Sprintf("%s (%d consecutive failures)", message, failures)
+	}
+	r.event(ext, corev1.EventTypeWarning, reason, "%s, retrying in %s", message, delay)
+}
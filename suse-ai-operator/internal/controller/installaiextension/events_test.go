@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+)
+
+// drainEvents returns the events recorded so far.
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+var _ = Describe("Events", func() {
+	var (
+		reconciler *InstallAIExtensionReconciler
+		recorder   *record.FakeRecorder
+		ext        *aiplatformv1alpha1.InstallAIExtension
+	)
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(20)
+		reconciler = &InstallAIExtensionReconciler{Recorder: recorder}
+		ext = newInstalledExtension()
+	})
+
+	Describe("recordReleaseEvents", func() {
+		spec := helmClient.ReleaseSpec{
+			Name:      "ext",
+			Namespace: "default",
+			ChartRef:  "oci://registry.example.com/charts/ext",
+		}
+		diff := &helmClient.ManifestDiff{Changes: []helmClient.ObjectChange{{
+			Type:       helmClient.ChangeChanged,
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  "default",
+			Name:       "ext",
+		}}}
+
+		DescribeTable("reports the action of a rollout",
+			func(result *helmClient.ReleaseResult, expected []string) {
+				reconciler.recordReleaseEvents(ext, spec, result)
+				Expect(drainEvents(recorder)).To(Equal(expected))
+			},
+			Entry("an install", &helmClient.ReleaseResult{
+				Action: helmClient.ActionInstalled, Revision: 1, ChartVersion: "0.2.0", Rendered: true,
+			}, []string{
+				"Normal ChartResolved Resolved chart oci://registry.example.com/charts/ext to version 0.2.0 (digest unknown)",
+				"Normal Installed Installed release default/ext with chart version 0.2.0, extension version 2.0.0 (revision 1)",
+			}),
+			Entry("a reinstall", &helmClient.ReleaseResult{
+				Action: helmClient.ActionReinstalled, Revision: 1, ChartVersion: "0.2.0", ChartDigest: "sha256:abc",
+				Rendered: true,
+			}, []string{
+				"Normal ChartResolved Resolved chart oci://registry.example.com/charts/ext to version 0.2.0 (digest sha256:abc)",
+				"Normal Installed Installed release default/ext with chart version 0.2.0, extension version 2.0.0 (revision 1)",
+			}),
+			Entry("an upgrade", &helmClient.ReleaseResult{
+				Action: helmClient.ActionUpgraded, Revision: 2, ChartVersion: "0.2.0", PreviousChartVersion: "0.1.0",
+				ChartDigest: "sha256:abc", Diff: diff, Rendered: true,
+			}, []string{
+				"Normal ChartResolved Resolved chart oci://registry.example.com/charts/ext to version 0.2.0 (digest sha256:abc)",
+				"Normal Upgraded Upgraded release default/ext from chart version 0.1.0 to 0.2.0, " +
+					"extension version 1.0.0 to 2.0.0 (revision 2)",
+			}),
+			Entry("a skipped upgrade", &helmClient.ReleaseResult{
+				Action: helmClient.ActionUnchanged, Revision: 1, ChartVersion: "0.2.0", ChartDigest: "sha256:abc",
+				Rendered: true,
+			}, []string{
+				"Normal ChartResolved Resolved chart oci://registry.example.com/charts/ext to version 0.2.0 (digest sha256:abc)",
+				"Normal UpgradeSkipped Release default/ext already matches chart version 0.2.0 (revision 1), no upgrade needed",
+			}),
+			Entry("nothing for a release that was not rendered", &helmClient.ReleaseResult{
+				Action: helmClient.ActionUnchanged, Revision: 1, ChartVersion: "0.1.0",
+			}, nil),
+		)
+
+		DescribeTable("reports the action of a drift check",
+			func(result *helmClient.ReleaseResult, expected []string) {
+				ext.Status.ObservedGeneration = ext.Generation
+
+				reconciler.recordReleaseEvents(ext, spec, result)
+				Expect(drainEvents(recorder)).To(Equal(expected))
+			},
+			Entry("corrected drift", &helmClient.ReleaseResult{
+				Action: helmClient.ActionUpgraded, Revision: 2, ChartVersion: "0.1.0", PreviousChartVersion: "0.1.0",
+				Diff: diff, Rendered: true,
+			}, []string{
+				"Normal DriftCorrected Reverted changes made outside the operator to release default/ext (revision 2): " +
+					"0 added, 1 changed, 0 removed: changed Deployment/default/ext",
+			}),
+			Entry("nothing without drift", &helmClient.ReleaseResult{
+				Action: helmClient.ActionUnchanged, Revision: 1, ChartVersion: "0.1.0", Rendered: true,
+			}, nil),
+		)
+	})
+
+	Describe("recordRetriedFailure", func() {
+		// fail records the failures-th consecutive failure with err, after
+		// the previous one was recorded in status like recordOutcome does.
+		fail := func(class string, failures int32, err error) {
+			reconciler.recordRetriedFailure(ext, class, failures, time.Second, err)
+			ext.Status.FailureCount = failures
+			ext.Status.FailedGeneration = ext.Generation
+			ext.Status.LastError = err.Error()
+		}
+
+		It("reports a repeated error after 1, 2, 4 and 8 failures", func() {
+			err := errors.New("connection refused")
+			var reported []int32
+			for failures := int32(1); failures <= 9; failures++ {
+				fail(aiplatformv1alpha1.FailureTransient, failures, err)
+				if len(drainEvents(recorder)) > 0 {
+					reported = append(reported, failures)
+				}
+			}
+			Expect(reported).To(Equal([]int32{1, 2, 4, 8}))
+
+			fail(aiplatformv1alpha1.FailureTransient, 16, err)
+			Expect(drainEvents(recorder)).To(Equal([]string{
+				"Warning ReconcileFailed connection refused (16 consecutive failures), retrying in 1s",
+			}))
+		})
+
+		It("reports a new error right away", func() {
+			fail(aiplatformv1alpha1.FailureTransient, 1, errors.New("connection refused"))
+			fail(aiplatformv1alpha1.FailureTransient, 2, errors.New("connection refused"))
+			Expect(drainEvents(recorder)).To(HaveLen(2))
+
+			fail(aiplatformv1alpha1.FailureTransient, 3, errors.New("timed out"))
+			Expect(drainEvents(recorder)).To(Equal([]string{
+				"Warning ReconcileFailed timed out (3 consecutive failures), retrying in 1s",
+			}))
+		})
+
+		It("reports the first failure of a new generation", func() {
+			err := errors.New("connection refused")
+			fail(aiplatformv1alpha1.FailureTransient, 3, err)
+			Expect(drainEvents(recorder)).To(HaveLen(1))
+
+			ext.Generation++
+			fail(aiplatformv1alpha1.FailureTransient, 1, err)
+			Expect(drainEvents(recorder)).To(Equal([]string{
+				"Warning ReconcileFailed connection refused, retrying in 1s",
+			}))
+		})
+
+		It("reports dependency errors as a dependency that is not ready", func() {
+			fail(aiplatformv1alpha1.FailureDependency, 1, errors.New("ClusterRepo ext is not ready"))
+			Expect(drainEvents(recorder)).To(Equal([]string{
+				"Warning DependencyNotReady ClusterRepo ext is not ready, retrying in 1s",
+			}))
+		})
+	})
+})
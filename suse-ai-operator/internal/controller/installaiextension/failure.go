@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/infra/rancher"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// DefaultRetryBudget is the number of consecutive transient failures
+// retried before retries stop.
+const DefaultRetryBudget = 10
+
+// classifyError returns the failure class of an error returned by a
+// reconcile.
+func classifyError(err error) string {
+	var (
+		notReady       *rancher.DependencyNotReadyError
+		conflict       *rancher.FieldConflictError
+		secretNotReady *certs.SecretNotReadyError
+		secretType     *certs.SecretTypeError
+		mismatch       *helmClient.DigestMismatchError
+		unverified     *helmClient.VerificationError
+	)
+
+	switch {
+	case errors.Is(err, reconcile.TerminalError(nil)),
+		errors.As(err, &mismatch),
+		errors.As(err, &unverified),
+		helmClient.IsChartNotFound(err),
+		apierrors.IsInvalid(err),
+		apierrors.IsBadRequest(err):
+		return aiplatformv1alpha1.FailureTerminal
+
+	case errors.As(err, &notReady),
+		errors.As(err, &conflict),
+		errors.As(err, &secretNotReady),
+		errors.As(err, &secretType),
+		meta.IsNoMatchError(err),
+		apierrors.IsNotFound(err),
+		isPermissionDenied(err):
+		return aiplatformv1alpha1.FailureDependency
+	}
+
+	return aiplatformv1alpha1.FailureTransient
+}
+
+// retryDelay returns the delay before retrying after failures consecutive
+// failures: base doubled for every failure after the first, capped at maxDelay.
+func retryDelay(failures int32, base, maxDelay time.Duration) time.Duration {
+	if base <= 0 {
+		base = DefaultBackoffBase
+	}
+	if maxDelay < base {
+		maxDelay = base
+	}
+	delay := base
+	for i := int32(1); i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// retriesStopped reports whether the last failure of ext stopped retries
+// for its current generation, after a terminal error or once the retry
+// budget was spent.
+func retriesStopped(ext *aiplatformv1alpha1.InstallAIExtension) bool {
+	return ext.Status.FailureCount > 0 &&
+		ext.Status.FailedGeneration == ext.Generation &&
+		ext.Status.NextRetryTime == nil
+}
+
+// handleRetryRequest resets the failure count when the retry annotation
+// is set to a new token.
+func (r *InstallAIExtensionReconciler) handleRetryRequest(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+) error {
+
+	token := ext.Annotations[aiplatformv1alpha1.AnnotationRetry]
+	if token == "" || token == ext.Status.LastHandledRetry {
+		return nil
+	}
+
+	logging.FromContext(ctx, "retry").
+		WithValues(logging.KeyExtension, ext.Name).
+		Info("Retry requested, resetting the failure count", "token", token, "failures", ext.Status.FailureCount)
+	r.event(ext, corev1.EventTypeNormal, aiplatformv1alpha1.ReasonRetryRequested,
+		"Retry requested with token %q", token)
+
+	reset := func(status *aiplatformv1alpha1.InstallAIExtensionStatus) {
+		status.LastHandledRetry = token
+		clearFailure(status)
+	}
+	if err := r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		reset(&latest.Status)
+	}); err != nil {
+		return err
+	}
+	reset(&ext.Status)
+	return nil
+}
+
+// recordOutcome records the result of a reconcile of ext in its failure
+// status and decides when it is retried. Errors are not returned to
+// controller-runtime: transient and dependency errors are requeued with
+// backoff, terminal errors and spent retry budgets are not requeued until
+// the spec changes or a retry is requested.
+func (r *InstallAIExtensionReconciler) recordOutcome(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	result ctrl.Result,
+	err error,
+) (ctrl.Result, error) {
+
+	key := types.NamespacedName{Name: ext.Name}
+
+	if err == nil {
+		if ext.Status.FailureCount == 0 && ext.Status.LastError == "" {
+			return result, nil
+		}
+		return result, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			clearFailure(&latest.Status)
+		})
+	}
+
+	log := logging.FromContext(ctx, "retry").WithValues(logging.KeyExtension, ext.Name)
+
+	failures := ext.Status.FailureCount + 1
+	if ext.Status.FailedGeneration != ext.Generation {
+		failures = 1
+	}
+
+	class := classifyError(err)
+	budget := int32(r.RetryBudget)
+	exhausted := class == aiplatformv1alpha1.FailureTransient && budget > 0 && failures >= budget
+
+	var nextRetry *metav1.Time
+	var delay time.Duration
+	if class != aiplatformv1alpha1.FailureTerminal && !exhausted {
+		delay = retryDelay(failures, r.RetryBackoffBase, r.RetryBackoffMax)
+		t := metav1.NewTime(time.Now().Add(delay))
+		nextRetry = &t
+	}
+
+	stopReason := aiplatformv1alpha1.ReasonReconcileFailed
+	if exhausted {
+		stopReason = aiplatformv1alpha1.ReasonRetryBudgetExhausted
+	}
+
+	if nextRetry != nil {
+		log.Error(err, "Reconcile failed, retrying",
+			"class", class, "failures", failures, "retryIn", delay)
+		r.recordRetriedFailure(ext, class, failures, delay, err)
+	} else {
+		message := fmt.Sprintf("%s error, not retrying until the spec changes: %v", class, err)
+		if exhausted {
+			message = fmt.Sprintf("Retry budget of %d attempts spent: %v", budget, err)
+		}
+		log.Error(err, "Reconcile failed, retries stopped", "class", class, "failures", failures)
+		r.event(ext, corev1.EventTypeWarning, stopReason, "%s", message)
+	}
+
+	if statusErr := r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		latest.Status.FailureCount = failures
+		latest.Status.LastError = err.Error()
+		latest.Status.FailureClass = class
+		latest.Status.FailedGeneration = ext.Generation
+		latest.Status.NextRetryTime = nextRetry
+		if nextRetry == nil {
+			installaiextension.SetPhase(latest, aiplatformv1alpha1.PhaseFailed, stopReason, err.Error())
+		}
+	}); statusErr != nil {
+		return ctrl.Result{}, errors.Join(err, statusErr)
+	}
+
+	if nextRetry == nil {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: delay}, nil
+}
+
+func clearFailure(status *aiplatformv1alpha1.InstallAIExtensionStatus) {
+	status.FailureCount = 0
+	status.LastError = ""
+	status.FailureClass = ""
+	status.FailedGeneration = 0
+	status.NextRetryTime = nil
+}
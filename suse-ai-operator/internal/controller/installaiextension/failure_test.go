@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/infra/rancher"
+)
+
+var _ = Describe("Reconcile failures", func() {
+	gr := schema.GroupResource{Group: "catalog.cattle.io", Resource: "uiplugins"}
+
+	DescribeTable("classifyError",
+		func(err error, expected string) {
+			Expect(classifyError(err)).To(Equal(expected))
+		},
+		Entry("a plain error", errors.New("connection reset by peer"), aiplatformv1alpha1.FailureTransient),
+		Entry("a server timeout", apierrors.NewTimeoutError("timed out", 1), aiplatformv1alpha1.FailureTransient),
+		Entry("a terminal error", reconcile.TerminalError(errors.New("invalid spec")), aiplatformv1alpha1.FailureTerminal),
+		Entry("a digest mismatch",
+			fmt.Errorf("pull failed: %w", &helmClient.DigestMismatchError{Ref: "oci://r/ext", Expected: "sha256:0", Actual: "sha256:1"}),
+			aiplatformv1alpha1.FailureTerminal),
+		Entry("a failed verification",
+			&helmClient.VerificationError{Provider: helmClient.VerifyCosign, Ref: "oci://r/ext", Err: errors.New("no signature")},
+			aiplatformv1alpha1.FailureTerminal),
+		Entry("a missing chart",
+			&helmClient.ChartNotFoundError{Ref: "oci://r/ext", Version: "9.9.9", Err: errors.New("not found")},
+			aiplatformv1alpha1.FailureTerminal),
+		Entry("an invalid object",
+			apierrors.NewInvalid(schema.GroupKind{Kind: "UIPlugin"}, "ext", field.ErrorList{field.Required(field.NewPath("spec"), "")}),
+			aiplatformv1alpha1.FailureTerminal),
+		Entry("a bad request", apierrors.NewBadRequest("bad"), aiplatformv1alpha1.FailureTerminal),
+		Entry("a dependency that is not ready",
+			fmt.Errorf("register: %w", &rancher.DependencyNotReadyError{Dependency: "ClusterRepo"}),
+			aiplatformv1alpha1.FailureDependency),
+		Entry("a field owned by another manager",
+			&rancher.FieldConflictError{Kind: "UIPlugin", Name: "ext", Conflicts: []string{".spec.plugin"}},
+			aiplatformv1alpha1.FailureDependency),
+		Entry("a Secret that is not ready",
+			&certs.SecretNotReadyError{Namespace: "default", Name: "ext-tls", Key: "ca.crt"},
+			aiplatformv1alpha1.FailureDependency),
+		Entry("a TLS Secret of another type",
+			&certs.SecretTypeError{Namespace: "default", Name: "ext-tls", Type: "Opaque"},
+			aiplatformv1alpha1.FailureDependency),
+		Entry("a missing CRD",
+			&meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "catalog.cattle.io", Kind: "UIPlugin"}},
+			aiplatformv1alpha1.FailureDependency),
+		Entry("a missing object", apierrors.NewNotFound(gr, "ext"), aiplatformv1alpha1.FailureDependency),
+		Entry("a forbidden request", apierrors.NewForbidden(gr, "ext", errors.New("denied")), aiplatformv1alpha1.FailureDependency),
+		Entry("a forbidden request reported by Helm",
+			errors.New(`deployments.apps is forbidden: User "system:serviceaccount:default:ext" cannot create resource`),
+			aiplatformv1alpha1.FailureDependency),
+	)
+
+	DescribeTable("retryDelay",
+		func(failures int32, base, maxDelay, expected time.Duration) {
+			Expect(retryDelay(failures, base, maxDelay)).To(Equal(expected))
+		},
+		Entry("waits base after the first failure", int32(1), time.Second, time.Minute, time.Second),
+		Entry("doubles for every further failure", int32(4), time.Second, time.Minute, 8*time.Second),
+		Entry("is capped at the maximum", int32(10), time.Second, time.Minute, time.Minute),
+		Entry("stays capped after many failures", int32(1000), time.Second, time.Minute, time.Minute),
+		Entry("uses the default base when unset", int32(1), time.Duration(0), time.Minute, DefaultBackoffBase),
+		Entry("never waits less than base", int32(3), time.Minute, time.Second, time.Minute),
+	)
+
+	Describe("recordOutcome", func() {
+		const (
+			base     = time.Second
+			maxDelay = time.Minute
+		)
+		transientErr := errors.New("connection reset by peer")
+
+		var (
+			ctx        context.Context
+			reconciler *InstallAIExtensionReconciler
+			recorder   *record.FakeRecorder
+		)
+
+		// setUp stores ext and returns a reconciler with a budget of three
+		// retries.
+		setUp := func(ext *aiplatformv1alpha1.InstallAIExtension) {
+			scheme := runtime.NewScheme()
+			Expect(aiplatformv1alpha1.AddToScheme(scheme)).To(Succeed())
+			recorder = record.NewFakeRecorder(10)
+			reconciler = &InstallAIExtensionReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(ext).
+					WithStatusSubresource(ext).
+					Build(),
+				Scheme:           scheme,
+				Recorder:         recorder,
+				RetryBackoffBase: base,
+				RetryBackoffMax:  maxDelay,
+				RetryBudget:      3,
+			}
+		}
+
+		stored := func() *aiplatformv1alpha1.InstallAIExtension {
+			var ext aiplatformv1alpha1.InstallAIExtension
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "ext"}, &ext)).To(Succeed())
+			return &ext
+		}
+
+		newExtension := func(status aiplatformv1alpha1.InstallAIExtensionStatus) *aiplatformv1alpha1.InstallAIExtension {
+			return &aiplatformv1alpha1.InstallAIExtension{
+				ObjectMeta: metav1.ObjectMeta{Name: "ext", Generation: 2},
+				Status:     status,
+			}
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+		})
+
+		DescribeTable("retries",
+			func(status aiplatformv1alpha1.InstallAIExtensionStatus, err error, failures int32, class string, delay time.Duration) {
+				ext := newExtension(status)
+				setUp(ext)
+
+				result, returned := reconciler.recordOutcome(ctx, ext, ctrl.Result{}, err)
+				Expect(returned).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(delay))
+
+				latest := stored()
+				Expect(latest.Status.FailureCount).To(Equal(failures))
+				Expect(latest.Status.FailureClass).To(Equal(class))
+				Expect(latest.Status.LastError).To(Equal(err.Error()))
+				Expect(latest.Status.FailedGeneration).To(Equal(int64(2)))
+				Expect(latest.Status.NextRetryTime).NotTo(BeNil())
+				Expect(latest.Status.Phase).NotTo(Equal(aiplatformv1alpha1.PhaseFailed))
+				Expect(recorder.Events).To(Receive(HavePrefix("Warning")))
+			},
+			Entry("a first transient failure after base",
+				aiplatformv1alpha1.InstallAIExtensionStatus{},
+				transientErr, int32(1), aiplatformv1alpha1.FailureTransient, base),
+			Entry("a repeated failure with a doubled delay",
+				aiplatformv1alpha1.InstallAIExtensionStatus{FailureCount: 1, FailedGeneration: 2, LastError: "timeout"},
+				transientErr, int32(2), aiplatformv1alpha1.FailureTransient, 2*base),
+			Entry("a failure of a new generation as the first one",
+				aiplatformv1alpha1.InstallAIExtensionStatus{FailureCount: 2, FailedGeneration: 1},
+				transientErr, int32(1), aiplatformv1alpha1.FailureTransient, base),
+			Entry("a dependency failure beyond the retry budget",
+				aiplatformv1alpha1.InstallAIExtensionStatus{FailureCount: 5, FailedGeneration: 2},
+				&rancher.DependencyNotReadyError{Dependency: "ClusterRepo"},
+				int32(6), aiplatformv1alpha1.FailureDependency, 32*base),
+		)
+
+		DescribeTable("stops retrying",
+			func(status aiplatformv1alpha1.InstallAIExtensionStatus, err error, class, reason string) {
+				ext := newExtension(status)
+				setUp(ext)
+
+				result, returned := reconciler.recordOutcome(ctx, ext, ctrl.Result{}, err)
+				Expect(returned).NotTo(HaveOccurred())
+				Expect(result).To(Equal(ctrl.Result{}))
+
+				latest := stored()
+				Expect(latest.Status.FailureClass).To(Equal(class))
+				Expect(latest.Status.NextRetryTime).To(BeNil())
+				Expect(latest.Status.Phase).To(Equal(aiplatformv1alpha1.PhaseFailed))
+				Expect(latest.Status.PhaseHistory).NotTo(BeEmpty())
+				Expect(latest.Status.PhaseHistory[len(latest.Status.PhaseHistory)-1].Reason).To(Equal(reason))
+				Expect(retriesStopped(latest)).To(BeTrue())
+				Expect(recorder.Events).To(Receive(HavePrefix("Warning " + reason)))
+			},
+			Entry("on a terminal error",
+				aiplatformv1alpha1.InstallAIExtensionStatus{},
+				reconcile.TerminalError(errors.New("invalid spec")),
+				aiplatformv1alpha1.FailureTerminal, aiplatformv1alpha1.ReasonReconcileFailed),
+			Entry("once the retry budget is spent",
+				aiplatformv1alpha1.InstallAIExtensionStatus{FailureCount: 2, FailedGeneration: 2},
+				transientErr,
+				aiplatformv1alpha1.FailureTransient, aiplatformv1alpha1.ReasonRetryBudgetExhausted),
+		)
+
+		It("clears the failure once a reconcile succeeds", func() {
+			nextRetry := metav1.Now()
+			ext := newExtension(aiplatformv1alpha1.InstallAIExtensionStatus{
+				FailureCount:     3,
+				FailedGeneration: 2,
+				FailureClass:     aiplatformv1alpha1.FailureTransient,
+				LastError:        "timeout",
+				NextRetryTime:    &nextRetry,
+			})
+			setUp(ext)
+
+			result, err := reconciler.recordOutcome(ctx, ext, ctrl.Result{RequeueAfter: time.Hour}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{RequeueAfter: time.Hour}))
+
+			latest := stored()
+			Expect(latest.Status.FailureCount).To(BeZero())
+			Expect(latest.Status.FailureClass).To(BeEmpty())
+			Expect(latest.Status.LastError).To(BeEmpty())
+			Expect(latest.Status.NextRetryTime).To(BeNil())
+		})
+	})
+})
@@ -2,9 +2,13 @@ package controller
 
 import (
 	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/SUSE/suse-ai-operator/internal/infra/rancher"
 	"github.com/SUSE/suse-ai-operator/internal/logging"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
@@ -13,6 +17,11 @@ import (
 
 const finalizerName = "ai-platform.suse.com/finalizer"
 
+// finalizerRequeueDelay is how long the reconcile that added the finalizer
+// waits before installing. Adding the finalizer does not change the
+// generation, so its update event is filtered out.
+const finalizerRequeueDelay = time.Second
+
 func (r *InstallAIExtensionReconciler) ensureFinalizer(
 	ctx context.Context,
 	ext *aiplatformv1alpha1.InstallAIExtension,
@@ -30,6 +39,7 @@ func (r *InstallAIExtensionReconciler) ensureFinalizer(
 	if err := r.Update(ctx, ext); err != nil {
 		return false, err
 	}
+	r.event(ext, corev1.EventTypeNormal, eventFinalizerAdded, "Added finalizer %s", finalizerName)
 
 	return true, nil
 }
@@ -51,15 +61,21 @@ func (r *InstallAIExtensionReconciler) handleDeletion(
 
 	log.Info("Handling resource deletion")
 
-	if err := helm.DeleteRelease(ctx, releaseName); err != nil {
+	if err := uninstallRelease(ctx, ext, helm, releaseName); err != nil {
 		log.Error(err, "Failed to delete Helm release")
 		return err
 	}
+	r.event(ext, corev1.EventTypeNormal, eventUninstalled, "Uninstalled release %s/%s", namespace, releaseName)
 
-	if err := rancherMgr.Cleanup(ctx, ext, namespace); err != nil {
+	changes, err := rancherMgr.Cleanup(ctx, ext, namespace)
+	r.recordRancherChanges(ext, changes)
+	if err != nil {
 		log.Error(err, "Failed to cleanup Rancher resources")
 		return err
 	}
+	r.event(ext, corev1.EventTypeNormal, eventCleanupCompleted, "Removed the release and Rancher resources of the extension")
+
+	metrics.DeleteExtension(ext.Name)
 
 	return r.removeFinalizer(ctx, ext)
 }
@@ -80,6 +96,7 @@ func (r *InstallAIExtensionReconciler) removeFinalizer(
 		}
 		return err
 	}
+	r.event(ext, corev1.EventTypeNormal, eventFinalizerRemoved, "Removed finalizer %s", finalizerName)
 
 	return nil
 }
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("Finalizer", func() {
+	It("requeues the reconcile that added it", func() {
+		ctx := context.Background()
+		ext := newInstalledExtension()
+		ext.Finalizers = nil
+		ext.Status = aiplatformv1alpha1.InstallAIExtensionStatus{}
+		reconciler, recorder := newTestReconciler(&fakeHelm{}, ext)
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "ext"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: finalizerRequeueDelay}))
+
+		var latest aiplatformv1alpha1.InstallAIExtension
+		Expect(reconciler.Get(ctx, types.NamespacedName{Name: "ext"}, &latest)).To(Succeed())
+		Expect(latest.Finalizers).To(ConsistOf(finalizerName))
+		Expect(latest.Status.Phase).To(Equal(aiplatformv1alpha1.PhasePending))
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal " + eventFinalizerAdded)))
+	})
+})
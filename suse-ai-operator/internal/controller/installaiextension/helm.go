@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"helm.sh/helm/v3/pkg/cli"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+)
+
+func (r *InstallAIExtensionReconciler) newHelmClient(
+	settings *cli.EnvSettings,
+	cache *helmClient.ChartCache,
+	opts ...helmClient.Option,
+) (helmClient.HelmClient, error) {
+	if r.NewHelmClient == nil {
+		return helmClient.New(settings, cache, opts...)
+	}
+	return r.NewHelmClient(settings, cache, opts...)
+}
+
+// releaseOptions maps spec.helm.options onto the Helm client options,
+// filling in the operator's Helm defaults, then the client defaults, for
+// unset fields.
+func releaseOptions(defaults, opts *aiplatformv1alpha1.HelmOptions) helmClient.ReleaseOptions {
+	out := helmClient.DefaultReleaseOptions()
+	applyHelmOptions(&out, defaults)
+	applyHelmOptions(&out, opts)
+	return out
+}
+
+// applyHelmOptions overrides the fields of out that opts sets.
+func applyHelmOptions(out *helmClient.ReleaseOptions, opts *aiplatformv1alpha1.HelmOptions) {
+	if opts == nil {
+		return
+	}
+
+	if opts.Timeout != nil {
+		out.Timeout = opts.Timeout.Duration
+	}
+	if opts.MaxHistory != nil {
+		out.MaxHistory = int(*opts.MaxHistory)
+	}
+
+	setBool(&out.Wait, opts.Wait)
+	setBool(&out.WaitForJobs, opts.WaitForJobs)
+	setBool(&out.Atomic, opts.Atomic)
+	setBool(&out.SkipCRDs, opts.SkipCRDs)
+	setBool(&out.DisableHooks, opts.DisableHooks)
+	setBool(&out.Force, opts.Force)
+	setBool(&out.CleanupOnFail, opts.CleanupOnFail)
+	setBool(&out.CreateNamespace, opts.CreateNamespace)
+	setBool(&out.DependencyUpdate, opts.DependencyUpdate)
+}
+
+func setBool(dst, value *bool) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+// recoveryPolicy maps spec.helm.remediation onto the policy used to recover
+// stuck and failed releases.
+func recoveryPolicy(rem *aiplatformv1alpha1.HelmRemediation) helmClient.RecoveryPolicy {
+	out := helmClient.RecoveryPolicy{
+		StaleLockTimeout: helmClient.DefaultStaleLockTimeout,
+		Strategy:         helmClient.RecoveryRollback,
+	}
+	if rem == nil {
+		return out
+	}
+
+	if rem.StuckReleaseTimeout != nil {
+		out.StaleLockTimeout = rem.StuckReleaseTimeout.Duration
+	}
+	if rem.StuckReleaseStrategy != "" {
+		out.Strategy = helmClient.RecoveryStrategy(rem.StuckReleaseStrategy)
+	}
+
+	return out
+}
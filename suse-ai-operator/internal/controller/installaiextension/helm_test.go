@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+)
+
+var _ = Describe("releaseOptions", func() {
+	withDefaults := func(modify func(*helmClient.ReleaseOptions)) helmClient.ReleaseOptions {
+		out := helmClient.DefaultReleaseOptions()
+		modify(&out)
+		return out
+	}
+
+	DescribeTable("maps spec.helm.options",
+		func(opts *aiplatformv1alpha1.HelmOptions, expected helmClient.ReleaseOptions) {
+			Expect(releaseOptions(nil, opts)).To(Equal(expected))
+		},
+		Entry("uses the Helm defaults without options",
+			nil,
+			helmClient.ReleaseOptions{Timeout: 10 * time.Minute, Wait: true, MaxHistory: 10}),
+		Entry("uses the Helm defaults for unset fields",
+			&aiplatformv1alpha1.HelmOptions{Atomic: ptr.To(true)},
+			withDefaults(func(o *helmClient.ReleaseOptions) { o.Atomic = true })),
+		Entry("overrides the timeout and history",
+			&aiplatformv1alpha1.HelmOptions{
+				Timeout:    &metav1.Duration{Duration: time.Minute},
+				MaxHistory: ptr.To[int32](0),
+			},
+			withDefaults(func(o *helmClient.ReleaseOptions) {
+				o.Timeout = time.Minute
+				o.MaxHistory = 0
+			})),
+		Entry("turns off waiting",
+			&aiplatformv1alpha1.HelmOptions{Wait: ptr.To(false)},
+			withDefaults(func(o *helmClient.ReleaseOptions) { o.Wait = false })),
+	)
+	DescribeTable("layers spec.helm.options over the operator defaults",
+		func(defaults, opts *aiplatformv1alpha1.HelmOptions, expected helmClient.ReleaseOptions) {
+			Expect(releaseOptions(defaults, opts)).To(Equal(expected))
+		},
+		Entry("uses the operator defaults without options",
+			&aiplatformv1alpha1.HelmOptions{Atomic: ptr.To(true), MaxHistory: ptr.To[int32](3)},
+			nil,
+			withDefaults(func(o *helmClient.ReleaseOptions) {
+				o.Atomic = true
+				o.MaxHistory = 3
+			})),
+		Entry("keeps operator defaults the options do not set",
+			&aiplatformv1alpha1.HelmOptions{Atomic: ptr.To(true)},
+			&aiplatformv1alpha1.HelmOptions{Force: ptr.To(true)},
+			withDefaults(func(o *helmClient.ReleaseOptions) {
+				o.Atomic = true
+				o.Force = true
+			})),
+		Entry("lets the options turn off an operator default",
+			&aiplatformv1alpha1.HelmOptions{Atomic: ptr.To(true), Wait: ptr.To(false)},
+			&aiplatformv1alpha1.HelmOptions{Atomic: ptr.To(false), Wait: ptr.To(true)},
+			helmClient.DefaultReleaseOptions()),
+	)
+})
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;impersonate
+
+// serviceAccountUser returns the user Helm impersonates for ext, or an
+// empty string to use the operator's own credentials.
+func serviceAccountUser(ext *aiplatformv1alpha1.InstallAIExtension, namespace string) string {
+	if ext.Spec.ServiceAccountName == "" {
+		return ""
+	}
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, ext.Spec.ServiceAccountName)
+}
+
+// rejectWithoutServiceAccount reports an extension without
+// spec.serviceAccountName while the operator requires one. Nothing is
+// installed until the spec changes.
+func (r *InstallAIExtensionReconciler) rejectWithoutServiceAccount(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+) error {
+
+	log := logging.FromContext(ctx, "impersonation").WithValues(logging.KeyExtension, ext.Name)
+
+	const message = "a service account is required by the operator, set spec.serviceAccountName"
+	log.Info("Extension has no service account, not installing")
+
+	r.event(ext, corev1.EventTypeWarning, aiplatformv1alpha1.ReasonServiceAccountRequired, "%s", message)
+
+	return r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		installaiextension.SetPhase(latest, aiplatformv1alpha1.PhaseFailed,
+			aiplatformv1alpha1.ReasonServiceAccountRequired, message)
+		setCondition(latest, aiplatformv1alpha1.ConditionReleased, metav1.ConditionFalse,
+			aiplatformv1alpha1.ReasonServiceAccountRequired, message)
+	})
+}
+
+// serviceAccountExists reports whether the service account of ext exists
+// in namespace. A missing one is recorded in the Released condition.
+func (r *InstallAIExtensionReconciler) serviceAccountExists(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	namespace string,
+) (bool, error) {
+
+	name := ext.Spec.ServiceAccountName
+	if name == "" {
+		return true, nil
+	}
+
+	var sa corev1.ServiceAccount
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &sa)
+	if err == nil {
+		return true, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	message := fmt.Sprintf("service account %s/%s does not exist", namespace, name)
+	logging.FromContext(ctx, "impersonation").
+		WithValues(logging.KeyExtension, ext.Name).
+		Info("Service account not found, waiting for it", logging.KeyNamespace, namespace, logging.KeyName, name)
+	r.recordWaiting(ext, "Waiting for %s", message)
+
+	return false, r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		latest.Status.Message = message
+		setCondition(latest, aiplatformv1alpha1.ConditionReleased, metav1.ConditionFalse,
+			aiplatformv1alpha1.ReasonServiceAccountNotFound, message)
+	})
+}
+
+// permissionDenied reports whether err is the API server refusing a
+// request of the impersonated service account.
+func permissionDenied(ext *aiplatformv1alpha1.InstallAIExtension, err error) bool {
+	return ext.Spec.ServiceAccountName != "" && isPermissionDenied(err)
+}
+
+// isPermissionDenied reports whether err is the API server refusing a
+// request. Helm wraps some API errors as text, so the message is checked
+// as well.
+func isPermissionDenied(err error) bool {
+	return err != nil && (apierrors.IsForbidden(err) || strings.Contains(err.Error(), "is forbidden: User"))
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("Impersonation", func() {
+	DescribeTable("serviceAccountUser",
+		func(serviceAccount, expected string) {
+			ext := &aiplatformv1alpha1.InstallAIExtension{}
+			ext.Spec.ServiceAccountName = serviceAccount
+
+			Expect(serviceAccountUser(ext, "ai")).To(Equal(expected))
+		},
+		Entry("uses the operator's credentials without a service account", "", ""),
+		Entry("impersonates the service account in the extension namespace", "installer",
+			"system:serviceaccount:ai:installer"),
+	)
+
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "ext",
+		errors.New("cannot create resource"))
+
+	DescribeTable("isPermissionDenied",
+		func(err error, expected bool) {
+			Expect(isPermissionDenied(err)).To(Equal(expected))
+		},
+		Entry("for no error", nil, false),
+		Entry("for a Forbidden API error", forbidden, true),
+		Entry("for a wrapped Forbidden API error", fmt.Errorf("failed to install: %w", forbidden), true),
+		Entry("for a Forbidden API error Helm flattened to text",
+			errors.New(`deployments.apps "ext" is forbidden: User "system:serviceaccount:ai:installer" cannot create resource`),
+			true),
+		Entry("for another error", errors.New("connection refused"), false),
+		Entry("for a not found error",
+			apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "ext"), false),
+	)
+
+	DescribeTable("permissionDenied",
+		func(serviceAccount string, err error, expected bool) {
+			ext := &aiplatformv1alpha1.InstallAIExtension{}
+			ext.Spec.ServiceAccountName = serviceAccount
+
+			Expect(permissionDenied(ext, err)).To(Equal(expected))
+		},
+		Entry("reports a Forbidden error of the service account", "installer", forbidden, true),
+		Entry("ignores Forbidden errors of the operator's own credentials", "", forbidden, false),
+		Entry("ignores other errors of the service account", "installer", errors.New("timed out"), false),
+		Entry("ignores success", "installer", nil, false),
+	)
+})
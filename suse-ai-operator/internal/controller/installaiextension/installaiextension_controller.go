@@ -18,33 +18,82 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	urlpkg "net/url"
 
 	"github.com/go-logr/logr"
 	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/config"
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
 	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
-	"github.com/SUSE/suse-ai-operator/internal/infra/kubernetes"
+	"github.com/SUSE/suse-ai-operator/internal/infra/pluginserver"
 	"github.com/SUSE/suse-ai-operator/internal/infra/rancher"
 	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+	"github.com/SUSE/suse-ai-operator/internal/policy"
 )
 
 // InstallAIExtensionReconciler reconciles a InstallAIExtension object
 type InstallAIExtensionReconciler struct {
 	client.Client
-	Scheme             *runtime.Scheme
-	Log                logr.Logger
-	Recorder           record.EventRecorder
-	Config             *rest.Config
-	ExtensionNamespace string
+	Scheme   *runtime.Scheme
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	Config   *rest.Config
+	Prober   *pluginserver.Prober
+	// Settings holds the operator settings, read on every reconcile so
+	// OperatorConfig changes apply without a restart.
+	Settings *config.Store
+	// SettingsChanged receives an event whenever the OperatorConfig
+	// applied new settings, and reconciles every extension. Nil only
+	// picks them up on the next reconcile of each extension.
+	SettingsChanged <-chan event.GenericEvent
+	// ChartCache is shared by all reconciles. Nil disables caching.
+	ChartCache *helmClient.ChartCache
+	// IndexCache keeps extension index files across reconciles while
+	// the index cache TTL is set.
+	IndexCache *helmClient.IndexCache
+	// MaxConcurrentReconciles is how many extensions are reconciled at
+	// once. Defaults to 1.
+	MaxConcurrentReconciles int
+	// RateLimiter delays the retries of failed deletions. Nil uses the
+	// controller-runtime default.
+	RateLimiter workqueue.TypedRateLimiter[reconcile.Request]
+	// RetryBackoffBase and RetryBackoffMax bound the exponential delay
+	// between retries of failed reconciles, reported in
+	// status.nextRetryTime.
+	RetryBackoffBase time.Duration
+	RetryBackoffMax  time.Duration
+	// RetryBudget is how many consecutive transient failures are retried
+	// before retries stop. Zero retries forever.
+	RetryBudget int
+	// Policies loads the operator's registry and repository policy. Nil
+	// allows every chart.
+	Policies *policy.Loader
+	// NewHelmClient creates the Helm client of a reconcile. Nil uses
+	// helmClient.New.
+	NewHelmClient func(*cli.EnvSettings, *helmClient.ChartCache, ...helmClient.Option) (helmClient.HelmClient, error)
 }
 
 // +kubebuilder:rbac:groups=ai-platform.suse.com,resources=installaiextensions,verbs=get;list;watch;create;update;patch;delete
@@ -56,6 +105,11 @@ type InstallAIExtensionReconciler struct {
 // +kubebuilder:rbac:groups=catalog.cattle.io,resources=clusterrepos/status,verbs=get;update;patch
 
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -67,23 +121,50 @@ type InstallAIExtensionReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
 func (r *InstallAIExtensionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := r.Log.WithValues("InstallAIExtension", req.NamespacedName)
+	var ext aiplatformv1alpha1.InstallAIExtension
+	if err := r.Get(ctx, req.NamespacedName, &ext); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Deletion is always retried by controller-runtime, so a failing
+	// uninstall never leaves the finalizer behind for good.
+	if !ext.DeletionTimestamp.IsZero() {
+		return r.reconcile(ctx, req)
+	}
+
+	if err := r.handleRetryRequest(ctx, &ext); err != nil {
+		return ctrl.Result{}, err
+	}
+	if retriesStopped(&ext) && !triggerPending(&ext) {
+		logging.Debug(logging.FromContext(ctx, "retry")).Info("Retries stopped for this generation, skipping",
+			logging.KeyExtension, ext.Name, "failureClass", ext.Status.FailureClass)
+		return ctrl.Result{}, nil
+	}
 
-	namespace := r.ExtensionNamespace
+	result, err := r.reconcile(ctx, req)
+	return r.recordOutcome(ctx, &ext, result, err)
+}
 
-	var svcURL string
+// reconcile moves the extension towards its spec. Errors are classified
+// and retried by Reconcile.
+func (r *InstallAIExtensionReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("InstallAIExtension", req.NamespacedName)
+
+	settings := r.Settings.Get()
 
 	var installExt aiplatformv1alpha1.InstallAIExtension
 	if err := r.Get(ctx, req.NamespacedName, &installExt); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	namespace := installaiextension.Namespace(&installExt, settings.ExtensionNamespace)
+
 	releaseName := installExt.Spec.Helm.Name
 	chartVersion := installExt.Spec.Helm.Version
 	values, err := helmClient.ConvertHelmValues(installExt.Spec.Helm.Values)
 	if err != nil {
 		log.Error(err, "failed to convert Helm values")
-		return ctrl.Result{}, err
+		return ctrl.Result{}, reconcile.TerminalError(err)
 	}
 
 	chart := ""
@@ -92,29 +173,36 @@ func (r *InstallAIExtensionReconciler) Reconcile(ctx context.Context, req ctrl.R
 
 	u, err := urlpkg.Parse(url)
 	if err != nil {
-		log.Error(err, "invalid helm url %q", url)
+		log.Error(err, "invalid helm url", "url", url)
+		return ctrl.Result{}, reconcile.TerminalError(err)
 	}
 
 	switch u.Scheme {
 	case "oci", "https":
 		chart = url
 	default:
-		log.Error(err, "unsupported helm url scheme: %s", u.Scheme)
-		return ctrl.Result{}, err
+		err := fmt.Errorf("unsupported helm url scheme %q", u.Scheme)
+		log.Error(err, "invalid helm url", "url", url)
+		return ctrl.Result{}, reconcile.TerminalError(err)
 	}
 
-	settings := cli.New()
-	settings.SetNamespace(namespace)
+	helmSettings := cli.New()
+	helmSettings.SetNamespace(namespace)
+	helmSettings.KubeAsUser = serviceAccountUser(&installExt, namespace)
 
-	helm, err := helmClient.New(settings)
+	helm, err := r.newHelmClient(helmSettings, r.ChartCache, helmClient.WithTransport(settings.Transport))
 	if err != nil {
 		log.Error(err, "failed to create Helm client")
 		return ctrl.Result{}, err
 	}
 
-	rancherMgr := rancher.NewManager(r.Client, r.Scheme)
+	rancherMgr := rancher.NewManager(r.Client, r.Scheme, r.indexCache(settings))
 
 	if !installExt.ObjectMeta.DeletionTimestamp.IsZero() {
+		if err := r.enterPhase(ctx, &installExt, aiplatformv1alpha1.PhaseDeleting, "DeletionRequested",
+			"Uninstalling the extension"); err != nil {
+			return ctrl.Result{}, err
+		}
 		if err := r.handleDeletion(
 			ctx,
 			&installExt,
@@ -133,68 +221,208 @@ func (r *InstallAIExtensionReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 	if added {
-		return ctrl.Result{Requeue: true}, nil
+		return ctrl.Result{RequeueAfter: finalizerRequeueDelay}, r.enterPhase(ctx, &installExt, aiplatformv1alpha1.PhasePending, "Created",
+			"Waiting for the operator to install the extension")
 	}
 
-	err = helm.EnsureRelease(ctx, helmClient.ReleaseSpec{
-		Name:      releaseName,
-		Namespace: namespace,
-		ChartRef:  chart,
-		Version:   chartVersion,
-		Values:    values,
-	})
-	if err != nil {
+	if suspended, err := r.reconcileSuspend(ctx, &installExt); suspended || err != nil {
 		return ctrl.Result{}, err
 	}
 
-	svc, err := kubernetes.ServiceForHelmRelease(ctx, r.Client, namespace, releaseName)
+	if rolloutPending(&installExt) && !planMode(&installExt) {
+		if err := r.enterPhase(ctx, &installExt, aiplatformv1alpha1.PhaseResolving, "SpecChanged",
+			"Checking the chart and loading what the release needs"); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if allowed, err := r.enforcePolicy(ctx, &installExt); !allowed || err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if settings.RequireChartVerification && installExt.Spec.Helm.Verify == nil {
+		return ctrl.Result{}, r.rejectUnverified(ctx, &installExt)
+	}
+
+	if settings.RequireServiceAccount && installExt.Spec.ServiceAccountName == "" {
+		return ctrl.Result{}, r.rejectWithoutServiceAccount(ctx, &installExt)
+	}
+
+	if found, err := r.serviceAccountExists(ctx, &installExt, namespace); err != nil || !found {
+		return ctrl.Result{RequeueAfter: readinessPollInterval}, err
+	}
+
+	verify, err := r.chartVerification(ctx, &installExt, namespace)
 	if err != nil {
-		msg := fmt.Sprintf("Error to fetch services")
-		log.Info(msg)
+		log.Error(err, "failed to load chart verification key")
+		return ctrl.Result{}, err
+	}
+
+	remediation := installExt.Spec.Helm.Remediation
+
+	releaseSpec := helmClient.ReleaseSpec{
+		Name:              releaseName,
+		Namespace:         namespace,
+		ChartRef:          chart,
+		Version:           chartVersion,
+		Digest:            installExt.Spec.Helm.Digest,
+		Verify:            verify,
+		Values:            values,
+		Options:           releaseOptions(settings.HelmDefaults, installExt.Spec.Helm.Options),
+		RollbackOnFailure: remediation != nil && remediation.RollbackOnUpgradeFailure,
+		Recovery:          recoveryPolicy(remediation),
 	}
 
-	svcName, svcNamespace, svcPort, err := installaiextension.ServiceEndpoint(svc)
+	if planMode(&installExt) {
+		return ctrl.Result{}, r.reconcilePlan(ctx, &installExt, helm, rancherMgr, releaseSpec)
+	}
+
+	if err := r.reinstall(ctx, &installExt, helm, releaseName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	reconcileAt, forced := reconcileRequested(&installExt)
+
+	if rolloutPending(&installExt) {
+		phase, reason := releasePhase(&installExt)
+		if err := r.enterPhase(ctx, &installExt, phase, reason,
+			fmt.Sprintf("Applying chart %s", installExt.Spec.Helm.URL)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	extVersion, err := r.reconcileRelease(ctx, &installExt, helm, releaseSpec, settings.DriftCheckInterval)
 	if err != nil {
-		msg := fmt.Sprintf("Error to fetch svc info")
-		log.Info(msg)
+		var locked *helmClient.ReleaseLockedError
+		if errors.As(err, &locked) {
+			return ctrl.Result{RequeueAfter: releaseLockPollInterval}, nil
+		}
+		return ctrl.Result{}, err
 	}
 
-	svcURL = fmt.Sprintf("http://%s.%s:%d", svcName, svcNamespace, svcPort)
+	if installExt.Status.Phase != aiplatformv1alpha1.PhaseReady && installExt.Status.Phase != aiplatformv1alpha1.PhaseDegraded {
+		if err := r.enterPhase(ctx, &installExt, aiplatformv1alpha1.PhaseRegistering, "ReleaseDeployed",
+			"Waiting for the extension server and registering it in Rancher"); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Register the version that is actually running, which differs from
+	// the spec after a rolled back upgrade.
+	registered := installExt.DeepCopy()
+	registered.Spec.Extension.Version = extVersion
 
-	if err := rancherMgr.Ensure(ctx, &installExt, svcURL, namespace); err != nil {
+	endpoint, err := r.resolveService(ctx, &installExt, namespace, releaseName)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
+	svcURL := endpoint.URL
 
-	var latest aiplatformv1alpha1.InstallAIExtension
-	if err := r.Get(ctx, req.NamespacedName, &latest); err != nil {
-		if client.IgnoreNotFound(err) == nil {
-			return ctrl.Result{}, nil
+	caBundle, err := r.ensureTLS(ctx, &installExt, endpoint)
+	if err != nil {
+		var notReady *certs.SecretNotReadyError
+		if errors.As(err, &notReady) {
+			r.recordWaiting(&installExt, "Waiting for the serving certificate: %s", notReady.Error())
+			return ctrl.Result{RequeueAfter: readinessPollInterval}, nil
 		}
 		return ctrl.Result{}, err
 	}
 
-	if latest.Status == (aiplatformv1alpha1.InstallAIExtensionStatus{}) {
-		latest.Status = aiplatformv1alpha1.InstallAIExtensionStatus{}
+	ready, err := r.waitForServer(ctx, registered, endpoint, caBundle)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !ready {
+		return ctrl.Result{RequeueAfter: readinessPollInterval}, nil
 	}
 
-	latest.Status.Phase = "Installed"
-	latest.Status.Message = fmt.Sprintf(
-		"Extension %s installed",
-		latest.Spec.Extension.Name,
-	)
+	changes, err := rancherMgr.Ensure(ctx, registered, svcURL, caBundle, namespace)
+	r.recordRancherChanges(&installExt, changes)
+	if err != nil {
+		var conflict *rancher.FieldConflictError
+		if errors.As(err, &conflict) {
+			r.recordFieldConflict(ctx, &installExt, conflict)
+		}
+		return ctrl.Result{}, err
+	}
 
-	if err := r.Status().Update(ctx, &latest); err != nil {
+	var deployedChart string
+	if err := r.updateStatus(ctx, req.NamespacedName, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		if d := latest.Status.Deployed; d != nil {
+			deployedChart = d.ChartVersion
+		}
+		phase, reason := aiplatformv1alpha1.PhaseReady, aiplatformv1alpha1.ReasonRancherApplied
+		message := fmt.Sprintf(
+			"Extension %s installed",
+			latest.Spec.Extension.Name,
+		)
+		switch {
+		case installaiextension.UpgradeRolledBack(latest):
+			phase, reason = aiplatformv1alpha1.PhaseDegraded, aiplatformv1alpha1.ReasonUpgradeRolledBack
+			message = fmt.Sprintf(
+				"Upgrade of extension %s to chart version %s failed, running version %s",
+				latest.Spec.Extension.Name,
+				latest.Status.FailedUpgrade.ChartVersion,
+				extVersion,
+			)
+		case meta.IsStatusConditionFalse(latest.Status.Conditions, aiplatformv1alpha1.ConditionHealthy):
+			phase, reason = aiplatformv1alpha1.PhaseDegraded, aiplatformv1alpha1.ReasonProbeFailed
+			message = fmt.Sprintf("Extension %s installed, its plugin endpoint is not serving", latest.Spec.Extension.Name)
+		}
+		installaiextension.SetPhase(latest, phase, reason, message)
+		latest.Status.ObservedGeneration = installExt.Generation
+		latest.Status.ServiceURL = svcURL
+		latest.Status.PluginEndpoint = installaiextension.PluginEndpoint(
+			svcURL,
+			latest.Spec.Extension.Name,
+			extVersion,
+		)
+		setCondition(latest, aiplatformv1alpha1.ConditionServiceResolved, metav1.ConditionTrue,
+			aiplatformv1alpha1.ReasonServiceResolved, fmt.Sprintf("Extension served from %s", svcURL))
+		setCondition(latest, aiplatformv1alpha1.ConditionRancherRegistered, metav1.ConditionTrue,
+			aiplatformv1alpha1.ReasonRancherApplied, "ClusterRepo and UIPlugin applied")
+		if reconcileAt != "" {
+			latest.Status.LastHandledReconcileAt = reconcileAt
+		}
+	}); err != nil {
 		log.Error(err, "failed to update status")
 		return ctrl.Result{}, err
 	}
+	metrics.SetExtensionInfo(installExt.Name, extVersion, deployedChart)
+
+	if forced {
+		// Clear the noCache set on the UIPlugin for the forced reconcile.
+		return ctrl.Result{RequeueAfter: readinessPollInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: settings.DriftCheckInterval}, nil
+}
 
-	return ctrl.Result{}, nil
+// indexCache returns the index cache shared by reconciles, or nil to
+// fetch index files on every reconcile.
+func (r *InstallAIExtensionReconciler) indexCache(settings config.Settings) *helmClient.IndexCache {
+	if r.IndexCache == nil || settings.IndexCacheTTL <= 0 {
+		return nil
+	}
+	r.IndexCache.SetTTL(settings.IndexCacheTTL)
+	return r.IndexCache
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *InstallAIExtensionReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&aiplatformv1alpha1.InstallAIExtension{}).
-		Named("InstallAIExtension").
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&aiplatformv1alpha1.InstallAIExtension{}, builder.WithPredicates(specOrTriggerChanged())).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+			RateLimiter:             r.RateLimiter,
+		}).
+		Named("InstallAIExtension")
+
+	if r.Policies != nil && r.Policies.ConfigMap.Name != "" {
+		b = b.Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.policyConfigMapRequests))
+	}
+	if r.SettingsChanged != nil {
+		b = b.WatchesRawSource(source.Channel(r.SettingsChanged, handler.EnqueueRequestsFromMapFunc(r.settingsRequests)))
+	}
+
+	return b.Complete(r)
 }
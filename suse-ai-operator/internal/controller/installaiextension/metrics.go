@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+// observeRelease records the duration and outcome of an EnsureRelease
+// call that installed or upgraded the release of ext. Calls that left the
+// release unchanged, or failed before Helm ran an install or upgrade, such
+// as on a locked release or a chart that could not be pulled, are not
+// recorded.
+func observeRelease(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	result *helmClient.ReleaseResult,
+	err error,
+	duration time.Duration,
+) {
+	var locked *helmClient.ReleaseLockedError
+	if errors.As(err, &locked) {
+		return
+	}
+	if helmClient.IsIncompatible(err) {
+		metrics.CompatibilityCheckFailures.WithLabelValues(ext.Name).Inc()
+	}
+
+	var (
+		installFailed *helmClient.InstallFailedError
+		upgradeFailed *helmClient.UpgradeFailedError
+	)
+	installErr := errors.As(err, &installFailed)
+	if result == nil && !installErr && !errors.As(err, &upgradeFailed) {
+		return
+	}
+
+	operation := metrics.OperationUpgrade
+	switch {
+	case result != nil && result.Action == helmClient.ActionUnchanged && err == nil:
+		return
+	case result != nil && (result.Action == helmClient.ActionInstalled || result.Action == helmClient.ActionReinstalled):
+		operation = metrics.OperationInstall
+	case installErr:
+		operation = metrics.OperationInstall
+	}
+
+	metrics.HelmOperationDuration.WithLabelValues(ext.Name, operation, metrics.Result(err)).
+		Observe(duration.Seconds())
+
+	if err == nil && driftCorrected(ext, result) {
+		metrics.DriftCorrections.WithLabelValues(ext.Name).Inc()
+	}
+}
+
+// uninstallRelease deletes the Helm release of ext and records the
+// duration and outcome.
+func uninstallRelease(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	helm helmClient.HelmClient,
+	releaseName string,
+) error {
+	start := time.Now()
+	err := helm.DeleteRelease(ctx, releaseName)
+	metrics.HelmOperationDuration.WithLabelValues(ext.Name, metrics.OperationUninstall, metrics.Result(err)).
+		Observe(time.Since(start).Seconds())
+	return err
+}
+
+// driftCorrected reports whether result is an upgrade that only reverted
+// changes made to the release outside the operator: the spec was already
+// rolled out, the chart version did not change and the manifest differed.
+func driftCorrected(ext *aiplatformv1alpha1.InstallAIExtension, result *helmClient.ReleaseResult) bool {
+	return result.Action == helmClient.ActionUpgraded &&
+		!rolloutPending(ext) &&
+		!result.Diff.Empty() &&
+		result.PreviousChartVersion == result.ChartVersion
+}
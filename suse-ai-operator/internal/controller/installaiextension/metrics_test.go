@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+// incompatibleErr is the error Helm returns for a chart whose kubeVersion
+// excludes the cluster.
+var incompatibleErr = errors.New("chart requires kubeVersion: >=1.99.0 which is incompatible with Kubernetes v1.30.0")
+
+// helmOperations returns the number of Helm operations recorded for
+// extension "ext".
+func helmOperations(operation, result string) uint64 {
+	var m dto.Metric
+	observer := metrics.HelmOperationDuration.WithLabelValues("ext", operation, result)
+	Expect(observer.(prometheus.Metric).Write(&m)).To(Succeed())
+	return m.GetHistogram().GetSampleCount()
+}
+
+var _ = Describe("Metrics", func() {
+	var ext *aiplatformv1alpha1.InstallAIExtension
+
+	BeforeEach(func() {
+		metrics.DeleteExtension("ext")
+		ext = newInstalledExtension()
+	})
+
+	Describe("observeRelease", func() {
+		DescribeTable("records Helm operations",
+			func(result *helmClient.ReleaseResult, err error, operation, outcome string) {
+				observeRelease(ext, result, err, time.Second)
+				Expect(helmOperations(operation, outcome)).To(Equal(uint64(1)))
+				Expect(testutil.CollectAndCount(metrics.HelmOperationDuration)).To(Equal(1))
+			},
+			Entry("an install",
+				&helmClient.ReleaseResult{Action: helmClient.ActionInstalled}, nil,
+				metrics.OperationInstall, metrics.ResultSuccess),
+			Entry("a reinstall",
+				&helmClient.ReleaseResult{Action: helmClient.ActionReinstalled}, nil,
+				metrics.OperationInstall, metrics.ResultSuccess),
+			Entry("an upgrade",
+				&helmClient.ReleaseResult{Action: helmClient.ActionUpgraded}, nil,
+				metrics.OperationUpgrade, metrics.ResultSuccess),
+			Entry("a failed install",
+				nil, &helmClient.InstallFailedError{ChartVersion: "0.2.0", Err: errors.New("timed out")},
+				metrics.OperationInstall, metrics.ResultFailure),
+			Entry("a rolled back upgrade",
+				&helmClient.ReleaseResult{Action: helmClient.ActionUpgraded},
+				&helmClient.UpgradeFailedError{Revision: 2, ChartVersion: "0.2.0", RolledBackTo: 1, Err: errors.New("timed out")},
+				metrics.OperationUpgrade, metrics.ResultFailure),
+		)
+
+		DescribeTable("does not record calls without a Helm operation",
+			func(result *helmClient.ReleaseResult, err error) {
+				observeRelease(ext, result, err, time.Second)
+				Expect(testutil.CollectAndCount(metrics.HelmOperationDuration)).To(BeZero())
+			},
+			Entry("an unchanged release", &helmClient.ReleaseResult{Action: helmClient.ActionUnchanged}, nil),
+			Entry("a locked release", nil, &helmClient.ReleaseLockedError{Name: "ext", Status: "pending-upgrade"}),
+			Entry("a chart that could not be pulled", nil, errors.New("failed to pull chart")),
+		)
+
+		It("counts charts refused by the cluster's Kubernetes version", func() {
+			observeRelease(ext, nil, incompatibleErr, time.Second)
+			observeRelease(ext, nil, &helmClient.InstallFailedError{ChartVersion: "0.2.0", Err: incompatibleErr}, time.Second)
+
+			Expect(testutil.ToFloat64(metrics.CompatibilityCheckFailures.WithLabelValues("ext"))).To(Equal(2.0))
+			Expect(helmOperations(metrics.OperationInstall, metrics.ResultFailure)).To(Equal(uint64(1)))
+		})
+
+		It("counts upgrades that corrected drift", func() {
+			ext.Status.ObservedGeneration = ext.Generation
+			drift := &helmClient.ReleaseResult{
+				Action: helmClient.ActionUpgraded, ChartVersion: "0.1.0", PreviousChartVersion: "0.1.0",
+				Diff: &helmClient.ManifestDiff{Changes: []helmClient.ObjectChange{{
+					Type: helmClient.ChangeChanged, APIVersion: "apps/v1", Kind: "Deployment", Name: "ext",
+				}}},
+			}
+
+			observeRelease(ext, drift, nil, time.Second)
+			Expect(testutil.ToFloat64(metrics.DriftCorrections.WithLabelValues("ext"))).To(Equal(1.0))
+			Expect(testutil.ToFloat64(metrics.CompatibilityCheckFailures.WithLabelValues("ext"))).To(BeZero())
+		})
+	})
+
+	Describe("uninstallRelease", func() {
+		It("records the uninstall", func() {
+			helm := &fakeHelm{}
+
+			Expect(uninstallRelease(context.Background(), ext, helm, "ext")).To(Succeed())
+			Expect(helm.deleted).To(Equal([]string{"ext"}))
+			Expect(helmOperations(metrics.OperationUninstall, metrics.ResultSuccess)).To(Equal(uint64(1)))
+		})
+	})
+})
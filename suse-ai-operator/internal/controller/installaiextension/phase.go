@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// enterPhase moves ext to phase, writing status only when the phase
+// changes.
+func (r *InstallAIExtensionReconciler) enterPhase(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	phase, reason, message string,
+) error {
+
+	if ext.Status.Phase == phase {
+		return nil
+	}
+
+	logging.Debug(logging.FromContext(ctx, "phase")).Info("Entering phase",
+		logging.KeyExtension, ext.Name, "from", ext.Status.Phase, "to", phase, "reason", reason)
+
+	if err := r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		installaiextension.SetPhase(latest, phase, reason, message)
+	}); err != nil {
+		return err
+	}
+	installaiextension.SetPhase(ext, phase, reason, message)
+	return nil
+}
+
+// rolloutPending reports whether the current generation of ext has not
+// been installed and registered yet.
+func rolloutPending(ext *aiplatformv1alpha1.InstallAIExtension) bool {
+	return ext.Status.ObservedGeneration != ext.Generation
+}
+
+// releasePhase returns the phase of the Helm action a rollout of ext
+// runs.
+func releasePhase(ext *aiplatformv1alpha1.InstallAIExtension) (string, string) {
+	if ext.Status.Deployed == nil || ext.Status.Phase == aiplatformv1alpha1.PhaseInstalling {
+		return aiplatformv1alpha1.PhaseInstalling, "InstallStarted"
+	}
+	return aiplatformv1alpha1.PhaseUpgrading, "UpgradeStarted"
+}
@@ -0,0 +1,295 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/releaseutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/infra/kubernetes"
+	"github.com/SUSE/suse-ai-operator/internal/infra/rancher"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+const (
+	planKeyManifest = "manifest.yaml"
+	planKeyRancher  = "rancher.yaml"
+
+	// maxPlanBytes is the budget of all keys of a plan ConfigMap together,
+	// leaving room for its metadata below the 1MiB object limit.
+	maxPlanBytes = 900 * 1024
+)
+
+// planMode reports whether ext is reconciled in Plan mode. The mode
+// annotation takes precedence over spec.mode.
+func planMode(ext *aiplatformv1alpha1.InstallAIExtension) bool {
+	if mode, ok := ext.Annotations[aiplatformv1alpha1.AnnotationMode]; ok {
+		return strings.EqualFold(mode, aiplatformv1alpha1.ModePlan)
+	}
+	return ext.Spec.Mode == aiplatformv1alpha1.ModePlan
+}
+
+// reconcilePlan computes what applying the spec would do: the Helm diff
+// from a dry run and the ClusterRepo and UIPlugin that would be written.
+// Nothing is applied. The plan is published in status and a ConfigMap and
+// only recomputed when the spec changes.
+func (r *InstallAIExtensionReconciler) reconcilePlan(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	helm helmClient.HelmClient,
+	rancherMgr *rancher.Manager,
+	spec helmClient.ReleaseSpec,
+) error {
+
+	log := logging.FromContext(ctx, "plan").WithValues(
+		logging.KeyExtension, ext.Name,
+		logging.KeyVersion, spec.Version,
+	)
+
+	key := types.NamespacedName{Name: ext.Name}
+
+	if p := ext.Status.Plan; p != nil && p.ObservedGeneration == ext.Generation &&
+		meta.IsStatusConditionTrue(ext.Status.Conditions, aiplatformv1alpha1.ConditionPlanned) {
+		logging.Debug(log).Info("Plan is up to date")
+		return nil
+	}
+
+	log.Info("Computing plan")
+
+	plan, err := helm.PlanRelease(ctx, spec)
+	if err != nil {
+		log.Error(err, "Failed to plan Helm release")
+		if helmClient.IsIncompatible(err) {
+			metrics.CompatibilityCheckFailures.WithLabelValues(ext.Name).Inc()
+		}
+		if statusErr := r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			installaiextension.SetPhase(latest, aiplatformv1alpha1.PhasePlanFailed,
+				aiplatformv1alpha1.ReasonPlanFailed, err.Error())
+			setCondition(latest, aiplatformv1alpha1.ConditionPlanned, metav1.ConditionFalse,
+				aiplatformv1alpha1.ReasonPlanFailed, err.Error())
+		}); statusErr != nil {
+			log.Error(statusErr, "Failed to update status")
+		}
+		return err
+	}
+
+	added, removed, changed := plan.Diff.Count()
+	status := &aiplatformv1alpha1.PlanStatus{
+		Action:              planAction(plan.Action),
+		ChartVersion:        plan.ChartVersion,
+		ChartDigest:         plan.ChartDigest,
+		CurrentChartVersion: plan.CurrentChartVersion,
+		ExtensionVersion:    ext.Spec.Extension.Version,
+		Added:               int32(added),
+		Changed:             int32(changed),
+		Removed:             int32(removed),
+		Summary:             plan.Diff.Summary(),
+		ObservedGeneration:  ext.Generation,
+		Time:                metav1.Now(),
+	}
+
+	objs, warnings := r.planRancher(ctx, ext, rancherMgr, spec, plan, status)
+	status.Warnings = warnings
+
+	name, err := r.writePlanConfigMap(ctx, ext, spec.Namespace, plan, objs)
+	if err != nil {
+		log.Error(err, "Failed to store plan")
+		status.Warnings = append(status.Warnings, fmt.Sprintf("plan ConfigMap not written: %v", err))
+	} else {
+		status.ConfigMapName = name
+	}
+
+	r.event(ext, corev1.EventTypeNormal, "Planned",
+		"%s chart version %s: %s", status.Action, status.ChartVersion, status.Summary)
+
+	log.Info("Plan computed", "action", status.Action, "changes", status.Summary)
+
+	return r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		installaiextension.SetPhase(latest, aiplatformv1alpha1.PhasePlanned, aiplatformv1alpha1.ReasonPlanReady,
+			fmt.Sprintf("%s of chart version %s planned, nothing applied", status.Action, status.ChartVersion))
+		latest.Status.Plan = status
+		setCondition(latest, aiplatformv1alpha1.ConditionPlanned, metav1.ConditionTrue,
+			aiplatformv1alpha1.ReasonPlanReady, status.Summary)
+	})
+}
+
+// planRancher computes the Rancher objects for the Service the rendered
+// manifest would create. Anything that cannot be resolved yet is returned
+// as a warning rather than failing the plan.
+func (r *InstallAIExtensionReconciler) planRancher(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	rancherMgr *rancher.Manager,
+	spec helmClient.ReleaseSpec,
+	plan *helmClient.ReleasePlan,
+	status *aiplatformv1alpha1.PlanStatus,
+) ([]*unstructured.Unstructured, []string) {
+
+	var warnings []string
+
+	services, err := kubernetes.ServicesFromManifest(plan.Manifest, spec.Namespace)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("rendered Services could not be parsed: %v", err)}
+	}
+
+	svc, err := selectRenderedService(services, ext.Spec.Extension.Service, spec.Namespace, spec.Name)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("no rendered Service serves the extension: %v", err)}
+	}
+
+	endpoint, err := endpointForService(&ext.Spec.Extension, svc)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	status.ServiceURL = endpoint.URL
+
+	var caBundle []byte
+	if name, key, ok := installaiextension.CABundleRef(ext.Spec.Extension.TLS); ok {
+		caBundle, err = certs.SecretValue(ctx, r.Client, spec.Namespace, name, key)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("CA bundle not available yet: %v", err))
+		}
+	}
+
+	objs, rancherWarnings, err := rancherMgr.Plan(ctx, ext, endpoint.URL, caBundle, spec.Namespace)
+	if err != nil {
+		return nil, append(warnings, fmt.Sprintf("Rancher objects could not be computed: %v", err))
+	}
+
+	return objs, append(warnings, rancherWarnings...)
+}
+
+func (r *InstallAIExtensionReconciler) writePlanConfigMap(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	namespace string,
+	plan *helmClient.ReleasePlan,
+	objs []*unstructured.Unstructured,
+) (string, error) {
+
+	diffJSON, err := json.Marshal(plan.Diff)
+	if err != nil {
+		return "", err
+	}
+
+	docs := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(data))
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ext.Name + "-plan",
+			Namespace: namespace,
+		},
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[labelExtension] = ext.Name
+
+		cm.Data = fitPlanData([]planEntry{
+			{key: diffKeyText, value: plan.Diff.String()},
+			{key: planKeyRancher, value: strings.Join(docs, "---\n")},
+			{key: diffKeyJSON, value: string(diffJSON), whole: true},
+			{key: planKeyManifest, value: redactSecrets(plan.Manifest)},
+		}, maxPlanBytes)
+
+		return controllerutil.SetControllerReference(ext, cm, r.Scheme)
+	})
+
+	return cm.Name, err
+}
+
+// planEntry is a key of the plan ConfigMap. A whole entry cannot be cut
+// short, such as JSON, and is left out when it does not fit.
+type planEntry struct {
+	key   string
+	value string
+	whole bool
+}
+
+// fitPlanData returns the data of a plan ConfigMap holding at most budget
+// bytes. Entries are added in order of priority, each capped at
+// maxDiffBytes and truncated to the budget left, or dropped once nothing
+// useful fits.
+func fitPlanData(entries []planEntry, budget int) map[string]string {
+	data := make(map[string]string, len(entries))
+	for _, e := range entries {
+		limit := min(budget, maxDiffBytes)
+		value := e.value
+		if len(value) > limit {
+			if e.whole || limit <= len(truncatedMarker) {
+				continue
+			}
+			value = truncate(value, limit)
+		}
+		data[e.key] = value
+		budget -= len(e.key) + len(value)
+	}
+	return data
+}
+
+// redactSecrets replaces the rendered Secrets of a manifest by a note.
+// Documents that cannot be parsed are replaced as well, so a Secret is
+// never published because its kind could not be read.
+func redactSecrets(manifest string) string {
+	split := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(split))
+	for key := range split {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	docs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		doc := split[key]
+		var obj struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Metadata   struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+		switch err := yaml.Unmarshal([]byte(doc), &obj); {
+		case err != nil:
+			doc = "# Unparsable document (redacted)"
+		case obj.APIVersion == "v1" && obj.Kind == "Secret":
+			doc = fmt.Sprintf("# Secret %s (redacted)", obj.Metadata.Name)
+		}
+		docs = append(docs, strings.TrimSpace(doc)+"\n")
+	}
+	return strings.Join(docs, "---\n")
+}
+
+func planAction(act helmClient.ReleaseAction) string {
+	switch act {
+	case helmClient.ActionInstalled:
+		return "Install"
+	case helmClient.ActionUpgraded:
+		return "Upgrade"
+	default:
+		return "None"
+	}
+}
@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/infra/rancher"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+const planManifest = `apiVersion: v1
+kind: Service
+metadata:
+  name: ext
+  namespace: default
+  labels:
+    app.kubernetes.io/instance: ext
+spec:
+  ports:
+  - name: http
+    port: 80
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: ext-credentials
+  namespace: default
+stringData:
+  password: hunter2
+`
+
+var _ = Describe("Plan mode", func() {
+	DescribeTable("planMode",
+		func(mode string, annotations map[string]string, expected bool) {
+			ext := &aiplatformv1alpha1.InstallAIExtension{}
+			ext.Spec.Mode = mode
+			ext.Annotations = annotations
+
+			Expect(planMode(ext)).To(Equal(expected))
+		},
+		Entry("applies by default", "", nil, false),
+		Entry("plans with spec.mode Plan", aiplatformv1alpha1.ModePlan, nil, true),
+		Entry("applies with spec.mode Apply", aiplatformv1alpha1.ModeApply, nil, false),
+		Entry("plans when the annotation overrides Apply", aiplatformv1alpha1.ModeApply,
+			map[string]string{aiplatformv1alpha1.AnnotationMode: "plan"}, true),
+		Entry("applies when the annotation overrides Plan", aiplatformv1alpha1.ModePlan,
+			map[string]string{aiplatformv1alpha1.AnnotationMode: aiplatformv1alpha1.ModeApply}, false),
+		Entry("applies with an unknown annotation value", aiplatformv1alpha1.ModePlan,
+			map[string]string{aiplatformv1alpha1.AnnotationMode: "dry-run"}, false),
+	)
+
+	Describe("redactSecrets", func() {
+		It("replaces Secrets and keeps other documents", func() {
+			redacted := redactSecrets(planManifest)
+			Expect(redacted).To(HavePrefix("apiVersion: v1\nkind: Service\n"))
+			Expect(redacted).To(HaveSuffix("---\n# Secret ext-credentials (redacted)\n"))
+			Expect(redacted).NotTo(ContainSubstring("hunter2"))
+		})
+
+		It("does not split block scalars holding a separator", func() {
+			manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: ext\ndata:\n" +
+				"  notes: |\n    first\n    ---\n    second\n"
+
+			Expect(redactSecrets(manifest)).To(Equal(manifest))
+		})
+
+		It("replaces documents that cannot be parsed", func() {
+			manifest := "apiVersion: v1\nkind: Secret\nmetadata: [ext\nstringData:\n  password: hunter2\n" +
+				"---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: ext\n"
+
+			Expect(redactSecrets(manifest)).To(Equal("# Unparsable document (redacted)\n---\n" +
+				"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: ext\n"))
+		})
+	})
+
+	Describe("fitPlanData", func() {
+		large := strings.Repeat("x", maxDiffBytes+1)
+
+		It("keeps the ConfigMap within the budget, in order of priority", func() {
+			data := fitPlanData([]planEntry{
+				{key: diffKeyText, value: large},
+				{key: planKeyRancher, value: "kind: UIPlugin\n"},
+				{key: diffKeyJSON, value: large, whole: true},
+				{key: planKeyManifest, value: large},
+			}, maxPlanBytes)
+
+			Expect(data).To(HaveKeyWithValue(planKeyRancher, "kind: UIPlugin\n"))
+			Expect(data).NotTo(HaveKey(diffKeyJSON))
+			Expect(data[diffKeyText]).To(HaveLen(maxDiffBytes))
+			Expect(data[diffKeyText]).To(HaveSuffix(truncatedMarker))
+			Expect(data[planKeyManifest]).To(HaveSuffix(truncatedMarker))
+
+			size := 0
+			for key, value := range data {
+				size += len(key) + len(value)
+			}
+			Expect(size).To(BeNumerically("<=", maxPlanBytes))
+		})
+
+		It("drops entries once the budget is spent", func() {
+			data := fitPlanData([]planEntry{
+				{key: diffKeyText, value: large},
+				{key: planKeyManifest, value: large},
+			}, maxDiffBytes+len(diffKeyText)+len(truncatedMarker))
+
+			Expect(data).To(HaveKey(diffKeyText))
+			Expect(data).NotTo(HaveKey(planKeyManifest))
+		})
+	})
+
+	DescribeTable("truncate",
+		func(s string, limit int, expected string) {
+			Expect(truncate(s, limit)).To(Equal(expected))
+		},
+		Entry("keeps a value within the limit", "short", 5, "short"),
+		Entry("cuts a value and marks it", strings.Repeat("a", 40), 20, "aaa"+truncatedMarker),
+		Entry("does not split a character", strings.Repeat("é", 20), 22, "éé"+truncatedMarker),
+	)
+
+	Describe("reconcilePlan", func() {
+		var (
+			ctx        context.Context
+			reconciler *InstallAIExtensionReconciler
+			recorder   *record.FakeRecorder
+			helm       *fakeHelm
+			rancherMgr *rancher.Manager
+		)
+		spec := helmClient.ReleaseSpec{Name: "ext", Namespace: "default", Version: "0.2.0"}
+
+		setUp := func(ext *aiplatformv1alpha1.InstallAIExtension) {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(aiplatformv1alpha1.AddToScheme(scheme)).To(Succeed())
+			recorder = record.NewFakeRecorder(10)
+			reconciler = &InstallAIExtensionReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(ext).
+					WithStatusSubresource(ext).
+					Build(),
+				Scheme:   scheme,
+				Recorder: recorder,
+			}
+			rancherMgr = rancher.NewManager(reconciler.Client, scheme, nil)
+		}
+
+		stored := func() *aiplatformv1alpha1.InstallAIExtension {
+			var ext aiplatformv1alpha1.InstallAIExtension
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "ext"}, &ext)).To(Succeed())
+			return &ext
+		}
+
+		newExtension := func() *aiplatformv1alpha1.InstallAIExtension {
+			ext := &aiplatformv1alpha1.InstallAIExtension{
+				ObjectMeta: metav1.ObjectMeta{Name: "ext", Generation: 2},
+			}
+			ext.Spec.Mode = aiplatformv1alpha1.ModePlan
+			ext.Spec.Helm = &aiplatformv1alpha1.HelmSpec{Name: "ext", URL: "oci://registry.example.com/charts/ext"}
+			ext.Spec.Extension.Name = "ext"
+			ext.Spec.Extension.Version = "2.0.0"
+			return ext
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			helm = &fakeHelm{
+				plan: func(helmClient.ReleaseSpec) (*helmClient.ReleasePlan, error) {
+					return &helmClient.ReleasePlan{
+						Action:       helmClient.ActionInstalled,
+						ChartVersion: "0.2.0",
+						Manifest:     planManifest,
+						Diff: &helmClient.ManifestDiff{Changes: []helmClient.ObjectChange{
+							{Type: helmClient.ChangeAdded, APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "ext"},
+						}},
+					}, nil
+				},
+			}
+		})
+
+		It("publishes the plan in status and a ConfigMap", func() {
+			ext := newExtension()
+			setUp(ext)
+
+			Expect(reconciler.reconcilePlan(ctx, ext, helm, rancherMgr, spec)).To(Succeed())
+			Expect(helm.planned).To(HaveLen(1))
+			Expect(helm.ensured).To(BeEmpty())
+
+			latest := stored()
+			Expect(latest.Status.Phase).To(Equal(aiplatformv1alpha1.PhasePlanned))
+			Expect(meta.IsStatusConditionTrue(latest.Status.Conditions, aiplatformv1alpha1.ConditionPlanned)).To(BeTrue())
+
+			plan := latest.Status.Plan
+			Expect(plan).NotTo(BeNil())
+			Expect(plan.Action).To(Equal("Install"))
+			Expect(plan.ChartVersion).To(Equal("0.2.0"))
+			Expect(plan.Added).To(Equal(int32(1)))
+			Expect(plan.ObservedGeneration).To(Equal(int64(2)))
+			Expect(plan.ServiceURL).To(ContainSubstring("ext.default"))
+			Expect(plan.ConfigMapName).To(Equal("ext-plan"))
+			Expect(plan.Warnings).To(ConsistOf(ContainSubstring("UIPlugin metadata from index.yaml not resolved")))
+
+			var cm corev1.ConfigMap
+			Expect(reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: "ext-plan"}, &cm)).To(Succeed())
+			Expect(cm.Labels).To(HaveKeyWithValue(labelExtension, "ext"))
+			Expect(cm.OwnerReferences).To(HaveLen(1))
+			Expect(cm.Data[planKeyManifest]).To(ContainSubstring("kind: Service"))
+			Expect(cm.Data[planKeyManifest]).To(ContainSubstring("# Secret ext-credentials (redacted)"))
+			Expect(cm.Data[planKeyManifest]).NotTo(ContainSubstring("hunter2"))
+			Expect(cm.Data[diffKeyText]).To(Equal("Added Service/default/ext (v1)\n"))
+			Expect(cm.Data[diffKeyJSON]).To(ContainSubstring(`"kind":"Service"`))
+			Expect(cm.Data[planKeyRancher]).To(ContainSubstring("kind: ClusterRepo"))
+			Expect(cm.Data[planKeyRancher]).To(ContainSubstring("kind: UIPlugin"))
+			Expect(cm.Data[planKeyRancher]).To(ContainSubstring(plan.ServiceURL))
+
+			Expect(recorder.Events).To(Receive(HavePrefix("Normal Planned Install chart version 0.2.0")))
+		})
+
+		It("counts a plan refused by the cluster's Kubernetes version", func() {
+			metrics.DeleteExtension("ext")
+			helm.plan = func(helmClient.ReleaseSpec) (*helmClient.ReleasePlan, error) {
+				return nil, incompatibleErr
+			}
+			ext := newExtension()
+			setUp(ext)
+
+			Expect(reconciler.reconcilePlan(ctx, ext, helm, rancherMgr, spec)).To(MatchError(incompatibleErr))
+			Expect(stored().Status.Phase).To(Equal(aiplatformv1alpha1.PhasePlanFailed))
+			Expect(testutil.ToFloat64(metrics.CompatibilityCheckFailures.WithLabelValues("ext"))).To(Equal(1.0))
+		})
+
+		It("does not recompute an up to date plan", func() {
+			ext := newExtension()
+			setUp(ext)
+			Expect(reconciler.reconcilePlan(ctx, ext, helm, rancherMgr, spec)).To(Succeed())
+
+			Expect(reconciler.reconcilePlan(ctx, stored(), helm, rancherMgr, spec)).To(Succeed())
+			Expect(helm.planned).To(HaveLen(1))
+
+			changed := stored()
+			changed.Generation = 3
+			Expect(reconciler.reconcilePlan(ctx, changed, helm, rancherMgr, spec)).To(Succeed())
+			Expect(helm.planned).To(HaveLen(2))
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+	"github.com/SUSE/suse-ai-operator/internal/policy"
+)
+
+// enforcePolicy checks the chart of ext against the operator policy and
+// records the result in the PolicyViolation condition. It reports whether
+// the extension may be installed. The admission webhook rejects violating
+// specs up front; this catches extensions admitted before the policy
+// changed or while the webhook was disabled.
+func (r *InstallAIExtensionReconciler) enforcePolicy(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+) (bool, error) {
+
+	log := logging.FromContext(ctx, "policy").WithValues(logging.KeyExtension, ext.Name)
+	key := types.NamespacedName{Name: ext.Name}
+
+	p, err := r.Policies.Load(ctx)
+	if err != nil {
+		log.Error(err, "Failed to load the operator policy")
+		if statusErr := r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			setCondition(latest, aiplatformv1alpha1.ConditionPolicyViolation, metav1.ConditionUnknown,
+				aiplatformv1alpha1.ReasonPolicyUnreadable, err.Error())
+		}); statusErr != nil {
+			log.Error(statusErr, "Failed to record policy failure")
+		}
+		return false, err
+	}
+
+	if p.Empty() {
+		if meta.FindStatusCondition(ext.Status.Conditions, aiplatformv1alpha1.ConditionPolicyViolation) == nil {
+			return true, nil
+		}
+		return true, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			meta.RemoveStatusCondition(&latest.Status.Conditions, aiplatformv1alpha1.ConditionPolicyViolation)
+		})
+	}
+
+	err = p.Check(policy.ExtensionChart(ext))
+
+	var violation *policy.ViolationError
+	if !errors.As(err, &violation) {
+		cond := meta.FindStatusCondition(ext.Status.Conditions, aiplatformv1alpha1.ConditionPolicyViolation)
+		if cond != nil && cond.Reason == aiplatformv1alpha1.ReasonPolicyCompliant && cond.ObservedGeneration == ext.Generation {
+			return true, nil
+		}
+		return true, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			setCondition(latest, aiplatformv1alpha1.ConditionPolicyViolation, metav1.ConditionFalse,
+				aiplatformv1alpha1.ReasonPolicyCompliant, "Chart is allowed by the operator policy")
+		})
+	}
+
+	log.Info("Chart violates the operator policy, not installing", "violations", violation.Violations)
+	r.event(ext, corev1.EventTypeWarning, aiplatformv1alpha1.ReasonPolicyViolated, "%s", violation.Error())
+
+	return false, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		installaiextension.SetPhase(latest, aiplatformv1alpha1.PhaseFailed,
+			aiplatformv1alpha1.ReasonPolicyViolated, violation.Error())
+		setCondition(latest, aiplatformv1alpha1.ConditionPolicyViolation, metav1.ConditionTrue,
+			aiplatformv1alpha1.ReasonPolicyViolated, violation.Error())
+	})
+}
+
+// policyConfigMapRequests enqueues every extension when the policy
+// ConfigMap changes.
+func (r *InstallAIExtensionReconciler) policyConfigMapRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	if r.Policies == nil || client.ObjectKeyFromObject(obj) != r.Policies.ConfigMap {
+		return nil
+	}
+	return r.extensionRequests(ctx, "policy")
+}
+
+// settingsRequests enqueues every extension when the OperatorConfig
+// applied new settings.
+func (r *InstallAIExtensionReconciler) settingsRequests(ctx context.Context, _ client.Object) []reconcile.Request {
+	return r.extensionRequests(ctx, "settings")
+}
+
+// extensionRequests returns a request for every extension in the cache,
+// which only holds the extensions of the shard.
+func (r *InstallAIExtensionReconciler) extensionRequests(ctx context.Context, name string) []reconcile.Request {
+	var list aiplatformv1alpha1.InstallAIExtensionList
+	if err := r.List(ctx, &list); err != nil {
+		logging.FromContext(ctx, name).Error(err, "Failed to list extensions to reconcile")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, ext := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ext.Name}})
+	}
+	return requests
+}
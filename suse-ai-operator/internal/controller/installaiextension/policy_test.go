@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/policy"
+)
+
+var _ = Describe("Extension requests", func() {
+	var (
+		ctx        context.Context
+		reconciler *InstallAIExtensionReconciler
+	)
+
+	all := []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "ext"}},
+		{NamespacedName: types.NamespacedName{Name: "other"}},
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		other := newInstalledExtension()
+		other.Name = "other"
+		reconciler, _ = newTestReconciler(&fakeHelm{}, newInstalledExtension(), other)
+		reconciler.Policies = &policy.Loader{
+			ConfigMap: types.NamespacedName{Namespace: "operator", Name: "chart-policy"},
+		}
+	})
+
+	It("reconciles every extension when settings are applied", func() {
+		Expect(reconciler.settingsRequests(ctx, &aiplatformv1alpha1.OperatorConfig{})).To(ConsistOf(all))
+	})
+
+	It("reconciles every extension when the policy ConfigMap changes", func() {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "operator", Name: "chart-policy"}}
+		Expect(reconciler.policyConfigMapRequests(ctx, cm)).To(ConsistOf(all))
+
+		cm.Name = "unrelated"
+		Expect(reconciler.policyConfigMapRequests(ctx, cm)).To(BeEmpty())
+	})
+})
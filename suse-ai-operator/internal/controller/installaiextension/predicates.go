@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// triggerAnnotationPrefix prefixes the annotations whose changes trigger a
+// reconcile, such as the mode override.
+const triggerAnnotationPrefix = "ai-platform.suse.com/"
+
+// Default backoff between retries of a failed reconcile.
+const (
+	DefaultBackoffBase = 5 * time.Second
+	DefaultBackoffMax  = 5 * time.Minute
+)
+
+// specOrTriggerChanged passes updates that change the generation, start
+// the deletion or change an ai-platform.suse.com/ annotation, and the
+// periodic resyncs of the cache. Status writes, including the operator's
+// own, are filtered out.
+func specOrTriggerChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return true
+			}
+			switch {
+			case e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion():
+				// Resync of an unchanged object.
+				return true
+			case e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration():
+				return true
+			case e.ObjectOld.GetDeletionTimestamp().IsZero() != e.ObjectNew.GetDeletionTimestamp().IsZero():
+				return true
+			}
+			return triggerAnnotationsChanged(e.ObjectOld, e.ObjectNew)
+		},
+	}
+}
+
+func triggerAnnotationsChanged(oldObj, newObj client.Object) bool {
+	oldAnn, newAnn := oldObj.GetAnnotations(), newObj.GetAnnotations()
+	for k, v := range newAnn {
+		if strings.HasPrefix(k, triggerAnnotationPrefix) && oldAnn[k] != v {
+			return true
+		}
+	}
+	for k := range oldAnn {
+		if _, ok := newAnn[k]; !ok && strings.HasPrefix(k, triggerAnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRateLimiter returns the reconcile rate limiter: a per-extension
+// exponential backoff from base to maxDelay, and the overall token bucket of
+// the controller-runtime default.
+func NewRateLimiter(base, maxDelay time.Duration) workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](base, maxDelay),
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
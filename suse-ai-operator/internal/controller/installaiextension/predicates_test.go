@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("specOrTriggerChanged", func() {
+	newExtension := func() *aiplatformv1alpha1.InstallAIExtension {
+		return &aiplatformv1alpha1.InstallAIExtension{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "ext",
+				Generation:      1,
+				ResourceVersion: "100",
+				Annotations: map[string]string{
+					aiplatformv1alpha1.AnnotationRetry: "1",
+					"example.com/owner":                "team-a",
+				},
+			},
+		}
+	}
+
+	DescribeTable("filters updates",
+		func(modify func(*aiplatformv1alpha1.InstallAIExtension), expected bool) {
+			oldExt := newExtension()
+			newExt := oldExt.DeepCopy()
+			modify(newExt)
+
+			Expect(specOrTriggerChanged().Update(event.UpdateEvent{ObjectOld: oldExt, ObjectNew: newExt})).
+				To(Equal(expected))
+		},
+		Entry("passes a resync", func(*aiplatformv1alpha1.InstallAIExtension) {}, true),
+		Entry("passes a spec change", func(e *aiplatformv1alpha1.InstallAIExtension) {
+			e.ResourceVersion = "101"
+			e.Generation = 2
+		}, true),
+		Entry("passes the start of the deletion", func(e *aiplatformv1alpha1.InstallAIExtension) {
+			e.ResourceVersion = "101"
+			e.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		}, true),
+		Entry("passes a changed trigger annotation", func(e *aiplatformv1alpha1.InstallAIExtension) {
+			e.ResourceVersion = "101"
+			e.Annotations[aiplatformv1alpha1.AnnotationRetry] = "2"
+		}, true),
+		Entry("passes an added trigger annotation", func(e *aiplatformv1alpha1.InstallAIExtension) {
+			e.ResourceVersion = "101"
+			e.Annotations[aiplatformv1alpha1.AnnotationReconcileAt] = "now"
+		}, true),
+		Entry("passes a removed trigger annotation", func(e *aiplatformv1alpha1.InstallAIExtension) {
+			e.ResourceVersion = "101"
+			delete(e.Annotations, aiplatformv1alpha1.AnnotationRetry)
+		}, true),
+		Entry("filters a status write", func(e *aiplatformv1alpha1.InstallAIExtension) {
+			e.ResourceVersion = "101"
+			e.Status.Phase = aiplatformv1alpha1.PhaseReady
+		}, false),
+		Entry("filters an unrelated annotation", func(e *aiplatformv1alpha1.InstallAIExtension) {
+			e.ResourceVersion = "101"
+			e.Annotations["example.com/owner"] = "team-b"
+		}, false),
+		Entry("filters a label change", func(e *aiplatformv1alpha1.InstallAIExtension) {
+			e.ResourceVersion = "101"
+			e.Labels = map[string]string{"tier": "prod"}
+		}, false),
+	)
+})
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/infra/kubernetes"
+	"github.com/SUSE/suse-ai-operator/internal/infra/pluginserver"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+const (
+	defaultReadinessTimeout = 5 * time.Minute
+	readinessPollInterval   = 10 * time.Second
+)
+
+// waitForServer gates Rancher registration on the extension server being
+// up: its Service must have ready endpoints and it must serve both the
+// plugin assets and index.yaml. It returns false while still waiting, and
+// an error once the readiness timeout has elapsed.
+func (r *InstallAIExtensionReconciler) waitForServer(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	endpoint *serviceEndpoint,
+	caBundle []byte,
+) (bool, error) {
+
+	log := logging.FromContext(ctx, "readiness").WithValues(
+		logging.KeyExtension, ext.Name,
+		logging.KeyName, endpoint.Name,
+	)
+
+	ready, err := kubernetes.ReadyEndpoints(ctx, r.Client, endpoint.Namespace, endpoint.Name)
+	if err != nil {
+		return false, err
+	}
+
+	var reason, message string
+	if ready == 0 {
+		reason = aiplatformv1alpha1.ReasonWaitingForEndpoints
+		message = fmt.Sprintf("Service %s has no ready endpoints", endpoint.Name)
+	} else {
+		pluginEndpoint := installaiextension.PluginEndpoint(
+			endpoint.URL,
+			ext.Spec.Extension.Name,
+			ext.Spec.Extension.Version,
+		)
+		if _, err := r.prober().ProbeExtension(ctx, pluginEndpoint, endpoint.URL, caBundle); err != nil {
+			reason = aiplatformv1alpha1.ReasonWaitingForPlugin
+			message = err.Error()
+		}
+	}
+
+	key := types.NamespacedName{Name: ext.Name}
+
+	if reason == "" {
+		logging.Debug(log).Info("Extension server is ready", "readyEndpoints", ready)
+		return true, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			setCondition(latest, aiplatformv1alpha1.ConditionServerReady, metav1.ConditionTrue,
+				aiplatformv1alpha1.ReasonServerReady, fmt.Sprintf("Service %s is serving the extension", endpoint.Name))
+		})
+	}
+
+	timeout := defaultReadinessTimeout
+	if ext.Spec.Extension.ReadinessTimeout != nil {
+		timeout = ext.Spec.Extension.ReadinessTimeout.Duration
+	}
+
+	var waitErr error
+	if since := waitingSince(ext); time.Since(since) > timeout {
+		reason = aiplatformv1alpha1.ReasonReadinessTimeout
+		message = fmt.Sprintf("extension server not ready after %s: %s", timeout, message)
+		waitErr = errors.New(message)
+		log.Error(waitErr, "Timed out waiting for extension server")
+	} else {
+		log.Info("Waiting for extension server", "reason", reason, "detail", message)
+		r.recordWaiting(ext, "Waiting for extension server %s: %s", endpoint.Name, message)
+	}
+
+	if err := r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		resetStaleCondition(latest, aiplatformv1alpha1.ConditionServerReady)
+		setCondition(latest, aiplatformv1alpha1.ConditionServerReady, metav1.ConditionFalse, reason, message)
+	}); err != nil {
+		return false, err
+	}
+
+	return false, waitErr
+}
+
+// waitingSince returns when the current wait for the server started. A
+// wait recorded for an older generation does not count.
+func waitingSince(ext *aiplatformv1alpha1.InstallAIExtension) time.Time {
+	cond := meta.FindStatusCondition(ext.Status.Conditions, aiplatformv1alpha1.ConditionServerReady)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.ObservedGeneration != ext.Generation {
+		return time.Now()
+	}
+	return cond.LastTransitionTime.Time
+}
+
+func (r *InstallAIExtensionReconciler) prober() *pluginserver.Prober {
+	if r.Prober == nil {
+		return pluginserver.NewProber(nil)
+	}
+	return r.Prober
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("Extension server readiness", func() {
+	waiting := func(generation int64, status metav1.ConditionStatus, since time.Time) *aiplatformv1alpha1.InstallAIExtension {
+		ext := &aiplatformv1alpha1.InstallAIExtension{
+			ObjectMeta: metav1.ObjectMeta{Name: "ext", Generation: 2},
+		}
+		ext.Status.Conditions = []metav1.Condition{{
+			Type:               aiplatformv1alpha1.ConditionServerReady,
+			Status:             status,
+			Reason:             aiplatformv1alpha1.ReasonWaitingForEndpoints,
+			ObservedGeneration: generation,
+			LastTransitionTime: metav1.NewTime(since),
+		}}
+		return ext
+	}
+	started := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	DescribeTable("waitingSince",
+		func(ext *aiplatformv1alpha1.InstallAIExtension, expected *time.Time) {
+			since := waitingSince(ext)
+			if expected == nil {
+				Expect(since).To(BeTemporally("~", time.Now(), time.Second))
+			} else {
+				Expect(since).To(BeTemporally("==", *expected))
+			}
+		},
+		Entry("starts now without a condition",
+			&aiplatformv1alpha1.InstallAIExtension{}, nil),
+		Entry("starts now once the server was ready",
+			waiting(2, metav1.ConditionTrue, started), nil),
+		Entry("starts now for a wait of an older generation",
+			waiting(1, metav1.ConditionFalse, started), nil),
+		Entry("continues a wait of the current generation",
+			waiting(2, metav1.ConditionFalse, started), &started),
+	)
+
+	Describe("waitForServer", func() {
+		var (
+			ctx        context.Context
+			reconciler *InstallAIExtensionReconciler
+			recorder   *record.FakeRecorder
+		)
+		endpoint := &serviceEndpoint{Name: "ui", Namespace: "default", Port: 8080, URL: "http://ui.default.svc:8080"}
+
+		setUp := func(ext *aiplatformv1alpha1.InstallAIExtension) {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(aiplatformv1alpha1.AddToScheme(scheme)).To(Succeed())
+			recorder = record.NewFakeRecorder(10)
+			reconciler = &InstallAIExtensionReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(ext).
+					WithStatusSubresource(ext).
+					Build(),
+				Scheme:      scheme,
+				Recorder:    recorder,
+				RetryBudget: 3,
+			}
+		}
+
+		stored := func() *aiplatformv1alpha1.InstallAIExtension {
+			var ext aiplatformv1alpha1.InstallAIExtension
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "ext"}, &ext)).To(Succeed())
+			return &ext
+		}
+
+		// withTimeout returns an extension waiting for its server since
+		// since, with a readiness timeout of one minute.
+		withTimeout := func(since time.Time) *aiplatformv1alpha1.InstallAIExtension {
+			ext := waiting(2, metav1.ConditionFalse, since)
+			ext.Spec.Extension.ReadinessTimeout = &metav1.Duration{Duration: time.Minute}
+			return ext
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+		})
+
+		It("waits for endpoints within the timeout", func() {
+			ext := withTimeout(time.Now().Add(-30 * time.Second))
+			setUp(ext)
+
+			ready, err := reconciler.waitForServer(ctx, ext, endpoint, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ready).To(BeFalse())
+
+			cond := meta.FindStatusCondition(stored().Status.Conditions, aiplatformv1alpha1.ConditionServerReady)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(aiplatformv1alpha1.ReasonWaitingForEndpoints))
+			Expect(recorder.Events).To(Receive(HavePrefix("Normal " + eventWaitingForDependency)))
+		})
+
+		It("reports a timeout once the server is not ready in time", func() {
+			ext := withTimeout(started)
+			setUp(ext)
+
+			ready, err := reconciler.waitForServer(ctx, ext, endpoint, nil)
+			Expect(err).To(MatchError(ContainSubstring("extension server not ready after 1m0s")))
+			Expect(ready).To(BeFalse())
+
+			cond := meta.FindStatusCondition(stored().Status.Conditions, aiplatformv1alpha1.ConditionServerReady)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(aiplatformv1alpha1.ReasonReadinessTimeout))
+			Expect(cond.LastTransitionTime.Time).To(BeTemporally("==", started))
+		})
+
+		It("retries a timeout with backoff", func() {
+			ext := withTimeout(started)
+			setUp(ext)
+
+			_, err := reconciler.waitForServer(ctx, ext, endpoint, nil)
+			Expect(err).To(HaveOccurred())
+
+			result, err := reconciler.recordOutcome(ctx, stored(), ctrl.Result{}, err)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			latest := stored()
+			Expect(latest.Status.FailureClass).To(Equal(aiplatformv1alpha1.FailureTransient))
+			Expect(latest.Status.Phase).NotTo(Equal(aiplatformv1alpha1.PhaseFailed))
+		})
+
+		It("fails the extension once the timeout spent the retry budget", func() {
+			ext := withTimeout(started)
+			ext.Status.FailureCount = 2
+			ext.Status.FailedGeneration = 2
+			setUp(ext)
+
+			_, err := reconciler.waitForServer(ctx, ext, endpoint, nil)
+			Expect(err).To(HaveOccurred())
+
+			result, err := reconciler.recordOutcome(ctx, stored(), ctrl.Result{}, err)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			latest := stored()
+			Expect(latest.Status.Phase).To(Equal(aiplatformv1alpha1.PhaseFailed))
+			Expect(latest.Status.Message).To(ContainSubstring("extension server not ready after 1m0s"))
+			Expect(latest.Status.PhaseHistory[len(latest.Status.PhaseHistory)-1].Reason).
+				To(Equal(aiplatformv1alpha1.ReasonRetryBudgetExhausted))
+			Expect(retriesStopped(latest)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// releaseLockPollInterval is how often a release locked by another Helm
+// operation is checked again.
+const releaseLockPollInterval = 30 * time.Second
+
+// reconcileRelease installs or upgrades the Helm release and returns the
+// extension version served by the revision running afterwards. After a
+// rolled back upgrade that is the previously deployed version, and the
+// failed generation is not retried until the spec changes or a reconcile
+// is requested through the reconcile-at annotation.
+func (r *InstallAIExtensionReconciler) reconcileRelease(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	helm helmClient.HelmClient,
+	spec helmClient.ReleaseSpec,
+	driftCheckInterval time.Duration,
+) (string, error) {
+
+	log := logging.FromContext(ctx, "release").WithValues(
+		logging.KeyExtension, ext.Name,
+		logging.KeyName, spec.Name,
+	)
+
+	key := types.NamespacedName{Name: ext.Name}
+
+	_, forced := reconcileRequested(ext)
+	if failed := ext.Status.FailedUpgrade; failed != nil && failed.ObservedGeneration == ext.Generation && !forced {
+		logging.Debug(log).Info(
+			"Upgrade already failed for this generation, waiting for a spec change",
+			"failedRevision", failed.Revision,
+		)
+		return deployedExtensionVersion(ext, nil), nil
+	}
+
+	if d := ext.Status.Deployed; d != nil {
+		spec.AppliedHash = d.DesiredStateHash
+		spec.AppliedRevision = d.Revision
+		spec.CheckDrift = forced || driftCheckDue(d, driftCheckInterval)
+	}
+
+	start := time.Now()
+	result, err := helm.EnsureRelease(ctx, spec)
+	observeRelease(ext, result, err, time.Since(start))
+
+	var locked *helmClient.ReleaseLockedError
+	if errors.As(err, &locked) {
+		log.Info("Helm release is locked by another operation", "status", locked.Status, "age", locked.Age)
+		r.recordWaiting(ext, "Waiting for another Helm operation on release %s/%s to finish (%s)",
+			spec.Namespace, spec.Name, locked.Status)
+		if statusErr := r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			setCondition(latest, aiplatformv1alpha1.ConditionReleased, metav1.ConditionFalse,
+				aiplatformv1alpha1.ReasonReleaseLocked, locked.Error())
+		}); statusErr != nil {
+			return "", statusErr
+		}
+		return "", err
+	}
+
+	if result != nil && len(result.Recovery) > 0 {
+		r.recordRecovery(ext, result)
+	}
+
+	var upgradeErr *helmClient.UpgradeFailedError
+	if errors.As(err, &upgradeErr) && result != nil {
+		log.Info("Upgrade failed and was rolled back",
+			"failedRevision", upgradeErr.Revision,
+			"revision", result.Revision,
+			"chartVersion", result.ChartVersion,
+		)
+
+		version := deployedExtensionVersion(ext, result)
+		r.event(ext, corev1.EventTypeWarning, aiplatformv1alpha1.ReasonUpgradeRolledBack,
+			"Upgrade to chart version %s failed (revision %d), rolled back to revision %d running extension version %s: %v",
+			upgradeErr.ChartVersion, upgradeErr.Revision, result.Revision, version, upgradeErr.Err)
+
+		return version, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			latest.Status.FailedUpgrade = &aiplatformv1alpha1.FailedUpgrade{
+				Revision:           upgradeErr.Revision,
+				ChartVersion:       upgradeErr.ChartVersion,
+				RolledBackTo:       upgradeErr.RolledBackTo,
+				Reason:             upgradeErr.Err.Error(),
+				ObservedGeneration: ext.Generation,
+				Time:               metav1.Now(),
+			}
+			latest.Status.Deployed = &aiplatformv1alpha1.DeployedRelease{
+				Revision:         result.Revision,
+				ChartVersion:     result.ChartVersion,
+				Namespace:        spec.Namespace,
+				ChartDigest:      result.ChartDigest,
+				ExtensionVersion: version,
+			}
+			setCondition(latest, aiplatformv1alpha1.ConditionReleased, metav1.ConditionFalse,
+				aiplatformv1alpha1.ReasonUpgradeRolledBack, fmt.Sprintf(
+					"Upgrade to revision %d failed, rolled back to revision %d", upgradeErr.Revision, result.Revision))
+		})
+	}
+
+	verifyReason, unverified := verificationFailure(err)
+	if unverified {
+		log.Info("Chart failed verification, not installing", "reason", verifyReason, "error", err.Error())
+		r.event(ext, corev1.EventTypeWarning, verifyReason, "%s", err.Error())
+	}
+	releaseReason := aiplatformv1alpha1.ReasonReleaseFailed
+	if permissionDenied(ext, err) {
+		releaseReason = aiplatformv1alpha1.ReasonInsufficientPermissions
+		log.Info("Service account lacks permissions for the release",
+			"serviceAccount", ext.Spec.ServiceAccountName, "error", err.Error())
+		r.event(ext, corev1.EventTypeWarning, releaseReason,
+			"Service account %s/%s lacks permissions: %s", spec.Namespace, ext.Spec.ServiceAccountName, err.Error())
+	}
+	if err != nil {
+		if statusErr := r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			if unverified {
+				setCondition(latest, aiplatformv1alpha1.ConditionChartVerified, metav1.ConditionFalse,
+					verifyReason, err.Error())
+			}
+			setCondition(latest, aiplatformv1alpha1.ConditionReleased, metav1.ConditionFalse,
+				releaseReason, err.Error())
+		}); statusErr != nil {
+			log.Error(statusErr, "Failed to record release failure")
+		}
+		return "", err
+	}
+
+	logging.Debug(log).Info("Helm release reconciled",
+		"action", result.Action,
+		"revision", result.Revision,
+		"rendered", result.Rendered,
+	)
+
+	r.recordReleaseEvents(ext, spec, result)
+
+	if !result.Rendered {
+		// Nothing was compared, so status still describes the release.
+		return ext.Spec.Extension.Version, nil
+	}
+
+	now := metav1.Now()
+
+	var upgradeDiff *aiplatformv1alpha1.UpgradeDiff
+	if result.Action == helmClient.ActionUpgraded && !result.Diff.Empty() {
+		upgradeDiff = r.recordUpgradeDiff(ctx, ext, spec.Namespace, result, spec.Options.MaxHistory)
+	}
+
+	return ext.Spec.Extension.Version, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		latest.Status.FailedUpgrade = nil
+		latest.Status.Deployed = &aiplatformv1alpha1.DeployedRelease{
+			Revision:         result.Revision,
+			ChartVersion:     result.ChartVersion,
+			Namespace:        spec.Namespace,
+			ChartDigest:      result.ChartDigest,
+			ExtensionVersion: ext.Spec.Extension.Version,
+			DesiredStateHash: result.Hash,
+			LastRenderTime:   &now,
+		}
+		if upgradeDiff != nil {
+			latest.Status.LastUpgradeDiff = upgradeDiff
+		}
+
+		reason := aiplatformv1alpha1.ReasonReleaseDeployed
+		message := fmt.Sprintf("Revision %d of chart version %s is deployed", result.Revision, result.ChartVersion)
+		if len(result.Recovery) > 0 {
+			reason = aiplatformv1alpha1.ReasonReleaseRecovered
+			message = fmt.Sprintf("%s after recovery: %s", message, strings.Join(result.Recovery, "; "))
+		}
+		setCondition(latest, aiplatformv1alpha1.ConditionReleased, metav1.ConditionTrue, reason, message)
+		setChartVerified(latest, spec, result)
+	})
+}
+
+// recordRecovery emits an event for the steps taken to recover a stuck
+// release.
+func (r *InstallAIExtensionReconciler) recordRecovery(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	result *helmClient.ReleaseResult,
+) {
+	r.event(ext, corev1.EventTypeWarning, "ReleaseRecovered",
+		"Recovered Helm release (%s): %s", result.Action, strings.Join(result.Recovery, "; "))
+}
+
+// driftCheckDue reports whether the release should be rendered and
+// compared even though its desired state is unchanged.
+func driftCheckDue(d *aiplatformv1alpha1.DeployedRelease, interval time.Duration) bool {
+	if interval <= 0 {
+		return false
+	}
+	return d.LastRenderTime == nil || time.Since(d.LastRenderTime.Time) >= interval
+}
+
+// deployedExtensionVersion returns the extension version recorded for the
+// running release. Without one it falls back to the app version, then the
+// chart version of running, the release a failed upgrade was rolled back
+// to, or to the chart version recorded in status. The desired version is
+// never used: after a rolled back upgrade it is the one that failed.
+func deployedExtensionVersion(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	running *helmClient.ReleaseResult,
+) string {
+
+	d := ext.Status.Deployed
+	switch {
+	case d != nil && d.ExtensionVersion != "":
+		return d.ExtensionVersion
+	case running != nil && running.AppVersion != "":
+		return running.AppVersion
+	case running != nil:
+		return running.ChartVersion
+	case d != nil:
+		return d.ChartVersion
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+)
+
+// fakeHelm is a HelmClient answering with canned results. It records the
+// releases it was asked to ensure, plan and delete.
+type fakeHelm struct {
+	ensure func(spec helmClient.ReleaseSpec) (*helmClient.ReleaseResult, error)
+	plan   func(spec helmClient.ReleaseSpec) (*helmClient.ReleasePlan, error)
+
+	ensured []helmClient.ReleaseSpec
+	planned []helmClient.ReleaseSpec
+	deleted []string
+}
+
+func (f *fakeHelm) EnsureRelease(_ context.Context, spec helmClient.ReleaseSpec) (*helmClient.ReleaseResult, error) {
+	f.ensured = append(f.ensured, spec)
+	if f.ensure == nil {
+		return &helmClient.ReleaseResult{Action: helmClient.ActionUnchanged, Revision: 1}, nil
+	}
+	return f.ensure(spec)
+}
+
+func (f *fakeHelm) DeleteRelease(_ context.Context, name string) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func (f *fakeHelm) GetRelease(context.Context, string) (*helmClient.ReleaseInfo, error) {
+	return nil, helmClient.ErrReleaseNotFound
+}
+
+func (f *fakeHelm) PlanRelease(_ context.Context, spec helmClient.ReleaseSpec) (*helmClient.ReleasePlan, error) {
+	f.planned = append(f.planned, spec)
+	if f.plan == nil {
+		return nil, errors.New("not implemented")
+	}
+	return f.plan(spec)
+}
+
+var _ = Describe("Rolled back upgrades", func() {
+	DescribeTable("deployedExtensionVersion",
+		func(deployed *aiplatformv1alpha1.DeployedRelease, running *helmClient.ReleaseResult, expected string) {
+			ext := &aiplatformv1alpha1.InstallAIExtension{}
+			ext.Spec.Extension.Version = "2.0.0"
+			ext.Status.Deployed = deployed
+
+			Expect(deployedExtensionVersion(ext, running)).To(Equal(expected))
+		},
+		Entry("uses the recorded extension version",
+			&aiplatformv1alpha1.DeployedRelease{ExtensionVersion: "1.0.0", ChartVersion: "0.1.0"},
+			&helmClient.ReleaseResult{AppVersion: "1.0.1", ChartVersion: "0.1.1"},
+			"1.0.0"),
+		Entry("falls back to the app version of the running release",
+			nil, &helmClient.ReleaseResult{AppVersion: "1.0.1", ChartVersion: "0.1.1"}, "1.0.1"),
+		Entry("falls back to the chart version of the running release",
+			&aiplatformv1alpha1.DeployedRelease{ChartVersion: "0.1.0"},
+			&helmClient.ReleaseResult{ChartVersion: "0.1.1"},
+			"0.1.1"),
+		Entry("falls back to the recorded chart version",
+			&aiplatformv1alpha1.DeployedRelease{ChartVersion: "0.1.0"}, nil, "0.1.0"),
+		Entry("never uses the desired version", nil, nil, ""),
+	)
+
+	Describe("reconcileRelease", func() {
+		var (
+			ctx        context.Context
+			reconciler *InstallAIExtensionReconciler
+			recorder   *record.FakeRecorder
+			helm       *fakeHelm
+		)
+		spec := helmClient.ReleaseSpec{Name: "ext", Namespace: "default", Version: "0.2.0"}
+
+		setUp := func(ext *aiplatformv1alpha1.InstallAIExtension) {
+			scheme := runtime.NewScheme()
+			Expect(aiplatformv1alpha1.AddToScheme(scheme)).To(Succeed())
+			recorder = record.NewFakeRecorder(10)
+			reconciler = &InstallAIExtensionReconciler{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(ext).
+					WithStatusSubresource(ext).
+					Build(),
+				Scheme:   scheme,
+				Recorder: recorder,
+			}
+		}
+
+		stored := func() *aiplatformv1alpha1.InstallAIExtension {
+			var ext aiplatformv1alpha1.InstallAIExtension
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "ext"}, &ext)).To(Succeed())
+			return &ext
+		}
+
+		newExtension := func() *aiplatformv1alpha1.InstallAIExtension {
+			ext := &aiplatformv1alpha1.InstallAIExtension{
+				ObjectMeta: metav1.ObjectMeta{Name: "ext", Generation: 2},
+			}
+			ext.Spec.Extension.Version = "2.0.0"
+			return ext
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			helm = &fakeHelm{}
+		})
+
+		It("registers the version of the release rolled back to without a recorded one", func() {
+			ext := newExtension()
+			setUp(ext)
+			helm.ensure = func(helmClient.ReleaseSpec) (*helmClient.ReleaseResult, error) {
+				return &helmClient.ReleaseResult{
+					Action:       helmClient.ActionRolledBack,
+					Revision:     3,
+					ChartVersion: "0.1.0",
+					AppVersion:   "1.0.0",
+				}, &helmClient.UpgradeFailedError{
+					Revision: 2, ChartVersion: "0.2.0", RolledBackTo: 3, Err: errors.New("timed out"),
+				}
+			}
+
+			version, err := reconciler.reconcileRelease(ctx, ext, helm, spec, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("1.0.0"))
+
+			latest := stored()
+			Expect(latest.Status.Deployed.ExtensionVersion).To(Equal("1.0.0"))
+			Expect(latest.Status.Deployed.ChartVersion).To(Equal("0.1.0"))
+			Expect(latest.Status.FailedUpgrade.ChartVersion).To(Equal("0.2.0"))
+			Expect(meta.IsStatusConditionFalse(latest.Status.Conditions, aiplatformv1alpha1.ConditionReleased)).To(BeTrue())
+			Expect(recorder.Events).To(Receive(And(
+				HavePrefix("Warning "+aiplatformv1alpha1.ReasonUpgradeRolledBack),
+				ContainSubstring("running extension version 1.0.0"),
+			)))
+		})
+
+		It("keeps the rolled back version while the failed generation is not retried", func() {
+			ext := newExtension()
+			ext.Status.FailedUpgrade = &aiplatformv1alpha1.FailedUpgrade{Revision: 2, ObservedGeneration: 2}
+			ext.Status.Deployed = &aiplatformv1alpha1.DeployedRelease{Revision: 3, ChartVersion: "0.1.0"}
+			setUp(ext)
+
+			version, err := reconciler.reconcileRelease(ctx, ext, helm, spec, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("0.1.0"))
+			Expect(helm.ensured).To(BeEmpty())
+		})
+	})
+})
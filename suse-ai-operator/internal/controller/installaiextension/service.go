@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/infra/kubernetes"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+type serviceEndpoint struct {
+	Name      string
+	Namespace string
+	Port      int32
+	URL       string
+	TLS       bool
+}
+
+// resolveService finds the Service serving the extension assets and
+// returns its endpoint. Resolution failures are recorded in the
+// ServiceResolved condition and returned, so nothing is registered in
+// Rancher with a broken endpoint.
+func (r *InstallAIExtensionReconciler) resolveService(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	namespace string,
+	releaseName string,
+) (*serviceEndpoint, error) {
+
+	log := logging.FromContext(ctx, "service").WithValues(
+		logging.KeyExtension, ext.Name,
+		logging.KeyNamespace, namespace,
+	)
+
+	endpoint, err := r.serviceEndpoint(ctx, &ext.Spec.Extension, namespace, releaseName)
+	if err != nil {
+		reason := aiplatformv1alpha1.ReasonServiceNotFound
+		var portErr *installaiextension.PortNotFoundError
+		if errors.As(err, &portErr) {
+			reason = aiplatformv1alpha1.ReasonPortNotFound
+		}
+
+		log.Error(err, "Failed to resolve extension service")
+
+		if statusErr := r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			setCondition(latest, aiplatformv1alpha1.ConditionServiceResolved, metav1.ConditionFalse, reason, err.Error())
+		}); statusErr != nil {
+			log.Error(statusErr, "Failed to update status")
+		}
+		return nil, err
+	}
+
+	logging.Debug(log).Info("Resolved extension service", "url", endpoint.URL)
+
+	return endpoint, nil
+}
+
+func (r *InstallAIExtensionReconciler) serviceEndpoint(
+	ctx context.Context,
+	spec *aiplatformv1alpha1.ExtensionSpec,
+	namespace string,
+	releaseName string,
+) (*serviceEndpoint, error) {
+
+	svc, err := r.selectService(ctx, spec.Service, namespace, releaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	return endpointForService(spec, svc)
+}
+
+// endpointForService selects the port of svc and builds the URL the
+// extension is served from.
+func endpointForService(spec *aiplatformv1alpha1.ExtensionSpec, svc *corev1.Service) (*serviceEndpoint, error) {
+	sel := spec.Service
+
+	var port *intstr.IntOrString
+	if sel != nil {
+		port = sel.Port
+	}
+
+	svcName, svcNamespace, svcPort, err := installaiextension.ServiceEndpoint(svc, port)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := installaiextension.ServiceScheme(svc, svcPort)
+	if spec.TLS != nil {
+		scheme = "https"
+	}
+
+	return &serviceEndpoint{
+		Name:      svcName,
+		Namespace: svcNamespace,
+		Port:      svcPort,
+		URL:       fmt.Sprintf("%s://%s.%s:%d", scheme, svcName, svcNamespace, svcPort),
+		TLS:       scheme == "https",
+	}, nil
+}
+
+func (r *InstallAIExtensionReconciler) selectService(
+	ctx context.Context,
+	sel *aiplatformv1alpha1.ServiceSelector,
+	namespace string,
+	releaseName string,
+) (*corev1.Service, error) {
+
+	switch {
+	case sel != nil && sel.Name != "":
+		return kubernetes.ServiceByName(ctx, r.Client, namespace, sel.Name)
+
+	case sel != nil && sel.Selector != nil:
+		selector, err := metav1.LabelSelectorAsSelector(sel.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service selector: %w", err)
+		}
+		items, err := kubernetes.ServicesBySelector(ctx, r.Client, namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		return &items[0], nil
+
+	default:
+		return kubernetes.ServiceForHelmRelease(ctx, r.Client, namespace, releaseName)
+	}
+}
+
+// selectRenderedService applies the same selection as selectService to the
+// Services of a rendered manifest.
+func selectRenderedService(
+	items []corev1.Service,
+	sel *aiplatformv1alpha1.ServiceSelector,
+	namespace string,
+	releaseName string,
+) (*corev1.Service, error) {
+
+	var selector labels.Selector
+	var desc string
+
+	switch {
+	case sel != nil && sel.Name != "":
+		for i := range items {
+			if items[i].Name == sel.Name {
+				return &items[i], nil
+			}
+		}
+		return nil, &kubernetes.ServiceNotFoundError{Namespace: namespace, Selector: fmt.Sprintf("name %q", sel.Name)}
+
+	case sel != nil && sel.Selector != nil:
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(sel.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service selector: %w", err)
+		}
+		desc = selector.String()
+
+	default:
+		selector = labels.SelectorFromSet(labels.Set{kubernetes.LabelInstance: releaseName})
+		desc = selector.String()
+	}
+
+	for i := range items {
+		if selector.Matches(labels.Set(items[i].Labels)) {
+			return &items[i], nil
+		}
+	}
+	return nil, &kubernetes.ServiceNotFoundError{Namespace: namespace, Selector: fmt.Sprintf("selector %q", desc)}
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+// updateStatus applies mutate to the latest version of the object and
+// writes its status, retrying on conflicts.
+func (r *InstallAIExtensionReconciler) updateStatus(
+	ctx context.Context,
+	key types.NamespacedName,
+	mutate func(ext *aiplatformv1alpha1.InstallAIExtension),
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var latest aiplatformv1alpha1.InstallAIExtension
+		if err := r.Get(ctx, key, &latest); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		mutate(&latest)
+
+		if err := r.Status().Update(ctx, &latest); err != nil {
+			return err
+		}
+		metrics.SetExtensionReady(latest.Name, latest.Status.Phase == aiplatformv1alpha1.PhaseReady)
+		return nil
+	})
+}
+
+func setCondition(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	condType string,
+	status metav1.ConditionStatus,
+	reason, message string,
+) {
+	meta.SetStatusCondition(&ext.Status.Conditions, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: ext.Generation,
+	})
+}
+
+// resetStaleCondition drops a condition recorded for an older generation so
+// its LastTransitionTime restarts with the current spec.
+func resetStaleCondition(ext *aiplatformv1alpha1.InstallAIExtension, condType string) {
+	cond := meta.FindStatusCondition(ext.Status.Conditions, condType)
+	if cond != nil && cond.ObservedGeneration != ext.Generation {
+		meta.RemoveStatusCondition(&ext.Status.Conditions, condType)
+	}
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+const suspendedMessage = "Reconciliation is suspended with spec.suspend"
+
+// reconcileSuspend records whether ext is suspended in the Suspended
+// condition and reports whether reconciliation should stop. Nothing in
+// the cluster is changed while an extension is suspended, and it is not
+// requeued: unsetting spec.suspend triggers the next reconcile.
+func (r *InstallAIExtensionReconciler) reconcileSuspend(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+) (bool, error) {
+
+	log := logging.FromContext(ctx, "suspend").WithValues(logging.KeyExtension, ext.Name)
+	key := types.NamespacedName{Name: ext.Name}
+	cond := meta.FindStatusCondition(ext.Status.Conditions, aiplatformv1alpha1.ConditionSuspended)
+
+	if !ext.Spec.Suspend {
+		if cond == nil || cond.Status != metav1.ConditionTrue {
+			return false, nil
+		}
+		log.Info("Reconciliation resumed")
+		r.event(ext, corev1.EventTypeNormal, aiplatformv1alpha1.ReasonResumed, "Reconciliation resumed")
+		return false, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+			setCondition(latest, aiplatformv1alpha1.ConditionSuspended, metav1.ConditionFalse,
+				aiplatformv1alpha1.ReasonResumed, "Reconciliation resumed")
+		})
+	}
+
+	if cond != nil && cond.Status == metav1.ConditionTrue && ext.Status.Phase == aiplatformv1alpha1.PhaseSuspended {
+		logging.Debug(log).Info("Extension is suspended, skipping")
+		return true, nil
+	}
+
+	log.Info("Reconciliation suspended")
+	r.event(ext, corev1.EventTypeNormal, aiplatformv1alpha1.ReasonSuspended, "%s", suspendedMessage)
+	return true, r.updateStatus(ctx, key, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		installaiextension.SetPhase(latest, aiplatformv1alpha1.PhaseSuspended,
+			aiplatformv1alpha1.ReasonSuspended, suspendedMessage)
+		setCondition(latest, aiplatformv1alpha1.ConditionSuspended, metav1.ConditionTrue,
+			aiplatformv1alpha1.ReasonSuspended, suspendedMessage)
+	})
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/config"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/infra/kubernetes"
+)
+
+// newTestReconciler returns a reconciler running every reconcile with
+// helm, backed by a fake client holding objs and the Service of release
+// "ext" in namespace default.
+func newTestReconciler(helm *fakeHelm, objs ...client.Object) (*InstallAIExtensionReconciler, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(aiplatformv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ext",
+			Namespace: "default",
+			Labels:    map[string]string{kubernetes.LabelInstance: "ext"},
+		},
+		Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+	}
+
+	recorder := record.NewFakeRecorder(20)
+	return &InstallAIExtensionReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(append(objs, svc)...).
+			WithStatusSubresource(&aiplatformv1alpha1.InstallAIExtension{}).
+			Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+		Settings: config.NewStore(config.Settings{ExtensionNamespace: "default"}),
+		NewHelmClient: func(*cli.EnvSettings, *helmClient.ChartCache, ...helmClient.Option) (helmClient.HelmClient, error) {
+			return helm, nil
+		},
+	}, recorder
+}
+
+// newInstalledExtension returns extension "ext" with revision 1 of its
+// release deployed and a spec change not rolled out yet.
+func newInstalledExtension() *aiplatformv1alpha1.InstallAIExtension {
+	ext := &aiplatformv1alpha1.InstallAIExtension{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "ext",
+			Generation: 2,
+			Finalizers: []string{finalizerName},
+		},
+	}
+	ext.Spec.Helm = &aiplatformv1alpha1.HelmSpec{
+		Name:    "ext",
+		URL:     "oci://registry.example.com/charts/ext",
+		Version: "0.2.0",
+	}
+	ext.Spec.Extension.Name = "ext"
+	ext.Spec.Extension.Version = "2.0.0"
+	ext.Status.Phase = aiplatformv1alpha1.PhaseReady
+	ext.Status.ObservedGeneration = 1
+	ext.Status.Deployed = &aiplatformv1alpha1.DeployedRelease{
+		Revision:         1,
+		ChartVersion:     "0.1.0",
+		Namespace:        "default",
+		ExtensionVersion: "1.0.0",
+	}
+	return ext
+}
+
+// phases returns the phases ext entered, oldest first.
+func phases(ext *aiplatformv1alpha1.InstallAIExtension) []string {
+	out := make([]string, 0, len(ext.Status.PhaseHistory))
+	for _, t := range ext.Status.PhaseHistory {
+		out = append(out, t.Phase)
+	}
+	return out
+}
+
+var _ = Describe("Suspend", func() {
+	var (
+		ctx        context.Context
+		reconciler *InstallAIExtensionReconciler
+		recorder   *record.FakeRecorder
+		helm       *fakeHelm
+	)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ext"}}
+
+	stored := func() *aiplatformv1alpha1.InstallAIExtension {
+		var ext aiplatformv1alpha1.InstallAIExtension
+		Expect(reconciler.Get(ctx, req.NamespacedName, &ext)).To(Succeed())
+		return &ext
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		helm = &fakeHelm{}
+
+		ext := newInstalledExtension()
+		ext.Spec.Suspend = true
+		reconciler, recorder = newTestReconciler(helm, ext)
+	})
+
+	It("does not reconcile a suspended extension", func() {
+		result, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(helm.ensured).To(BeEmpty())
+
+		latest := stored()
+		Expect(latest.Status.Phase).To(Equal(aiplatformv1alpha1.PhaseSuspended))
+		Expect(latest.Status.ObservedGeneration).To(Equal(int64(1)))
+		Expect(meta.IsStatusConditionTrue(latest.Status.Conditions, aiplatformv1alpha1.ConditionSuspended)).To(BeTrue())
+		Expect(recorder.Events).To(Receive(Equal("Normal " + aiplatformv1alpha1.ReasonSuspended + " " + suspendedMessage)))
+
+		By("skipping later reconciles without recording them again")
+		result, err = reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(helm.ensured).To(BeEmpty())
+		Expect(recorder.Events).NotTo(Receive())
+		Expect(phases(stored())).To(Equal([]string{aiplatformv1alpha1.PhaseSuspended}))
+	})
+
+	It("rolls out the spec once resumed", func() {
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive())
+
+		resumed := stored()
+		resumed.Spec.Suspend = false
+		resumed.Generation = 3
+		Expect(reconciler.Update(ctx, resumed)).To(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(readinessPollInterval))
+		Expect(helm.ensured).To(HaveLen(1))
+		Expect(helm.ensured[0].Version).To(Equal("0.2.0"))
+
+		latest := stored()
+		cond := meta.FindStatusCondition(latest.Status.Conditions, aiplatformv1alpha1.ConditionSuspended)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(aiplatformv1alpha1.ReasonResumed))
+		Expect(phases(latest)).To(Equal([]string{
+			aiplatformv1alpha1.PhaseSuspended,
+			aiplatformv1alpha1.PhaseResolving,
+			aiplatformv1alpha1.PhaseUpgrading,
+			aiplatformv1alpha1.PhaseRegistering,
+		}))
+		Expect(recorder.Events).To(Receive(Equal("Normal " + aiplatformv1alpha1.ReasonResumed + " Reconciliation resumed")))
+	})
+})
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// ensureTLS issues the serving certificate requested in spec.extension.tls
+// and returns the CA bundle Rancher and the operator must trust to reach
+// the extension server. A nil bundle means the system roots are used.
+func (r *InstallAIExtensionReconciler) ensureTLS(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	endpoint *serviceEndpoint,
+) ([]byte, error) {
+
+	tls := ext.Spec.Extension.TLS
+	if tls == nil {
+		return nil, nil
+	}
+
+	log := logging.FromContext(ctx, "tls").WithValues(
+		logging.KeyExtension, ext.Name,
+		logging.KeyName, endpoint.Name,
+	)
+
+	if cert := tls.Certificate; cert != nil {
+		dnsNames := certs.ServiceDNSNames(endpoint.Name, endpoint.Namespace)
+
+		var err error
+		if cert.CASecretName != "" {
+			err = certs.EnsureServingSecret(ctx, r.Client, endpoint.Namespace, cert.SecretName, cert.CASecretName, dnsNames)
+		} else {
+			err = certs.EnsureCertificate(ctx, r.Client, endpoint.Namespace, cert.SecretName, cert.SecretName, dnsNames,
+				certs.IssuerRef{
+					Name:  cert.IssuerRef.Name,
+					Kind:  cert.IssuerRef.Kind,
+					Group: cert.IssuerRef.Group,
+				})
+		}
+		if err != nil {
+			return nil, r.tlsNotReady(ctx, ext, err)
+		}
+	}
+
+	name, key, ok := installaiextension.CABundleRef(tls)
+	if !ok {
+		return nil, nil
+	}
+
+	caBundle, err := certs.SecretValue(ctx, r.Client, endpoint.Namespace, name, key)
+	if err != nil {
+		return nil, r.tlsNotReady(ctx, ext, err)
+	}
+
+	logging.Debug(log).Info("Resolved CA bundle", "secret", name, "key", key)
+	return caBundle, nil
+}
+
+// tlsNotReady records a TLS problem in the ServerReady condition and
+// returns err. Missing Secrets are expected while an issuer is working and
+// only logged at debug level.
+func (r *InstallAIExtensionReconciler) tlsNotReady(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	err error,
+) error {
+	log := logging.FromContext(ctx, "tls").WithValues(logging.KeyExtension, ext.Name)
+
+	var notReady *certs.SecretNotReadyError
+	if errors.As(err, &notReady) {
+		logging.Debug(log).Info("Waiting for TLS secret", "secret", notReady.Name)
+	} else {
+		log.Error(err, "Failed to set up TLS for extension server")
+	}
+
+	if statusErr := r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		setCondition(latest, aiplatformv1alpha1.ConditionServerReady, metav1.ConditionFalse,
+			aiplatformv1alpha1.ReasonTLSNotReady, err.Error())
+	}); statusErr != nil {
+		log.Error(statusErr, "Failed to update status")
+	}
+
+	return err
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// reconcileRequested returns the value of the reconcile-at annotation
+// and whether it has not been handled yet.
+func reconcileRequested(ext *aiplatformv1alpha1.InstallAIExtension) (string, bool) {
+	value := ext.Annotations[aiplatformv1alpha1.AnnotationReconcileAt]
+	return value, value != "" && value != ext.Status.LastHandledReconcileAt
+}
+
+// reinstallRequested returns the reinstall token and whether the release
+// has not been reinstalled for it yet.
+func reinstallRequested(ext *aiplatformv1alpha1.InstallAIExtension) (string, bool) {
+	token := ext.Annotations[aiplatformv1alpha1.AnnotationReinstall]
+	return token, token != "" && token != ext.Status.LastHandledReinstall
+}
+
+// triggerPending reports whether a reconcile-at or reinstall token has
+// not been handled yet. Either is attempted even after retries stopped.
+func triggerPending(ext *aiplatformv1alpha1.InstallAIExtension) bool {
+	_, reconcileAt := reconcileRequested(ext)
+	_, reinstall := reinstallRequested(ext)
+	return reconcileAt || reinstall
+}
+
+// reinstall uninstalls the Helm release when a new reinstall token is
+// set, so the following reconcileRelease installs it from scratch. The
+// token is recorded as soon as the release is gone, so a failing install
+// is retried without uninstalling again. The deployed release and any
+// failed upgrade are cleared with it, since neither exists any more. The
+// UIPlugin and ClusterRepo are kept.
+func (r *InstallAIExtensionReconciler) reinstall(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	helm helmClient.HelmClient,
+	releaseName string,
+) error {
+
+	token, requested := reinstallRequested(ext)
+	if !requested {
+		return nil
+	}
+
+	log := logging.FromContext(ctx, "reinstall").WithValues(
+		logging.KeyExtension, ext.Name,
+		logging.KeyName, releaseName,
+	)
+
+	message := fmt.Sprintf("Reinstalling Helm release %s for token %q", releaseName, token)
+	log.Info("Reinstall requested, uninstalling the Helm release", "token", token)
+	r.event(ext, corev1.EventTypeNormal, aiplatformv1alpha1.ReasonReinstalling, "%s", message)
+
+	if err := uninstallRelease(ctx, ext, helm, releaseName); err != nil {
+		return fmt.Errorf("failed to uninstall release for reinstall: %w", err)
+	}
+
+	if err := r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		latest.Status.LastHandledReinstall = token
+		latest.Status.Deployed = nil
+		latest.Status.FailedUpgrade = nil
+		installaiextension.SetPhase(latest, aiplatformv1alpha1.PhaseInstalling,
+			aiplatformv1alpha1.ReasonReinstalling, message)
+		setCondition(latest, aiplatformv1alpha1.ConditionReleased, metav1.ConditionFalse,
+			aiplatformv1alpha1.ReasonReinstalling, message)
+	}); err != nil {
+		return err
+	}
+
+	ext.Status.LastHandledReinstall = token
+	ext.Status.Deployed = nil
+	ext.Status.FailedUpgrade = nil
+	return nil
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+)
+
+var _ = Describe("Triggers", func() {
+	DescribeTable("reconcileRequested",
+		func(annotation, handled string, expected bool) {
+			ext := &aiplatformv1alpha1.InstallAIExtension{}
+			if annotation != "" {
+				ext.Annotations = map[string]string{aiplatformv1alpha1.AnnotationReconcileAt: annotation}
+			}
+			ext.Status.LastHandledReconcileAt = handled
+
+			value, requested := reconcileRequested(ext)
+			Expect(value).To(Equal(annotation))
+			Expect(requested).To(Equal(expected))
+		},
+		Entry("without the annotation", "", "", false),
+		Entry("for a new value", "t1", "", true),
+		Entry("for a value replacing a handled one", "t2", "t1", true),
+		Entry("for a handled value", "t1", "t1", false),
+	)
+
+	DescribeTable("reinstallRequested",
+		func(annotation, handled string, expected bool) {
+			ext := &aiplatformv1alpha1.InstallAIExtension{}
+			if annotation != "" {
+				ext.Annotations = map[string]string{aiplatformv1alpha1.AnnotationReinstall: annotation}
+			}
+			ext.Status.LastHandledReinstall = handled
+
+			token, requested := reinstallRequested(ext)
+			Expect(token).To(Equal(annotation))
+			Expect(requested).To(Equal(expected))
+		},
+		Entry("without the annotation", "", "", false),
+		Entry("for a new token", "r1", "", true),
+		Entry("for a token replacing a handled one", "r2", "r1", true),
+		Entry("for a handled token", "r1", "r1", false),
+	)
+
+	Describe("Reconcile", func() {
+		var (
+			ctx        context.Context
+			reconciler *InstallAIExtensionReconciler
+			recorder   *record.FakeRecorder
+			helm       *fakeHelm
+		)
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "ext"}}
+
+		stored := func() *aiplatformv1alpha1.InstallAIExtension {
+			var ext aiplatformv1alpha1.InstallAIExtension
+			Expect(reconciler.Get(ctx, req.NamespacedName, &ext)).To(Succeed())
+			return &ext
+		}
+
+		annotate := func(key, value string) {
+			latest := stored()
+			latest.Annotations = map[string]string{key: value}
+			Expect(reconciler.Update(ctx, latest)).To(Succeed())
+		}
+
+		// reconcile runs a reconcile that stops waiting for the extension
+		// server, which has no EndpointSlices.
+		reconcile := func() {
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(readinessPollInterval))
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			helm = &fakeHelm{}
+		})
+
+		Context("with a rolled out extension", func() {
+			BeforeEach(func() {
+				ext := newInstalledExtension()
+				ext.Status.ObservedGeneration = ext.Generation
+				reconciler, recorder = newTestReconciler(helm, ext)
+			})
+
+			It("corrects drift once for each reconcile-at value", func() {
+				reconcile()
+				Expect(helm.ensured).To(HaveLen(1))
+				Expect(helm.ensured[0].CheckDrift).To(BeFalse())
+
+				annotate(aiplatformv1alpha1.AnnotationReconcileAt, "t1")
+				reconcile()
+				Expect(helm.ensured).To(HaveLen(2))
+				Expect(helm.ensured[1].CheckDrift).To(BeTrue())
+
+				By("not forcing it again once the value is handled")
+				handled := stored()
+				handled.Status.LastHandledReconcileAt = "t1"
+				Expect(reconciler.Status().Update(ctx, handled)).To(Succeed())
+
+				reconcile()
+				Expect(helm.ensured).To(HaveLen(3))
+				Expect(helm.ensured[2].CheckDrift).To(BeFalse())
+
+				By("forcing it for a new value")
+				annotate(aiplatformv1alpha1.AnnotationReconcileAt, "t2")
+				reconcile()
+				Expect(helm.ensured).To(HaveLen(4))
+				Expect(helm.ensured[3].CheckDrift).To(BeTrue())
+			})
+
+			It("retries an upgrade that failed for the generation", func() {
+				failed := stored()
+				failed.Status.FailedUpgrade = &aiplatformv1alpha1.FailedUpgrade{
+					Revision:           2,
+					ObservedGeneration: failed.Generation,
+				}
+				Expect(reconciler.Status().Update(ctx, failed)).To(Succeed())
+
+				reconcile()
+				Expect(helm.ensured).To(BeEmpty())
+
+				annotate(aiplatformv1alpha1.AnnotationReconcileAt, "t1")
+				reconcile()
+				Expect(helm.ensured).To(HaveLen(1))
+				Expect(helm.ensured[0].CheckDrift).To(BeTrue())
+			})
+		})
+
+		Context("with retries stopped", func() {
+			BeforeEach(func() {
+				ext := newInstalledExtension()
+				ext.Status.ObservedGeneration = ext.Generation
+				ext.Status.Phase = aiplatformv1alpha1.PhaseFailed
+				ext.Status.FailureCount = 3
+				ext.Status.FailureClass = aiplatformv1alpha1.FailureTerminal
+				ext.Status.FailedGeneration = ext.Generation
+				reconciler, recorder = newTestReconciler(helm, ext)
+			})
+
+			It("skips the extension without a new token", func() {
+				result, err := reconciler.Reconcile(ctx, req)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(ctrl.Result{}))
+				Expect(helm.ensured).To(BeEmpty())
+				Expect(stored().Status.FailureCount).To(Equal(int32(3)))
+			})
+
+			It("reconciles for a new reconcile-at value", func() {
+				annotate(aiplatformv1alpha1.AnnotationReconcileAt, "t1")
+				reconcile()
+				Expect(helm.ensured).To(HaveLen(1))
+				Expect(helm.ensured[0].CheckDrift).To(BeTrue())
+				Expect(stored().Status.FailureCount).To(BeZero())
+			})
+
+			It("reinstalls for a new reinstall token", func() {
+				annotate(aiplatformv1alpha1.AnnotationReinstall, "r1")
+				reconcile()
+				Expect(helm.deleted).To(Equal([]string{"ext"}))
+				Expect(helm.ensured).To(HaveLen(1))
+				Expect(stored().Status.FailureCount).To(BeZero())
+			})
+		})
+
+		Context("with a reinstall token", func() {
+			var uiPlugin *unstructured.Unstructured
+
+			BeforeEach(func() {
+				uiPlugin = &unstructured.Unstructured{}
+				uiPlugin.SetAPIVersion("catalog.cattle.io/v1")
+				uiPlugin.SetKind("UIPlugin")
+				uiPlugin.SetName("ext")
+
+				ext := newInstalledExtension()
+				ext.Status.ObservedGeneration = ext.Generation
+				ext.Annotations = map[string]string{aiplatformv1alpha1.AnnotationReinstall: "r1"}
+				reconciler, recorder = newTestReconciler(helm, ext, uiPlugin)
+
+				helm.ensure = func(helmClient.ReleaseSpec) (*helmClient.ReleaseResult, error) {
+					return &helmClient.ReleaseResult{
+						Action:       helmClient.ActionInstalled,
+						Revision:     1,
+						ChartVersion: "0.2.0",
+						Rendered:     true,
+					}, nil
+				}
+			})
+
+			It("uninstalls and reinstalls the release, keeping the UIPlugin", func() {
+				reconcile()
+				Expect(helm.deleted).To(Equal([]string{"ext"}))
+				Expect(helm.ensured).To(HaveLen(1))
+				Expect(helm.ensured[0].AppliedRevision).To(BeZero())
+				Expect(helm.ensured[0].CheckDrift).To(BeFalse())
+
+				latest := stored()
+				Expect(latest.Status.LastHandledReinstall).To(Equal("r1"))
+				Expect(latest.Status.Deployed).NotTo(BeNil())
+				Expect(latest.Status.Deployed.ChartVersion).To(Equal("0.2.0"))
+				Expect(phases(latest)).To(ContainElement(aiplatformv1alpha1.PhaseInstalling))
+				Expect(recorder.Events).To(Receive(Equal("Normal " + aiplatformv1alpha1.ReasonReinstalling +
+					` Reinstalling Helm release ext for token "r1"`)))
+
+				Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(uiPlugin), uiPlugin)).To(Succeed())
+
+				By("not uninstalling again for the same token")
+				reconcile()
+				Expect(helm.deleted).To(HaveLen(1))
+				Expect(helm.ensured).To(HaveLen(2))
+				Expect(helm.ensured[1].AppliedRevision).To(Equal(1))
+			})
+		})
+	})
+})
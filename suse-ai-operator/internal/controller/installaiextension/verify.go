@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+	helmClient "github.com/SUSE/suse-ai-operator/internal/infra/helm"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+const (
+	defaultKeyringKey   = "keyring.gpg"
+	defaultCosignKeyKey = "cosign.pub"
+)
+
+// chartVerification loads the key spec.helm.verify references. It returns
+// nil when the chart is not verified.
+func (r *InstallAIExtensionReconciler) chartVerification(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	namespace string,
+) (*helmClient.Verification, error) {
+
+	v := ext.Spec.Helm.Verify
+	if v == nil {
+		return nil, nil
+	}
+
+	key := v.Key
+	if key == "" {
+		key = defaultKeyringKey
+		if v.Provider == string(helmClient.VerifyCosign) {
+			key = defaultCosignKeyKey
+		}
+	}
+
+	data, err := certs.SecretValue(ctx, r.Client, namespace, v.SecretName, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load verification key: %w", err)
+	}
+
+	return &helmClient.Verification{
+		Provider: helmClient.VerificationProvider(v.Provider),
+		Key:      data,
+	}, nil
+}
+
+// rejectUnverified reports an extension without spec.helm.verify while the
+// operator requires verification. Nothing is pulled or installed until
+// the spec changes.
+func (r *InstallAIExtensionReconciler) rejectUnverified(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+) error {
+
+	log := logging.FromContext(ctx, "verify").WithValues(logging.KeyExtension, ext.Name)
+
+	const message = "chart verification is required by the operator, set spec.helm.verify"
+	log.Info("Extension has no chart verification, not installing")
+
+	r.event(ext, corev1.EventTypeWarning, aiplatformv1alpha1.ReasonVerificationRequired, "%s", message)
+
+	return r.updateStatus(ctx, types.NamespacedName{Name: ext.Name}, func(latest *aiplatformv1alpha1.InstallAIExtension) {
+		installaiextension.SetPhase(latest, aiplatformv1alpha1.PhaseFailed,
+			aiplatformv1alpha1.ReasonVerificationRequired, message)
+		setCondition(latest, aiplatformv1alpha1.ConditionChartVerified, metav1.ConditionFalse,
+			aiplatformv1alpha1.ReasonVerificationRequired, message)
+	})
+}
+
+// verificationFailure returns the ChartVerified reason for errors caused
+// by a chart failing its digest or signature check.
+func verificationFailure(err error) (string, bool) {
+	var mismatch *helmClient.DigestMismatchError
+	var invalid *helmClient.VerificationError
+	switch {
+	case errors.As(err, &mismatch):
+		return aiplatformv1alpha1.ReasonDigestMismatch, true
+	case errors.As(err, &invalid):
+		return aiplatformv1alpha1.ReasonSignatureInvalid, true
+	}
+	return "", false
+}
+
+// setChartVerified records how the chart of a rendered release was
+// verified.
+func setChartVerified(
+	ext *aiplatformv1alpha1.InstallAIExtension,
+	spec helmClient.ReleaseSpec,
+	result *helmClient.ReleaseResult,
+) {
+	if v := result.Verification; v != nil {
+		ext.Status.Verification = &aiplatformv1alpha1.VerificationStatus{
+			Provider:    string(v.Provider),
+			SignedBy:    v.SignedBy,
+			ChartDigest: result.ChartDigest,
+			Revision:    result.Revision,
+			Time:        metav1.Now(),
+		}
+
+		message := fmt.Sprintf("Chart signed by %s (%s)", v.SignedBy, v.Provider)
+		if spec.Digest != "" {
+			message = fmt.Sprintf("%s, matches digest %s", message, spec.Digest)
+		}
+		setCondition(ext, aiplatformv1alpha1.ConditionChartVerified, metav1.ConditionTrue,
+			aiplatformv1alpha1.ReasonSignatureVerified, message)
+		return
+	}
+
+	ext.Status.Verification = nil
+	if spec.Digest != "" {
+		setCondition(ext, aiplatformv1alpha1.ConditionChartVerified, metav1.ConditionTrue,
+			aiplatformv1alpha1.ReasonDigestVerified, fmt.Sprintf("Chart matches digest %s", spec.Digest))
+		return
+	}
+	meta.RemoveStatusCondition(&ext.Status.Conditions, aiplatformv1alpha1.ConditionChartVerified)
+}
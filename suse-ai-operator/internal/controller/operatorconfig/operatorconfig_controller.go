@@ -0,0 +1,234 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/config"
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+const defaultCABundleKey = "ca.crt"
+
+// OperatorConfigReconciler applies the OperatorConfig named Name to
+// Settings. It runs on every replica, so replicas serving the admission
+// webhook without leading use the same settings.
+type OperatorConfigReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	Settings *config.Store
+	// Name is the OperatorConfig the operator reads. Others are ignored.
+	Name string
+	// Namespace is the operator namespace spec.caBundle is read from.
+	Namespace string
+	// Changed receives an event whenever settings are applied or reset.
+	// Sends never block, since the receiving controller only runs on the
+	// leader; an event already pending reconciles every extension anyway.
+	Changed chan<- event.GenericEvent
+}
+
+// +kubebuilder:rbac:groups=ai-platform.suse.com,resources=operatorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ai-platform.suse.com,resources=operatorconfigs/status,verbs=get;update;patch
+
+// Reconcile validates the OperatorConfig and applies it. An invalid spec
+// is reported in status and the last applied settings stay in effect.
+// Without an OperatorConfig the flag values apply.
+func (r *OperatorConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logging.FromContext(ctx, "operatorconfig").WithValues(logging.KeyName, req.Name)
+
+	var cfg aiplatformv1alpha1.OperatorConfig
+	if err := r.Get(ctx, req.NamespacedName, &cfg); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("OperatorConfig not found, using the flag values")
+			r.Settings.Reset()
+			r.notify(&aiplatformv1alpha1.OperatorConfig{ObjectMeta: metav1.ObjectMeta{Name: req.Name}})
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	caBundle, err := r.caBundle(ctx, cfg.Spec.CABundle)
+	if err == nil {
+		var settings config.Settings
+		if settings, err = config.Resolve(r.Settings.Base(), &cfg.Spec, caBundle); err == nil {
+			r.Settings.Set(settings)
+			r.notify(&cfg)
+		}
+	}
+
+	if err != nil {
+		log.Info("OperatorConfig is invalid, keeping the last applied settings", "error", err.Error())
+		if r.Recorder != nil {
+			r.Recorder.Eventf(&cfg, corev1.EventTypeWarning, aiplatformv1alpha1.ReasonConfigInvalid, "%s", err.Error())
+		}
+	} else {
+		logging.Debug(log).Info("OperatorConfig applied", "generation", cfg.Generation)
+	}
+
+	return ctrl.Result{}, r.updateStatus(ctx, req.NamespacedName, func(latest *aiplatformv1alpha1.OperatorConfig) {
+		latest.Status.ObservedGeneration = cfg.Generation
+
+		cond := metav1.Condition{
+			Type:               aiplatformv1alpha1.ConditionConfigApplied,
+			Status:             metav1.ConditionTrue,
+			Reason:             aiplatformv1alpha1.ReasonConfigApplied,
+			Message:            "Configuration is in effect",
+			ObservedGeneration: cfg.Generation,
+		}
+		if err != nil {
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = aiplatformv1alpha1.ReasonConfigInvalid
+			cond.Message = err.Error()
+		} else {
+			latest.Status.AppliedGeneration = cfg.Generation
+		}
+		meta.SetStatusCondition(&latest.Status.Conditions, cond)
+	})
+}
+
+// notify reports applied settings on Changed, unless an event is pending.
+func (r *OperatorConfigReconciler) notify(cfg *aiplatformv1alpha1.OperatorConfig) {
+	if r.Changed == nil {
+		return
+	}
+	select {
+	case r.Changed <- event.GenericEvent{Object: cfg}:
+	default:
+	}
+}
+
+// caBundle reads the certificates src references.
+func (r *OperatorConfigReconciler) caBundle(ctx context.Context, src *aiplatformv1alpha1.CABundleSource) ([]byte, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	key := src.Key
+	if key == "" {
+		key = defaultCABundleKey
+	}
+
+	if src.SecretName != "" {
+		data, err := certs.SecretValue(ctx, r.Client, r.Namespace, src.SecretName, key)
+		if err != nil {
+			return nil, fmt.Errorf("caBundle: %w", err)
+		}
+		return data, nil
+	}
+
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: src.ConfigMapName}, &cm); err != nil {
+		return nil, fmt.Errorf("caBundle: failed to read ConfigMap %s/%s: %w", r.Namespace, src.ConfigMapName, err)
+	}
+	data, ok := cm.Data[key]
+	if !ok || data == "" {
+		return nil, fmt.Errorf("caBundle: ConfigMap %s/%s has no key %q", r.Namespace, src.ConfigMapName, key)
+	}
+	return []byte(data), nil
+}
+
+func (r *OperatorConfigReconciler) updateStatus(
+	ctx context.Context,
+	key types.NamespacedName,
+	mutate func(cfg *aiplatformv1alpha1.OperatorConfig),
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var latest aiplatformv1alpha1.OperatorConfig
+		if err := r.Get(ctx, key, &latest); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		before := latest.Status.DeepCopy()
+		mutate(&latest)
+		if equalStatus(before, &latest.Status) {
+			return nil
+		}
+
+		return r.Status().Update(ctx, &latest)
+	})
+}
+
+func equalStatus(a, b *aiplatformv1alpha1.OperatorConfigStatus) bool {
+	if a.ObservedGeneration != b.ObservedGeneration || a.AppliedGeneration != b.AppliedGeneration ||
+		len(a.Conditions) != len(b.Conditions) {
+		return false
+	}
+	for i := range a.Conditions {
+		x, y := a.Conditions[i], b.Conditions[i]
+		if x.Type != y.Type || x.Status != y.Status || x.Reason != y.Reason ||
+			x.Message != y.Message || x.ObservedGeneration != y.ObservedGeneration {
+			return false
+		}
+	}
+	return true
+}
+
+// caBundleRequests reconciles the OperatorConfig when the ConfigMap or
+// Secret holding its CA bundle changes.
+func (r *OperatorConfigReconciler) caBundleRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	if obj.GetNamespace() != r.Namespace {
+		return nil
+	}
+
+	var cfg aiplatformv1alpha1.OperatorConfig
+	if err := r.Get(ctx, types.NamespacedName{Name: r.Name}, &cfg); err != nil || cfg.Spec.CABundle == nil {
+		return nil
+	}
+
+	src := cfg.Spec.CABundle
+	_, isSecret := obj.(*corev1.Secret)
+	if (isSecret && src.SecretName != obj.GetName()) || (!isSecret && src.ConfigMapName != obj.GetName()) {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: r.Name}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OperatorConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	named := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == r.Name
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&aiplatformv1alpha1.OperatorConfig{}, builder.WithPredicates(named)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.caBundleRequests)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.caBundleRequests)).
+		WithOptions(controller.Options{NeedLeaderElection: ptr.To(false)}).
+		Named("OperatorConfig").
+		Complete(r)
+}
@@ -0,0 +1,271 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/config"
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+	"github.com/SUSE/suse-ai-operator/internal/infra/pluginserver"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+const (
+	DefaultInterval         = 30 * time.Second
+	DefaultFailureThreshold = 3
+
+	// maxConcurrentProbes bounds how many extensions are probed at once.
+	maxConcurrentProbes = 4
+)
+
+// Checker periodically probes the plugin endpoint of every registered
+// extension and records the result in its status, metrics and events.
+// It runs as a manager Runnable, on the leader only.
+type Checker struct {
+	Client   client.Client
+	Prober   *pluginserver.Prober
+	Recorder record.EventRecorder
+	// Settings provides the extension namespace CA bundle Secrets are read
+	// from, and whether health checks are enabled.
+	Settings *config.Store
+
+	Interval time.Duration
+	// FailureThreshold is the number of consecutive failed probes after
+	// which the extension is reported unhealthy.
+	FailureThreshold int32
+}
+
+func (c *Checker) NeedLeaderElection() bool {
+	return true
+}
+
+func (c *Checker) Start(ctx context.Context) error {
+	log := logging.FromContext(ctx, "health")
+
+	interval := c.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	log.Info("Starting extension health checker", "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Stopping extension health checker")
+			return nil
+		case <-ticker.C:
+			if !c.Settings.Get().HealthChecks {
+				continue
+			}
+			c.checkAll(ctx)
+		}
+	}
+}
+
+func (c *Checker) checkAll(ctx context.Context) {
+	log := logging.FromContext(ctx, "health")
+
+	var list aiplatformv1alpha1.InstallAIExtensionList
+	if err := c.Client.List(ctx, &list); err != nil {
+		log.Error(err, "Failed to list extensions")
+		return
+	}
+
+	sem := make(chan struct{}, maxConcurrentProbes)
+	var wg sync.WaitGroup
+
+	for i := range list.Items {
+		ext := &list.Items[i]
+		if !ext.DeletionTimestamp.IsZero() || ext.Status.PluginEndpoint == "" {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.check(ctx, ext)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (c *Checker) check(ctx context.Context, ext *aiplatformv1alpha1.InstallAIExtension) {
+	log := logging.FromContext(ctx, "health").WithValues(
+		logging.KeyExtension, ext.Name,
+	)
+
+	var caBundle []byte
+	if name, key, ok := installaiextension.CABundleRef(ext.Spec.Extension.TLS); ok {
+		var err error
+		namespace := installaiextension.Namespace(ext, c.Settings.Get().ExtensionNamespace)
+		caBundle, err = certs.SecretValue(ctx, c.Client, namespace, name, key)
+		if err != nil {
+			log.Error(err, "Failed to read CA bundle, probing with system roots")
+		}
+	}
+
+	latency, probeErr := c.Prober.ProbeExtension(ctx, ext.Status.PluginEndpoint, ext.Status.ServiceURL, caBundle)
+
+	metrics.HealthProbeDuration.WithLabelValues(ext.Name).Observe(latency.Seconds())
+	metrics.HealthProbeTimestamp.WithLabelValues(ext.Name).SetToCurrentTime()
+	if probeErr != nil {
+		metrics.HealthProbeFailures.WithLabelValues(ext.Name).Inc()
+		logging.Debug(log).Info("Health probe failed", "error", probeErr.Error())
+	}
+
+	var wasHealthy, isHealthy metav1.ConditionStatus
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var latest aiplatformv1alpha1.InstallAIExtension
+		if err := c.Client.Get(ctx, client.ObjectKeyFromObject(ext), &latest); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		wasHealthy = metav1.ConditionUnknown
+		if prev := meta.FindStatusCondition(latest.Status.Conditions, aiplatformv1alpha1.ConditionHealthy); prev != nil {
+			wasHealthy = prev.Status
+		}
+		before := latest.Status.DeepCopy()
+		isHealthy = c.record(&latest, probeErr)
+		recordPhase(&latest, isHealthy)
+
+		// Probes that leave the health of the endpoint unchanged are only
+		// reported in metrics.
+		if healthChanged(before, &latest.Status) {
+			if err := c.Client.Status().Update(ctx, &latest); err != nil {
+				return err
+			}
+		}
+		metrics.SetExtensionReady(latest.Name, latest.Status.Phase == aiplatformv1alpha1.PhaseReady)
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "Failed to record health status")
+		return
+	}
+
+	switch isHealthy {
+	case metav1.ConditionTrue:
+		metrics.ExtensionHealthy.WithLabelValues(ext.Name).Set(1)
+	case metav1.ConditionFalse:
+		metrics.ExtensionHealthy.WithLabelValues(ext.Name).Set(0)
+	}
+
+	if wasHealthy == metav1.ConditionUnknown || isHealthy == metav1.ConditionUnknown ||
+		isHealthy == wasHealthy || c.Recorder == nil {
+		return
+	}
+
+	if isHealthy == metav1.ConditionTrue {
+		log.Info("Extension is healthy again")
+		c.Recorder.Eventf(ext, corev1.EventTypeNormal, "ExtensionHealthy",
+			"Plugin endpoint %s is serving again", ext.Status.PluginEndpoint)
+	} else {
+		log.Info("Extension became unhealthy", "error", probeErr.Error())
+		c.Recorder.Eventf(ext, corev1.EventTypeWarning, "ExtensionUnhealthy",
+			"Plugin endpoint %s is not serving: %v", ext.Status.PluginEndpoint, probeErr)
+	}
+}
+
+// record writes the probe result into ext's status and returns the status
+// of its Healthy condition. Failures below the threshold keep the current
+// condition, and leave it unset on an extension that was not probed
+// successfully yet, which is reported as ConditionUnknown.
+func (c *Checker) record(ext *aiplatformv1alpha1.InstallAIExtension, probeErr error) metav1.ConditionStatus {
+	threshold := c.FailureThreshold
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+
+	if ext.Status.Health == nil {
+		ext.Status.Health = &aiplatformv1alpha1.HealthStatus{}
+	}
+	h := ext.Status.Health
+
+	now := metav1.Now()
+	cond := metav1.Condition{
+		Type:               aiplatformv1alpha1.ConditionHealthy,
+		ObservedGeneration: ext.Generation,
+	}
+
+	if probeErr == nil {
+		if h.LastSuccessTime == nil || h.ConsecutiveFailures > 0 {
+			h.LastSuccessTime = &now
+		}
+		h.ConsecutiveFailures = 0
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = aiplatformv1alpha1.ReasonProbeSucceeded
+		cond.Message = "Plugin endpoint is serving"
+	} else {
+		h.LastFailureTime = &now
+		h.LastError = probeErr.Error()
+		h.ConsecutiveFailures++
+
+		if h.ConsecutiveFailures < threshold {
+			// Below the threshold, keep the current condition to avoid flapping.
+			if prev := meta.FindStatusCondition(ext.Status.Conditions, cond.Type); prev != nil {
+				return prev.Status
+			}
+			return metav1.ConditionUnknown
+		}
+
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = aiplatformv1alpha1.ReasonProbeFailed
+		cond.Message = fmt.Sprintf("%d consecutive probes failed: %v", h.ConsecutiveFailures, probeErr)
+	}
+
+	meta.SetStatusCondition(&ext.Status.Conditions, cond)
+	return cond.Status
+}
+
+// healthChanged reports whether a probe changed the Healthy condition, the
+// count of consecutive failures or the phase, which are worth writing.
+func healthChanged(before, after *aiplatformv1alpha1.InstallAIExtensionStatus) bool {
+	if before.Phase != after.Phase {
+		return true
+	}
+	if before.Health == nil || before.Health.ConsecutiveFailures != after.Health.ConsecutiveFailures {
+		return true
+	}
+
+	prev := meta.FindStatusCondition(before.Conditions, aiplatformv1alpha1.ConditionHealthy)
+	cur := meta.FindStatusCondition(after.Conditions, aiplatformv1alpha1.ConditionHealthy)
+	if prev == nil || cur == nil {
+		return prev != cur
+	}
+	return prev.Status != cur.Status || prev.Reason != cur.Reason || prev.Message != cur.Message ||
+		prev.ObservedGeneration != cur.ObservedGeneration
+}
+
+// recordPhase moves a Ready extension that became unhealthy to Degraded,
+// and back to Ready once it is healthy again. Degraded extensions whose
+// upgrade was rolled back stay Degraded until the next rollout.
+func recordPhase(ext *aiplatformv1alpha1.InstallAIExtension, healthy metav1.ConditionStatus) {
+	switch {
+	case healthy == metav1.ConditionFalse && ext.Status.Phase == aiplatformv1alpha1.PhaseReady:
+		installaiextension.SetPhase(ext, aiplatformv1alpha1.PhaseDegraded, aiplatformv1alpha1.ReasonProbeFailed,
+			fmt.Sprintf("Plugin endpoint %s is not serving", ext.Status.PluginEndpoint))
+	case healthy == metav1.ConditionTrue && ext.Status.Phase == aiplatformv1alpha1.PhaseDegraded && !installaiextension.UpgradeRolledBack(ext):
+		installaiextension.SetPhase(ext, aiplatformv1alpha1.PhaseReady, aiplatformv1alpha1.ReasonProbeSucceeded,
+			fmt.Sprintf("Extension %s installed", ext.Spec.Extension.Name))
+	}
+}
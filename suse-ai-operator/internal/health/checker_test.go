@@ -0,0 +1,228 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/config"
+	"github.com/SUSE/suse-ai-operator/internal/infra/pluginserver"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+var _ = Describe("Checker", func() {
+	probeErr := errors.New("connection refused")
+	checker := &Checker{FailureThreshold: 3}
+
+	// newExtension returns a Ready extension with the given Healthy
+	// condition status, or none when status is empty.
+	newExtension := func(status metav1.ConditionStatus, failures int32) *aiplatformv1alpha1.InstallAIExtension {
+		ext := &aiplatformv1alpha1.InstallAIExtension{
+			ObjectMeta: metav1.ObjectMeta{Name: "ext", Generation: 1},
+		}
+		ext.Status.Phase = aiplatformv1alpha1.PhaseReady
+		ext.Status.Health = &aiplatformv1alpha1.HealthStatus{ConsecutiveFailures: failures}
+		if status != "" {
+			meta.SetStatusCondition(&ext.Status.Conditions, metav1.Condition{
+				Type:   aiplatformv1alpha1.ConditionHealthy,
+				Status: status,
+				Reason: aiplatformv1alpha1.ReasonProbeSucceeded,
+			})
+		}
+		return ext
+	}
+
+	Describe("record", func() {
+		DescribeTable("reports the Healthy condition",
+			func(ext *aiplatformv1alpha1.InstallAIExtension, err error, expected metav1.ConditionStatus) {
+				Expect(checker.record(ext, err)).To(Equal(expected))
+
+				cond := meta.FindStatusCondition(ext.Status.Conditions, aiplatformv1alpha1.ConditionHealthy)
+				if expected == metav1.ConditionUnknown {
+					Expect(cond).To(BeNil())
+				} else {
+					Expect(cond).NotTo(BeNil())
+					Expect(cond.Status).To(Equal(expected))
+				}
+			},
+			Entry("a first successful probe",
+				newExtension("", 0), nil, metav1.ConditionTrue),
+			Entry("a first failed probe below the threshold",
+				newExtension("", 0), probeErr, metav1.ConditionUnknown),
+			Entry("a failed probe of a new extension reaching the threshold",
+				newExtension("", 2), probeErr, metav1.ConditionFalse),
+			Entry("a failed probe of a healthy extension below the threshold",
+				newExtension(metav1.ConditionTrue, 1), probeErr, metav1.ConditionTrue),
+			Entry("a failed probe of a healthy extension reaching the threshold",
+				newExtension(metav1.ConditionTrue, 2), probeErr, metav1.ConditionFalse),
+			Entry("a successful probe of an unhealthy extension",
+				newExtension(metav1.ConditionFalse, 5), nil, metav1.ConditionTrue),
+		)
+
+		It("records the probe in status.health", func() {
+			ext := newExtension(metav1.ConditionTrue, 0)
+
+			checker.record(ext, probeErr)
+			Expect(ext.Status.Health.ConsecutiveFailures).To(Equal(int32(1)))
+			Expect(ext.Status.Health.LastError).To(Equal(probeErr.Error()))
+			Expect(ext.Status.Health.LastFailureTime).NotTo(BeNil())
+
+			checker.record(ext, nil)
+			Expect(ext.Status.Health.ConsecutiveFailures).To(BeZero())
+			recovered := ext.Status.Health.LastSuccessTime
+			Expect(recovered).NotTo(BeNil())
+
+			By("keeping the time probes started succeeding")
+			checker.record(ext, nil)
+			Expect(ext.Status.Health.LastSuccessTime).To(BeIdenticalTo(recovered))
+			cond := meta.FindStatusCondition(ext.Status.Conditions, aiplatformv1alpha1.ConditionHealthy)
+			Expect(cond.Message).To(Equal("Plugin endpoint is serving"))
+		})
+
+		It("uses the default threshold when unset", func() {
+			ext := newExtension("", 0)
+			defaults := &Checker{}
+
+			for range DefaultFailureThreshold - 1 {
+				Expect(defaults.record(ext, probeErr)).To(Equal(metav1.ConditionUnknown))
+			}
+			Expect(defaults.record(ext, probeErr)).To(Equal(metav1.ConditionFalse))
+		})
+	})
+
+	Describe("check", func() {
+		var (
+			ctx     context.Context
+			c       *Checker
+			serving atomic.Bool
+			writes  int
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			writes = 0
+			serving.Store(true)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if !serving.Load() {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			DeferCleanup(server.Close)
+
+			ext := newExtension("", 0)
+			ext.Status.Health = nil
+			ext.Status.PluginEndpoint = server.URL
+			ext.Status.ServiceURL = server.URL
+
+			scheme := runtime.NewScheme()
+			Expect(aiplatformv1alpha1.AddToScheme(scheme)).To(Succeed())
+			c = &Checker{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(ext).
+					WithStatusSubresource(ext).
+					WithInterceptorFuncs(interceptor.Funcs{
+						SubResourceUpdate: func(ctx context.Context, cl client.Client, sub string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+							writes++
+							return cl.SubResource(sub).Update(ctx, obj, opts...)
+						},
+					}).
+					Build(),
+				Prober:           pluginserver.NewProber(server.Client()),
+				Settings:         config.NewStore(config.Settings{}),
+				FailureThreshold: 2,
+			}
+		})
+
+		probe := func() *aiplatformv1alpha1.InstallAIExtension {
+			var ext aiplatformv1alpha1.InstallAIExtension
+			Expect(c.Client.Get(ctx, client.ObjectKey{Name: "ext"}, &ext)).To(Succeed())
+			c.check(ctx, &ext)
+			Expect(c.Client.Get(ctx, client.ObjectKey{Name: "ext"}, &ext)).To(Succeed())
+			return &ext
+		}
+
+		It("only writes status when the health of the endpoint changes", func() {
+			ext := probe()
+			Expect(writes).To(Equal(1))
+			Expect(meta.IsStatusConditionTrue(ext.Status.Conditions, aiplatformv1alpha1.ConditionHealthy)).To(BeTrue())
+			Expect(testutil.ToFloat64(metrics.HealthProbeTimestamp.WithLabelValues("ext"))).NotTo(BeZero())
+
+			probe()
+			probe()
+			Expect(writes).To(Equal(1))
+
+			By("writing every failure and the recovery")
+			serving.Store(false)
+			Expect(probe().Status.Phase).To(Equal(aiplatformv1alpha1.PhaseReady))
+			Expect(probe().Status.Phase).To(Equal(aiplatformv1alpha1.PhaseDegraded))
+			Expect(writes).To(Equal(3))
+
+			serving.Store(true)
+			Expect(probe().Status.Phase).To(Equal(aiplatformv1alpha1.PhaseReady))
+			probe()
+			Expect(writes).To(Equal(4))
+		})
+	})
+
+	Describe("recordPhase", func() {
+		withPhase := func(phase string) *aiplatformv1alpha1.InstallAIExtension {
+			ext := newExtension("", 0)
+			ext.Status.Phase = phase
+			return ext
+		}
+		rolledBack := func() *aiplatformv1alpha1.InstallAIExtension {
+			ext := withPhase(aiplatformv1alpha1.PhaseDegraded)
+			ext.Status.FailedUpgrade = &aiplatformv1alpha1.FailedUpgrade{ObservedGeneration: ext.Generation}
+			return ext
+		}
+
+		DescribeTable("moves the phase",
+			func(ext *aiplatformv1alpha1.InstallAIExtension, healthy metav1.ConditionStatus, expected string) {
+				recordPhase(ext, healthy)
+				Expect(ext.Status.Phase).To(Equal(expected))
+			},
+			Entry("degrades an unhealthy Ready extension",
+				withPhase(aiplatformv1alpha1.PhaseReady), metav1.ConditionFalse, aiplatformv1alpha1.PhaseDegraded),
+			Entry("keeps a Ready extension below the threshold",
+				withPhase(aiplatformv1alpha1.PhaseReady), metav1.ConditionUnknown, aiplatformv1alpha1.PhaseReady),
+			Entry("recovers a healthy Degraded extension",
+				withPhase(aiplatformv1alpha1.PhaseDegraded), metav1.ConditionTrue, aiplatformv1alpha1.PhaseReady),
+			Entry("keeps a rolled back upgrade Degraded",
+				rolledBack(), metav1.ConditionTrue, aiplatformv1alpha1.PhaseDegraded),
+			Entry("leaves an extension that is still installing",
+				withPhase(aiplatformv1alpha1.PhaseInstalling), metav1.ConditionFalse, aiplatformv1alpha1.PhaseInstalling),
+		)
+
+		It("only degrades a freshly Ready extension once the threshold is reached", func() {
+			ext := newExtension("", 0)
+
+			for range checker.FailureThreshold - 1 {
+				recordPhase(ext, checker.record(ext, probeErr))
+				Expect(ext.Status.Phase).To(Equal(aiplatformv1alpha1.PhaseReady))
+			}
+
+			recordPhase(ext, checker.record(ext, probeErr))
+			Expect(ext.Status.Phase).To(Equal(aiplatformv1alpha1.PhaseDegraded))
+			Expect(ext.Status.PhaseHistory[len(ext.Status.PhaseHistory)-1].Reason).
+				To(Equal(aiplatformv1alpha1.ReasonProbeFailed))
+
+			recordPhase(ext, checker.record(ext, nil))
+			Expect(ext.Status.Phase).To(Equal(aiplatformv1alpha1.PhaseReady))
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package certs
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logging "github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+type IssuerRef struct {
+	Name  string
+	Kind  string
+	Group string
+}
+
+// EnsureCertificate keeps a cert-manager Certificate requesting a serving
+// certificate for dnsNames in secretName. The issuer is responsible for
+// populating the Secret, including its ca.crt key.
+func EnsureCertificate(
+	ctx context.Context,
+	c client.Client,
+	namespace, name, secretName string,
+	dnsNames []string,
+	issuer IssuerRef,
+) error {
+	log := logging.FromContext(ctx, "certs").WithValues(
+		logging.KeyNamespace, namespace,
+		logging.KeyName, name,
+	)
+
+	cert := &unstructured.Unstructured{}
+	cert.SetAPIVersion("cert-manager.io/v1")
+	cert.SetKind("Certificate")
+	cert.SetName(name)
+	cert.SetNamespace(namespace)
+
+	names := make([]interface{}, 0, len(dnsNames))
+	for _, n := range dnsNames {
+		names = append(names, n)
+	}
+
+	_, err := ctrl.CreateOrUpdate(ctx, c, cert, func() error {
+		if err := unstructured.SetNestedField(cert.Object, secretName, "spec", "secretName"); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedSlice(cert.Object, names, "spec", "dnsNames"); err != nil {
+			return err
+		}
+		return unstructured.SetNestedStringMap(cert.Object, map[string]string{
+			"name":  issuer.Name,
+			"kind":  issuer.Kind,
+			"group": issuer.Group,
+		}, "spec", "issuerRef")
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.Debug(log).Info("Certificate ensured")
+	return nil
+}
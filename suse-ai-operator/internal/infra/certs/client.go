@@ -0,0 +1,33 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPClient returns a client trusting caBundle in addition to the system
+// roots. With an empty bundle it only trusts the system roots.
+func HTTPClient(caBundle []byte, timeout time.Duration) (*http.Client, error) {
+	if len(caBundle) == 0 {
+		return &http.Client{Timeout: timeout}, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("CA bundle contains no valid PEM certificates")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
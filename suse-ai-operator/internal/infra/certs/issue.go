@@ -0,0 +1,124 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+)
+
+const (
+	ServingCertValidity = 365 * 24 * time.Hour
+	// renewBefore is the remaining validity below which a certificate is
+	// reissued.
+	renewBefore = 30 * 24 * time.Hour
+)
+
+// ServiceDNSNames returns the in-cluster names a Service is reachable at.
+func ServiceDNSNames(name, namespace string) []string {
+	return []string{
+		name,
+		fmt.Sprintf("%s.%s", name, namespace),
+		fmt.Sprintf("%s.%s.svc", name, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace),
+	}
+}
+
+// IssueServingCertificate signs a serving certificate for dnsNames with the
+// given CA key pair and returns the PEM encoded certificate and key.
+func IssueServingCertificate(
+	caCertPEM, caKeyPEM []byte,
+	dnsNames []string,
+	validity time.Duration,
+) ([]byte, []byte, error) {
+
+	ca, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA key pair: %w", err)
+	}
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA certificate: %w", err)
+	}
+	if !caCert.IsCA {
+		return nil, nil, fmt.Errorf("certificate %q is not a CA", caCert.Subject.CommonName)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), ca.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, nil
+}
+
+// NeedsRenewal reports whether certPEM is missing, close to expiry, not
+// signed by caCertPEM or does not cover all dnsNames.
+func NeedsRenewal(certPEM, caCertPEM []byte, dnsNames []string, now time.Time) bool {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return true
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+
+	if now.Add(renewBefore).After(cert.NotAfter) {
+		return true
+	}
+
+	for _, name := range dnsNames {
+		if !slices.Contains(cert.DNSNames, name) {
+			return true
+		}
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCertPEM) {
+		return true
+	}
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:       pool,
+		DNSName:     dnsNames[0],
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err != nil
+}
@@ -0,0 +1,129 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// newCA returns the PEM certificate and key of a self-signed certificate,
+// marked as a CA when isCA is set.
+func newCA(name string, isCA bool) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func parseCertificate(certPEM []byte) *x509.Certificate {
+	block, _ := pem.Decode(certPEM)
+	Expect(block).NotTo(BeNil())
+	cert, err := x509.ParseCertificate(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+var _ = Describe("Serving certificates", func() {
+	dnsNames := ServiceDNSNames("ui", "default")
+
+	var caCert, caKey []byte
+
+	BeforeEach(func() {
+		caCert, caKey = newCA("test-ca", true)
+	})
+
+	Describe("IssueServingCertificate", func() {
+		It("issues a certificate for the DNS names signed by the CA", func() {
+			certPEM, keyPEM, err := IssueServingCertificate(caCert, caKey, dnsNames, ServingCertValidity)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keyPEM).NotTo(BeEmpty())
+
+			cert := parseCertificate(certPEM)
+			Expect(cert.Subject.CommonName).To(Equal("ui"))
+			Expect(cert.DNSNames).To(Equal(dnsNames))
+			Expect(cert.ExtKeyUsage).To(ConsistOf(x509.ExtKeyUsageServerAuth))
+			Expect(cert.NotAfter).To(BeTemporally("~", time.Now().Add(ServingCertValidity), time.Minute))
+			Expect(cert.CheckSignatureFrom(parseCertificate(caCert))).To(Succeed())
+		})
+
+		It("refuses a certificate that is not a CA", func() {
+			notCA, notCAKey := newCA("leaf", false)
+
+			_, _, err := IssueServingCertificate(notCA, notCAKey, dnsNames, ServingCertValidity)
+			Expect(err).To(MatchError(`certificate "leaf" is not a CA`))
+		})
+
+		It("refuses a key that does not match the CA", func() {
+			_, otherKey := newCA("other-ca", true)
+
+			_, _, err := IssueServingCertificate(caCert, otherKey, dnsNames, ServingCertValidity)
+			Expect(err).To(MatchError(ContainSubstring("invalid CA key pair")))
+		})
+	})
+
+	Describe("NeedsRenewal", func() {
+		issue := func(names []string, validity time.Duration) []byte {
+			certPEM, _, err := IssueServingCertificate(caCert, caKey, names, validity)
+			Expect(err).NotTo(HaveOccurred())
+			return certPEM
+		}
+
+		It("keeps a valid certificate", func() {
+			Expect(NeedsRenewal(issue(dnsNames, ServingCertValidity), caCert, dnsNames, time.Now())).To(BeFalse())
+		})
+
+		It("renews a missing or unreadable certificate", func() {
+			Expect(NeedsRenewal(nil, caCert, dnsNames, time.Now())).To(BeTrue())
+			Expect(NeedsRenewal([]byte("not a certificate"), caCert, dnsNames, time.Now())).To(BeTrue())
+		})
+
+		It("renews a certificate expiring inside the renewal window", func() {
+			certPEM := issue(dnsNames, ServingCertValidity)
+
+			Expect(NeedsRenewal(certPEM, caCert, dnsNames, time.Now().Add(ServingCertValidity-renewBefore-time.Hour))).
+				To(BeFalse())
+			Expect(NeedsRenewal(certPEM, caCert, dnsNames, time.Now().Add(ServingCertValidity-renewBefore+time.Hour))).
+				To(BeTrue())
+			Expect(NeedsRenewal(issue(dnsNames, renewBefore/2), caCert, dnsNames, time.Now())).To(BeTrue())
+		})
+
+		It("renews a certificate for a changed set of DNS names", func() {
+			certPEM := issue(dnsNames, ServingCertValidity)
+
+			Expect(NeedsRenewal(certPEM, caCert, ServiceDNSNames("ui-v2", "default"), time.Now())).To(BeTrue())
+			Expect(NeedsRenewal(certPEM, caCert, append(dnsNames, "ui.example.com"), time.Now())).To(BeTrue())
+			Expect(NeedsRenewal(certPEM, caCert, dnsNames[:2], time.Now())).To(BeFalse())
+		})
+
+		It("renews a certificate signed by another CA", func() {
+			certPEM := issue(dnsNames, ServingCertValidity)
+			newCACert, _ := newCA("test-ca", true)
+
+			Expect(NeedsRenewal(certPEM, newCACert, dnsNames, time.Now())).To(BeTrue())
+			Expect(NeedsRenewal(certPEM, []byte("no CA"), dnsNames, time.Now())).To(BeTrue())
+		})
+	})
+})
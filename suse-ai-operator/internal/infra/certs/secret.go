@@ -0,0 +1,121 @@
+package certs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logging "github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+const KeyCACert = "ca.crt"
+
+type SecretNotReadyError struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+func (e *SecretNotReadyError) Error() string {
+	return fmt.Sprintf("secret %s/%s has no key %q yet", e.Namespace, e.Name, e.Key)
+}
+
+// SecretTypeError is returned when the Secret a serving certificate is
+// written to already exists with another type. The type of a Secret cannot
+// change, so it must be deleted or renamed.
+type SecretTypeError struct {
+	Namespace string
+	Name      string
+	Type      corev1.SecretType
+}
+
+func (e *SecretTypeError) Error() string {
+	return fmt.Sprintf("secret %s/%s has type %s, not %s: delete it to let the operator issue the serving certificate",
+		e.Namespace, e.Name, e.Type, corev1.SecretTypeTLS)
+}
+
+// SecretValue returns the value stored under key in the given Secret.
+func SecretValue(
+	ctx context.Context,
+	c client.Client,
+	namespace, name, key string,
+) ([]byte, error) {
+
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, &SecretNotReadyError{Namespace: namespace, Name: name, Key: key}
+		}
+		return nil, err
+	}
+
+	value, ok := secret.Data[key]
+	if !ok || len(value) == 0 {
+		return nil, &SecretNotReadyError{Namespace: namespace, Name: name, Key: key}
+	}
+
+	return value, nil
+}
+
+// EnsureServingSecret keeps a kubernetes.io/tls Secret holding a serving
+// certificate for dnsNames signed by the CA in caSecretName. The CA is
+// also stored under ca.crt so clients can trust it.
+func EnsureServingSecret(
+	ctx context.Context,
+	c client.Client,
+	namespace, secretName, caSecretName string,
+	dnsNames []string,
+) error {
+	log := logging.FromContext(ctx, "certs").WithValues(
+		logging.KeyNamespace, namespace,
+		logging.KeyName, secretName,
+	)
+
+	caCert, err := SecretValue(ctx, c, namespace, caSecretName, corev1.TLSCertKey)
+	if err != nil {
+		return err
+	}
+	caKey, err := SecretValue(ctx, c, namespace, caSecretName, corev1.TLSPrivateKeyKey)
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{}
+	secret.Name = secretName
+	secret.Namespace = namespace
+
+	result, err := ctrl.CreateOrUpdate(ctx, c, secret, func() error {
+		if secret.Type != "" && secret.Type != corev1.SecretTypeTLS {
+			return &SecretTypeError{Namespace: namespace, Name: secretName, Type: secret.Type}
+		}
+		secret.Type = corev1.SecretTypeTLS
+		if !NeedsRenewal(secret.Data[corev1.TLSCertKey], caCert, dnsNames, time.Now()) {
+			return nil
+		}
+
+		logging.Debug(log).Info("Issuing serving certificate", "dnsNames", dnsNames)
+
+		certPEM, keyPEM, err := IssueServingCertificate(caCert, caKey, dnsNames, ServingCertValidity)
+		if err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+			KeyCACert:               caCert,
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.Debug(log).Info("Serving certificate ensured", "result", result)
+	return nil
+}
@@ -0,0 +1,99 @@
+package certs
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("EnsureServingSecret", func() {
+	dnsNames := ServiceDNSNames("ui", "default")
+
+	var (
+		ctx    context.Context
+		c      client.Client
+		caCert []byte
+	)
+
+	setUp := func(objs ...client.Object) {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+		var caKey []byte
+		caCert, caKey = newCA("test-ca", true)
+		ca := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "default"},
+			Type:       corev1.SecretTypeTLS,
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       caCert,
+				corev1.TLSPrivateKeyKey: caKey,
+			},
+		}
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objs, ca)...).Build()
+	}
+
+	stored := func() *corev1.Secret {
+		var secret corev1.Secret
+		Expect(c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "ui-tls"}, &secret)).To(Succeed())
+		return &secret
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("creates a TLS Secret with the certificate and the CA", func() {
+		setUp()
+
+		Expect(EnsureServingSecret(ctx, c, "default", "ui-tls", "ca", dnsNames)).To(Succeed())
+
+		secret := stored()
+		Expect(secret.Type).To(Equal(corev1.SecretTypeTLS))
+		Expect(secret.Data).To(HaveKey(corev1.TLSPrivateKeyKey))
+		Expect(secret.Data[KeyCACert]).To(Equal(caCert))
+		Expect(NeedsRenewal(secret.Data[corev1.TLSCertKey], caCert, dnsNames, time.Now())).To(BeFalse())
+	})
+
+	It("keeps a certificate that does not need renewal", func() {
+		setUp()
+		Expect(EnsureServingSecret(ctx, c, "default", "ui-tls", "ca", dnsNames)).To(Succeed())
+		issued := stored().Data[corev1.TLSCertKey]
+
+		Expect(EnsureServingSecret(ctx, c, "default", "ui-tls", "ca", dnsNames)).To(Succeed())
+		Expect(stored().Data[corev1.TLSCertKey]).To(Equal(issued))
+	})
+
+	It("reports a missing CA Secret as not ready", func() {
+		setUp()
+
+		err := EnsureServingSecret(ctx, c, "default", "ui-tls", "missing-ca", dnsNames)
+		var notReady *SecretNotReadyError
+		Expect(err).To(BeAssignableToTypeOf(notReady))
+	})
+
+	It("refuses to overwrite a Secret of another type", func() {
+		setUp(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ui-tls", Namespace: "default"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("secret")},
+		})
+
+		err := EnsureServingSecret(ctx, c, "default", "ui-tls", "ca", dnsNames)
+		var typeErr *SecretTypeError
+		Expect(err).To(BeAssignableToTypeOf(typeErr))
+		Expect(err).To(MatchError(ContainSubstring("secret default/ui-tls has type Opaque, not kubernetes.io/tls")))
+
+		secret := stored()
+		Expect(secret.Type).To(Equal(corev1.SecretTypeOpaque))
+		Expect(secret.Data).To(Equal(map[string][]byte{"password": []byte("secret")}))
+	})
+})
@@ -2,19 +2,22 @@ package helm
 
 import (
 	"context"
-	"strings"
+	"errors"
+	"fmt"
 	"sync"
-	"time"
 
 	"github.com/SUSE/suse-ai-operator/internal/logging"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
 )
 
 func (c *helmClient) install(
 	ctx context.Context,
 	cfg *action.Configuration,
 	spec ReleaseSpec,
-) error {
+	replace bool,
+) (*release.Release, error) {
 	log := logging.FromContext(ctx, "helm").WithValues(
 		logging.KeyName, spec.Name,
 		logging.KeyNamespace, spec.Namespace,
@@ -28,28 +31,30 @@ func (c *helmClient) install(
 	install.Namespace = spec.Namespace
 	install.Version = spec.Version
 	install.SetRegistryClient(c.registry)
+	install.Replace = replace
+	applyInstallOptions(install, spec.Options)
 
-	ch, _, err := resolveChart(&install.ChartPathOptions, c.settings, spec.ChartRef)
+	ch, _, err := c.resolveChart(ctx, &install.ChartPathOptions, spec)
 	if err != nil {
 		log.Error(err, "Failed to resolve Helm chart")
-		return err
+		return nil, err
 	}
 
-	_, err = install.RunWithContext(ctx, ch, spec.Values)
+	rel, err := install.RunWithContext(ctx, ch, spec.Values)
 	if err != nil {
 		log.Error(err, "Helm install failed")
-		return err
+		return nil, &InstallFailedError{ChartVersion: spec.Version, Err: err}
 	}
 
 	log.Info("Helm release installed successfully")
-	return nil
+	return rel, nil
 }
 
 func (c *helmClient) upgrade(
 	ctx context.Context,
 	cfg *action.Configuration,
 	spec ReleaseSpec,
-) error {
+) (*release.Release, error) {
 	log := logging.FromContext(ctx, "helm").WithValues(
 		logging.KeyName, spec.Name,
 		logging.KeyNamespace, spec.Namespace,
@@ -62,51 +67,143 @@ func (c *helmClient) upgrade(
 	up.Namespace = spec.Namespace
 	up.Version = spec.Version
 	up.SetRegistryClient(c.registry)
+	applyUpgradeOptions(up, spec.Options)
 
-	up.Wait = true
-	up.Atomic = false
-	up.Timeout = 10 * time.Minute
-
-	ch, _, err := resolveChart(&up.ChartPathOptions, c.settings, spec.ChartRef)
+	ch, _, err := c.resolveChart(ctx, &up.ChartPathOptions, spec)
 	if err != nil {
 		log.Error(err, "Failed to resolve Helm chart")
-		return err
+		return nil, err
 	}
-	_, err = up.RunWithContext(ctx, spec.Name, ch, spec.Values)
+	rel, err := up.RunWithContext(ctx, spec.Name, ch, spec.Values)
 	if err != nil {
 		log.Error(err, "Helm upgrade failed")
-		return err
+		return nil, err
 	}
 
 	log.Info("Helm release upgraded successfully")
-	return nil
+	return rel, nil
+}
+
+// rollbackFailedUpgrade turns an upgrade error into an UpgradeFailedError
+// and, when the spec asks for it, rolls the release back to the last
+// deployed revision. An atomic upgrade has already rolled back by itself.
+// previousRevision is the revision of the release before the upgrade; an
+// upgrade that failed without recording a newer revision returns its
+// error unchanged.
+func (c *helmClient) rollbackFailedUpgrade(
+	ctx context.Context,
+	cfg *action.Configuration,
+	spec ReleaseSpec,
+	previousRevision int,
+	upgradeErr error,
+) (*release.Release, error) {
+	log := logging.FromContext(ctx, "helm").WithValues(
+		logging.KeyName, spec.Name,
+		logging.KeyNamespace, spec.Namespace,
+		logging.KeyVersion, spec.Version,
+	)
+
+	last, err := cfg.Releases.Last(spec.Name)
+	if err != nil || last.Version <= previousRevision {
+		// Failed before Helm recorded a revision, e.g. loading the chart.
+		return nil, upgradeErr
+	}
+
+	failed := &UpgradeFailedError{ChartVersion: spec.Version, Err: upgradeErr}
+
+	if last.Info.Status == release.StatusDeployed {
+		if !spec.Options.Atomic {
+			return nil, upgradeErr
+		}
+		// Atomic upgrades roll back on their own.
+		failed.Revision = last.Version - 1
+		failed.RolledBackTo = last.Version
+		return last, failed
+	}
+	failed.Revision = last.Version
+
+	if !spec.RollbackOnFailure {
+		return nil, failed
+	}
+
+	deployed, err := cfg.Releases.Deployed(spec.Name)
+	if err != nil {
+		log.Error(err, "No deployed revision to roll back to")
+		return nil, failed
+	}
+
+	log.Info("Rolling back failed upgrade",
+		"failedRevision", failed.Revision,
+		"targetRevision", deployed.Version,
+	)
+
+	rb := action.NewRollback(cfg)
+	rb.Version = deployed.Version
+	rb.Timeout = spec.Options.Timeout
+	rb.Wait = spec.Options.Wait || spec.Options.Atomic
+	rb.WaitForJobs = spec.Options.WaitForJobs
+	rb.DisableHooks = spec.Options.DisableHooks
+	rb.Force = spec.Options.Force
+	rb.CleanupOnFail = spec.Options.CleanupOnFail
+	rb.MaxHistory = spec.Options.MaxHistory
+
+	if err := rb.Run(spec.Name); err != nil {
+		log.Error(err, "Helm rollback failed")
+		return nil, fmt.Errorf("rollback to revision %d failed: %w (upgrade error: %v)",
+			deployed.Version, err, upgradeErr)
+	}
+
+	rolledBack, err := cfg.Releases.Last(spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	failed.RolledBackTo = rolledBack.Version
+	log.Info("Helm release rolled back", "revision", rolledBack.Version)
+	return rolledBack, failed
 }
 
 func (c *helmClient) renderUpgrade(
 	ctx context.Context,
 	cfg *action.Configuration,
 	spec ReleaseSpec,
-) (string, error) {
+) (*release.Release, error) {
 	up := action.NewUpgrade(cfg)
 	up.Namespace = spec.Namespace
 	up.Version = spec.Version
-	up.DryRun = true
-	up.Wait = false
-	up.Atomic = false
-	up.Timeout = 2 * time.Minute
 	up.SetRegistryClient(c.registry)
+	applyUpgradeOptions(up, spec.Options)
+	up.DryRun = true
 
-	ch, _, err := resolveChart(&up.ChartPathOptions, c.settings, spec.ChartRef)
+	ch, _, err := c.resolveChart(ctx, &up.ChartPathOptions, spec)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	rel, err := up.RunWithContext(ctx, spec.Name, ch, spec.Values)
-	if err != nil {
-		return "", err
-	}
+	return up.RunWithContext(ctx, spec.Name, ch, spec.Values)
+}
 
-	return rel.Manifest, nil
+func applyInstallOptions(install *action.Install, opts ReleaseOptions) {
+	install.Timeout = opts.Timeout
+	install.Wait = opts.Wait || opts.Atomic
+	install.WaitForJobs = opts.WaitForJobs
+	install.Atomic = opts.Atomic
+	install.SkipCRDs = opts.SkipCRDs
+	install.DisableHooks = opts.DisableHooks
+	install.Force = opts.Force
+	install.CreateNamespace = opts.CreateNamespace
+}
+
+func applyUpgradeOptions(up *action.Upgrade, opts ReleaseOptions) {
+	up.Timeout = opts.Timeout
+	up.Wait = opts.Wait || opts.Atomic
+	up.WaitForJobs = opts.WaitForJobs
+	up.Atomic = opts.Atomic
+	up.SkipCRDs = opts.SkipCRDs
+	up.DisableHooks = opts.DisableHooks
+	up.MaxHistory = opts.MaxHistory
+	up.Force = opts.Force
+	up.CleanupOnFail = opts.CleanupOnFail
 }
 
 func currentManifest(cfg *action.Configuration, name string) (string, error) {
@@ -118,10 +215,6 @@ func currentManifest(cfg *action.Configuration, name string) (string, error) {
 	return rel.Manifest, nil
 }
 
-func diffManifests(old, new string) bool {
-	return old != new
-}
-
 func (c *helmClient) lockRelease(name string) func() {
 	m, _ := c.locks.LoadOrStore(name, &sync.Mutex{})
 	mtx := m.(*sync.Mutex)
@@ -147,7 +240,7 @@ func (c *helmClient) DeleteRelease(ctx context.Context, name string) error {
 
 	_, err = uninstall.Run(name)
 	if err != nil {
-		if strings.Contains(err.Error(), "release: not found") {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
 			log.Info("Helm release already deleted")
 			return nil
 		}
@@ -165,55 +258,154 @@ func (c *helmClient) GetRelease(ctx context.Context, name string) (*ReleaseInfo,
 		return nil, err
 	}
 
-	hist := action.NewHistory(cfg)
-	hist.Max = 1
-
-	rels, err := hist.Run(name)
-	if err != nil || len(rels) == 0 {
-		return nil, nil
+	rel, err := cfg.Releases.Last(name)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil, ErrReleaseNotFound
+		}
+		return nil, err
 	}
 
-	rel := rels[0]
-
 	return &ReleaseInfo{
-		ChartName: rel.Chart.Name(),
-		Version:   rel.Chart.Metadata.Version,
-		Values:    rel.Config,
-		Status:    ReleaseStatus(rel.Info.Status),
-		Revision:  rel.Version,
+		ChartName:    rel.Chart.Name(),
+		Version:      rel.Chart.Metadata.Version,
+		Values:       rel.Config,
+		Status:       ReleaseStatus(rel.Info.Status),
+		Revision:     rel.Version,
+		LastDeployed: rel.Info.LastDeployed.Time,
+		ChartDigest:  chartDigest(rel.Chart),
 	}, nil
 }
 
-func (c *helmClient) EnsureRelease(ctx context.Context, spec ReleaseSpec) error {
+// EnsureRelease installs, recovers or upgrades the release to match spec.
+// The chart version is resolved first, then the chart is only rendered
+// when the desired state hash or the release revision differs from the
+// applied one, or a drift check is requested.
+func (c *helmClient) EnsureRelease(ctx context.Context, spec ReleaseSpec) (*ReleaseResult, error) {
+	unlock := c.lockRelease(spec.Name)
+	defer unlock()
+
+	resolved, err := c.resolveChartVersion(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := DesiredStateHash(spec, resolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash desired state: %w", err)
+	}
+	spec.resolvedDigest = resolved.Digest
+
+	result, err := c.ensureRelease(ctx, spec, hash)
+	if result != nil {
+		result.Hash = hash
+	}
+	return result, err
+}
+
+func (c *helmClient) ensureRelease(ctx context.Context, spec ReleaseSpec, hash string) (*ReleaseResult, error) {
 	log := logging.FromContext(ctx, "helm").WithValues(
 		logging.KeyName, spec.Name,
 		logging.KeyNamespace, spec.Namespace,
 	)
 
-	unlock := c.lockRelease(spec.Name)
-	defer unlock()
-
 	cfg, err := c.actionConfig(ctx, spec.Namespace)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	info, _ := c.GetRelease(ctx, spec.Name)
-	if info == nil {
+	info, err := c.GetRelease(ctx, spec.Name)
+	if errors.Is(err, ErrReleaseNotFound) {
 		log.Info("Helm release not found, installing")
-		return c.install(ctx, cfg, spec)
+		rel, err := c.install(ctx, cfg, spec, false)
+		if err != nil {
+			return nil, err
+		}
+		return releaseResult(ActionInstalled, rel, ""), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var recovery []string
+	if needsRecovery(info.Status) {
+		result, steps, err := c.recoverRelease(ctx, cfg, spec, info)
+		if err != nil || result != nil {
+			return result, err
+		}
+		recovery = steps
+
+		if info, err = c.GetRelease(ctx, spec.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if recovery == nil && !spec.CheckDrift &&
+		spec.AppliedHash == hash && spec.AppliedRevision == info.Revision {
+		logging.Debug(log).Info("Desired state unchanged, skipping render", "revision", info.Revision)
+		return &ReleaseResult{
+			Action:       ActionUnchanged,
+			Revision:     info.Revision,
+			ChartVersion: info.Version,
+			ChartDigest:  info.ChartDigest,
+		}, nil
 	}
 
 	current, _ := currentManifest(cfg, spec.Name)
 	rendered, err := c.renderUpgrade(ctx, cfg, spec)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !diffManifests(current, rendered) {
+	diff, err := DiffManifests(current, rendered.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	// The manifest of a failed revision may match the chart although the
+	// resources never became ready, so only a deployed one is left as is.
+	if diff.Empty() && info.Status == StatusDeployed {
 		log.Info("Helm release is up-to-date, skipping upgrade")
-		return nil
+		return &ReleaseResult{
+			Action:       ActionUnchanged,
+			Revision:     info.Revision,
+			ChartVersion: info.Version,
+			ChartDigest:  chartDigest(rendered.Chart),
+			Verification: chartVerification(rendered.Chart),
+			Recovery:     recovery,
+			Rendered:     true,
+		}, nil
+	}
+	if diff.Empty() {
+		log.Info("Latest revision is not deployed, upgrading", "status", info.Status, "revision", info.Revision)
+	} else {
+		log.Info("Detected Helm manifest changes, upgrading", "changes", diff.Summary())
+	}
+
+	rel, err := c.upgrade(ctx, cfg, spec)
+	if err != nil {
+		rolledBack, err := c.rollbackFailedUpgrade(ctx, cfg, spec, info.Revision, err)
+		if rolledBack == nil {
+			return nil, err
+		}
+		return releaseResult(ActionRolledBack, rolledBack, info.Version), err
+	}
+
+	result := releaseResult(ActionUpgraded, rel, info.Version)
+	result.Recovery = recovery
+	result.Diff = diff
+	return result, nil
+}
+
+func releaseResult(act ReleaseAction, rel *release.Release, previousVersion string) *ReleaseResult {
+	return &ReleaseResult{
+		Action:               act,
+		Revision:             rel.Version,
+		ChartVersion:         rel.Chart.Metadata.Version,
+		PreviousChartVersion: previousVersion,
+		AppVersion:           rel.Chart.Metadata.AppVersion,
+		ChartDigest:          chartDigest(rel.Chart),
+		Verification:         chartVerification(rel.Chart),
+		Rendered:             true,
 	}
-	log.Info("Detected Helm manifest changes, upgrading")
-	return c.upgrade(ctx, cfg, spec)
 }
@@ -0,0 +1,65 @@
+package helm
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+var _ = Describe("rollbackFailedUpgrade", func() {
+	upgradeErr := errors.New("timed out waiting for the condition")
+
+	DescribeTable("returns the upgrade error as is",
+		func(previousRevision int, atomic bool, statuses ...release.Status) {
+			cfg := newTestConfiguration(statuses...)
+			spec := ReleaseSpec{Name: "ext", Version: "1.1.0", RollbackOnFailure: true}
+			spec.Options.Atomic = atomic
+
+			rel, err := (&helmClient{}).rollbackFailedUpgrade(context.Background(), cfg, spec, previousRevision, upgradeErr)
+			Expect(rel).To(BeNil())
+			Expect(err).To(BeIdenticalTo(upgradeErr))
+		},
+		Entry("when the release has no history", 0, false),
+		Entry("when the upgrade recorded no revision", 1, false, release.StatusDeployed),
+		Entry("when a non-atomic upgrade left the previous revision deployed",
+			1, false, release.StatusSuperseded, release.StatusDeployed),
+	)
+
+	DescribeTable("reports the failed revision",
+		func(rollbackOnFailure, atomic bool, expected UpgradeFailedError, statuses ...release.Status) {
+			cfg := newTestConfiguration(statuses...)
+			spec := ReleaseSpec{Name: "ext", Version: "1.1.0", RollbackOnFailure: rollbackOnFailure}
+			spec.Options.Atomic = atomic
+
+			rel, err := (&helmClient{}).rollbackFailedUpgrade(context.Background(), cfg, spec, 1, upgradeErr)
+
+			var failed *UpgradeFailedError
+			Expect(errors.As(err, &failed)).To(BeTrue())
+			expected.ChartVersion = "1.1.0"
+			expected.Err = upgradeErr
+			Expect(*failed).To(Equal(expected))
+			if expected.RolledBackTo > 0 {
+				Expect(rel).NotTo(BeNil())
+				Expect(rel.Version).To(Equal(expected.RolledBackTo))
+				Expect(rel.Info.Status).To(Equal(release.StatusDeployed))
+			} else {
+				Expect(rel).To(BeNil())
+			}
+		},
+		Entry("without rolling back unless requested",
+			false, false, UpgradeFailedError{Revision: 2},
+			release.StatusDeployed, release.StatusFailed),
+		Entry("without a deployed revision to roll back to",
+			true, false, UpgradeFailedError{Revision: 2},
+			release.StatusSuperseded, release.StatusFailed),
+		Entry("after rolling back to the deployed revision",
+			true, false, UpgradeFailedError{Revision: 2, RolledBackTo: 3},
+			release.StatusDeployed, release.StatusFailed),
+		Entry("after an atomic upgrade rolled back on its own",
+			false, true, UpgradeFailedError{Revision: 2, RolledBackTo: 3},
+			release.StatusSuperseded, release.StatusSuperseded, release.StatusDeployed),
+	)
+})
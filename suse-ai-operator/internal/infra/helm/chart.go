@@ -1,21 +1,86 @@
 package helm
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
-	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
 )
 
-func resolveChart(
+// chartDigestAnnotation records the resolved chart digest in the chart
+// metadata, which Helm stores with every release revision.
+const chartDigestAnnotation = "ai-platform.suse.com/chart-digest"
+
+// ChartNotFoundError is returned when the chart or the requested version
+// does not exist in the repository or registry.
+type ChartNotFoundError struct {
+	Ref     string
+	Version string
+	Err     error
+}
+
+func (e *ChartNotFoundError) Error() string {
+	return fmt.Sprintf("chart %s version %q not found: %v", e.Ref, e.Version, e.Err)
+}
+
+func (e *ChartNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// IsChartNotFound reports whether err means the chart or the requested
+// version does not exist in the repository or registry.
+func IsChartNotFound(err error) bool {
+	var notFound *ChartNotFoundError
+	return errors.As(err, &notFound)
+}
+
+// registryNotFound reports whether err is a registry response saying the
+// repository or manifest does not exist. Resolving a missing tag returns
+// errdef.ErrNotFound, as the HEAD response has no error body.
+func registryNotFound(err error) bool {
+	if errors.Is(err, errdef.ErrNotFound) {
+		return true
+	}
+	var resp *errcode.ErrorResponse
+	if !errors.As(err, &resp) {
+		return false
+	}
+	for _, e := range resp.Errors {
+		if e.Code == errcode.ErrorCodeManifestUnknown || e.Code == errcode.ErrorCodeNameUnknown {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIncompatible reports whether err is Helm refusing a chart whose
+// kubeVersion constraint excludes the cluster.
+func IsIncompatible(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "which is incompatible with Kubernetes")
+}
+
+// resolveChart locates and loads the chart of spec, verifying it against
+// spec.Digest and spec.Verify when set.
+func (c *helmClient) resolveChart(
+	ctx context.Context,
 	opts *action.ChartPathOptions,
-	settings *cli.EnvSettings,
-	ref string,
+	spec ReleaseSpec,
 ) (*chart.Chart, string, error) {
 
-	chartPath, err := opts.LocateChart(ref, settings)
+	chartPath, digest, err := c.locateChart(opts, spec.ChartRef, spec.Digest, spec.resolvedDigest)
 	if err != nil {
 		return nil, "", err
 	}
@@ -25,9 +90,130 @@ func resolveChart(
 		return nil, "", err
 	}
 
+	var verified *VerificationResult
+	if spec.Verify != nil {
+		if verified, err = c.verifyChart(ctx, spec.ChartRef, chartPath, digest, ch, spec.Verify); err != nil {
+			return nil, "", err
+		}
+	}
+
 	if err := action.CheckDependencies(ch, ch.Metadata.Dependencies); err != nil {
-		return nil, "", fmt.Errorf("missing dependencies: %w", err)
+		if !spec.Options.DependencyUpdate {
+			return nil, "", fmt.Errorf("missing dependencies: %w", err)
+		}
+
+		if ch, err = c.updateDependencies(opts, chartPath, ch.Metadata.Name); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if ch.Metadata.Annotations == nil {
+		ch.Metadata.Annotations = map[string]string{}
+	}
+	ch.Metadata.Annotations[chartDigestAnnotation] = digest
+	if verified != nil {
+		ch.Metadata.Annotations[verifiedProviderAnnotation] = string(verified.Provider)
+		ch.Metadata.Annotations[verifiedSignerAnnotation] = verified.SignedBy
 	}
 
 	return ch, chartPath, nil
 }
+
+// updateDependencies downloads the dependencies of the chart archive at
+// chartPath and loads the chart with them. Helm only updates unpacked
+// charts, so this runs on a temporary copy; the archive itself may be
+// shared with the chart cache and is never modified.
+func (c *helmClient) updateDependencies(
+	opts *action.ChartPathOptions,
+	chartPath, name string,
+) (*chart.Chart, error) {
+
+	dir, err := os.MkdirTemp("", "chart-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := chartutil.ExpandFile(dir, chartPath); err != nil {
+		return nil, fmt.Errorf("failed to unpack chart: %w", err)
+	}
+	chartDir := filepath.Join(dir, name)
+
+	man := &downloader.Manager{
+		Out:              io.Discard,
+		ChartPath:        chartDir,
+		Keyring:          opts.Keyring,
+		Getters:          c.getters(),
+		RegistryClient:   c.registry,
+		RepositoryConfig: c.settings.RepositoryConfig,
+		RepositoryCache:  c.settings.RepositoryCache,
+	}
+	if err := man.Update(); err != nil {
+		return nil, fmt.Errorf("failed to update dependencies: %w", err)
+	}
+
+	return loader.Load(chartDir)
+}
+
+// chartDigest returns the digest recorded by resolveChart.
+func chartDigest(ch *chart.Chart) string {
+	if ch == nil || ch.Metadata == nil {
+		return ""
+	}
+	return ch.Metadata.Annotations[chartDigestAnnotation]
+}
+
+// locateChart returns the path of the chart archive for ref at the version
+// in opts and its digest: the manifest digest for OCI references, the
+// archive digest otherwise. A chart not matching expected fails with a
+// DigestMismatchError. latest is the digest an OCI tag was last resolved
+// to, which a cached archive must match.
+func (c *helmClient) locateChart(opts *action.ChartPathOptions, ref, expected, latest string) (string, string, error) {
+	if registry.IsOCI(ref) {
+		return c.locateOCIChart(ref, opts.Version, expected, latest)
+	}
+
+	path, err := c.locateArchive(opts, ref, expected)
+	if errors.Is(err, repo.ErrNoChartName) || errors.Is(err, repo.ErrNoChartVersion) {
+		return "", "", &ChartNotFoundError{Ref: ref, Version: opts.Version, Err: err}
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	digest, _, err := fileDigest(path)
+	if err != nil {
+		return "", "", err
+	}
+	if expected != "" && digest != expected {
+		return "", "", &DigestMismatchError{Ref: ref, Version: opts.Version, Expected: expected, Actual: digest}
+	}
+
+	return path, digest, nil
+}
+
+// locateArchive downloads a chart from an HTTP repository, served from the
+// chart cache when possible. A cached archive not matching expected is
+// downloaded again.
+func (c *helmClient) locateArchive(opts *action.ChartPathOptions, ref, expected string) (string, error) {
+	if c.cache == nil || !Cacheable(opts.Version) {
+		return c.downloadArchive(opts, ref)
+	}
+
+	key := ChartCacheKey{Ref: ref, Version: opts.Version}
+	if entry, path, ok := c.cache.Get(key); ok && (expected == "" || entry.Digest == expected) {
+		return path, nil
+	}
+
+	downloaded, err := c.downloadArchive(opts, ref)
+	if err != nil {
+		return "", err
+	}
+
+	_, path, err := c.cache.Put(key, downloaded, "")
+	if err != nil {
+		// The download is still usable without the cache.
+		return downloaded, nil
+	}
+	return path, nil
+}
@@ -0,0 +1,320 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/cli"
+
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+// DefaultChartCacheMaxSize bounds the size of the chart archives kept on
+// disk.
+const DefaultChartCacheMaxSize int64 = 1 << 30
+
+// ChartCacheKey identifies a chart by repository reference and exact
+// version.
+type ChartCacheKey struct {
+	Ref     string `json:"ref"`
+	Version string `json:"version"`
+}
+
+// ChartCacheEntry points at a cached chart archive.
+type ChartCacheEntry struct {
+	ChartCacheKey
+	// Digest is the sha256 digest of the archive, which is stored content
+	// addressed under it.
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+	// ManifestDigest is the OCI manifest digest the archive was pulled at,
+	// empty for charts from HTTP repositories.
+	ManifestDigest string `json:"manifestDigest,omitempty"`
+}
+
+// ChartCache keeps downloaded chart archives on disk, shared across
+// reconciles. Archives are stored by digest and verified on every read;
+// the least recently used ones are evicted above MaxSize. Only exact
+// versions are cached, since ranges may resolve differently over time.
+type ChartCache struct {
+	dir     string
+	maxSize int64
+
+	mu sync.Mutex
+}
+
+// NewChartCache creates the cache directory layout under dir.
+func NewChartCache(dir string, maxSize int64) (*ChartCache, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultChartCacheMaxSize
+	}
+
+	c := &ChartCache{dir: dir, maxSize: maxSize}
+	for _, d := range []string{c.blobDir(), c.refDir(), c.helmDir()} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create chart cache directory: %w", err)
+		}
+	}
+
+	size, err := c.size()
+	if err != nil {
+		return nil, err
+	}
+	metrics.ChartCacheSize.Set(float64(size))
+
+	return c, nil
+}
+
+// ApplySettings points Helm's repository cache, repository config and
+// registry config into the cache directory, so Helm never writes to its
+// home directory, which is read-only in the operator container. Paths set
+// explicitly through HELM_* environment variables are kept.
+func (c *ChartCache) ApplySettings(settings *cli.EnvSettings) {
+	if os.Getenv("HELM_REPOSITORY_CACHE") == "" {
+		settings.RepositoryCache = filepath.Join(c.helmDir(), "repository")
+	}
+	if os.Getenv("HELM_REPOSITORY_CONFIG") == "" {
+		settings.RepositoryConfig = filepath.Join(c.helmDir(), "repositories.yaml")
+	}
+	if os.Getenv("HELM_REGISTRY_CONFIG") == "" {
+		settings.RegistryConfig = filepath.Join(c.helmDir(), "registry", "config.json")
+	}
+}
+
+// Cacheable reports whether a chart version can be cached.
+func Cacheable(version string) bool {
+	if version == "" {
+		return false
+	}
+	_, err := semver.StrictNewVersion(strings.TrimPrefix(version, "v"))
+	return err == nil
+}
+
+// Get returns the path of the cached archive for key. An archive whose
+// digest no longer matches is dropped and reported as a miss.
+func (c *ChartCache) Get(key ChartCacheKey) (*ChartCacheEntry, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, err := c.readRef(key)
+	if err != nil {
+		metrics.ChartCacheRequests.WithLabelValues(metrics.ResultMiss).Inc()
+		return nil, "", false
+	}
+
+	path := c.blobPath(entry.Digest)
+	digest, _, err := fileDigest(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Evicted while its reference was kept.
+		_ = os.Remove(c.refPath(key))
+		metrics.ChartCacheRequests.WithLabelValues(metrics.ResultMiss).Inc()
+		return nil, "", false
+	}
+	if err != nil || digest != entry.Digest {
+		metrics.ChartCacheIntegrityFailures.Inc()
+		_ = os.Remove(path)
+		_ = os.Remove(c.refPath(key))
+		metrics.ChartCacheRequests.WithLabelValues(metrics.ResultMiss).Inc()
+		return nil, "", false
+	}
+
+	// The modification time records the last use for eviction.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	metrics.ChartCacheRequests.WithLabelValues(metrics.ResultHit).Inc()
+	return entry, path, true
+}
+
+// Put stores the archive at src for key and returns the cached copy. src
+// is removed when it was downloaded into Helm's repository cache.
+func (c *ChartCache) Put(key ChartCacheKey, src, manifestDigest string) (*ChartCacheEntry, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	digest, size, err := fileDigest(src)
+	if err != nil {
+		return nil, "", err
+	}
+
+	path := c.blobPath(digest)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := copyFile(src, path); err != nil {
+			return nil, "", fmt.Errorf("failed to cache chart: %w", err)
+		}
+	}
+	if strings.HasPrefix(src, c.helmDir()+string(filepath.Separator)) {
+		_ = os.Remove(src)
+	}
+
+	entry := &ChartCacheEntry{
+		ChartCacheKey:  key,
+		Digest:         digest,
+		Size:           size,
+		ManifestDigest: manifestDigest,
+	}
+	if err := c.writeRef(entry); err != nil {
+		return nil, "", err
+	}
+
+	if err := c.evict(path); err != nil {
+		return nil, "", err
+	}
+
+	return entry, path, nil
+}
+
+// evict removes the least recently used archives until the cache fits
+// maxSize. keep is never evicted.
+func (c *ChartCache) evict(keep string) error {
+	type blob struct {
+		path    string
+		size    int64
+		lastUse time.Time
+	}
+
+	entries, err := os.ReadDir(c.blobDir())
+	if err != nil {
+		return err
+	}
+
+	var blobs []blob
+	var total int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		blobs = append(blobs, blob{
+			path:    filepath.Join(c.blobDir(), e.Name()),
+			size:    info.Size(),
+			lastUse: info.ModTime(),
+		})
+		total += info.Size()
+	}
+
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].lastUse.Before(blobs[j].lastUse) })
+
+	for _, b := range blobs {
+		if total <= c.maxSize {
+			break
+		}
+		if b.path == keep {
+			continue
+		}
+		if err := os.Remove(b.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= b.size
+		metrics.ChartCacheEvictions.Inc()
+	}
+
+	metrics.ChartCacheSize.Set(float64(total))
+	return nil
+}
+
+func (c *ChartCache) size() (int64, error) {
+	entries, err := os.ReadDir(c.blobDir())
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil {
+			total += info.Size()
+		}
+	}
+	return total, nil
+}
+
+func (c *ChartCache) readRef(key ChartCacheKey) (*ChartCacheEntry, error) {
+	data, err := os.ReadFile(c.refPath(key))
+	if err != nil {
+		return nil, err
+	}
+	var entry ChartCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if entry.ChartCacheKey != key {
+		return nil, fmt.Errorf("chart cache entry mismatch for %s", key.Ref)
+	}
+	return &entry, nil
+}
+
+func (c *ChartCache) writeRef(entry *ChartCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp := c.refPath(entry.ChartCacheKey) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.refPath(entry.ChartCacheKey))
+}
+
+func (c *ChartCache) blobDir() string { return filepath.Join(c.dir, "blobs") }
+func (c *ChartCache) refDir() string  { return filepath.Join(c.dir, "refs") }
+func (c *ChartCache) helmDir() string { return filepath.Join(c.dir, "helm") }
+
+func (c *ChartCache) blobPath(digest string) string {
+	return filepath.Join(c.blobDir(), strings.TrimPrefix(digest, "sha256:")+".tgz")
+}
+
+func (c *ChartCache) refPath(key ChartCacheKey) string {
+	sum := sha256.Sum256([]byte(key.Ref + "\x00" + key.Version))
+	return filepath.Join(c.refDir(), hex.EncodeToString(sum[:])+".json")
+}
+
+// fileDigest returns the sha256 digest and size of the file at path.
+func fileDigest(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
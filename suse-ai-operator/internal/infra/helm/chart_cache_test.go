@@ -0,0 +1,128 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChartCache", func() {
+	var (
+		cache *ChartCache
+		src   string
+	)
+
+	// writeArchive writes an archive of size bytes filled with b to src.
+	writeArchive := func(b byte, size int) string {
+		path := filepath.Join(src, string(b)+".tgz")
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = b
+		}
+		Expect(os.WriteFile(path, data, 0o644)).To(Succeed())
+		return path
+	}
+
+	put := func(key ChartCacheKey, archive string) string {
+		_, path, err := cache.Put(key, archive, "")
+		Expect(err).NotTo(HaveOccurred())
+		return path
+	}
+
+	// use sets the last use of the cached archive at path.
+	use := func(path string, ago time.Duration) {
+		t := time.Now().Add(-ago)
+		Expect(os.Chtimes(path, t, t)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		cache, err = NewChartCache(GinkgoT().TempDir(), 25)
+		Expect(err).NotTo(HaveOccurred())
+		src = GinkgoT().TempDir()
+	})
+
+	DescribeTable("Cacheable",
+		func(version string, expected bool) {
+			Expect(Cacheable(version)).To(Equal(expected))
+		},
+		Entry("an exact version", "1.2.3", true),
+		Entry("an exact version with a v prefix", "v1.2.3", true),
+		Entry("a prerelease", "1.2.3-rc.1", true),
+		Entry("no version", "", false),
+		Entry("a range", "^1.2.0", false),
+		Entry("a partial version", "1.2", false),
+	)
+
+	It("returns the archive it stored", func() {
+		key := ChartCacheKey{Ref: "oci://registry.example.com/charts/ext", Version: "1.0.0"}
+		stored, storedPath, err := cache.Put(key, writeArchive('a', 10), "sha256:0123")
+		Expect(err).NotTo(HaveOccurred())
+
+		entry, path, ok := cache.Get(key)
+		Expect(ok).To(BeTrue())
+		Expect(path).To(Equal(storedPath))
+		Expect(entry).To(Equal(stored))
+		Expect(entry.Size).To(Equal(int64(10)))
+		Expect(entry.ManifestDigest).To(Equal("sha256:0123"))
+		Expect(entry.Digest).To(HavePrefix("sha256:"))
+	})
+
+	It("shares an archive stored for several keys", func() {
+		first := put(ChartCacheKey{Ref: "https://charts.example.com/ext", Version: "1.0.0"}, writeArchive('a', 10))
+		second := put(ChartCacheKey{Ref: "https://mirror.example.com/ext", Version: "1.0.0"}, writeArchive('a', 10))
+		Expect(second).To(Equal(first))
+	})
+
+	DescribeTable("misses",
+		func(modify func(key ChartCacheKey, path string) ChartCacheKey) {
+			key := ChartCacheKey{Ref: "oci://registry.example.com/charts/ext", Version: "1.0.0"}
+			path := put(key, writeArchive('a', 10))
+
+			_, _, ok := cache.Get(modify(key, path))
+			Expect(ok).To(BeFalse())
+		},
+		Entry("for another version", func(key ChartCacheKey, _ string) ChartCacheKey {
+			return ChartCacheKey{Ref: key.Ref, Version: "1.0.1"}
+		}),
+		Entry("for an evicted archive", func(key ChartCacheKey, path string) ChartCacheKey {
+			Expect(os.Remove(path)).To(Succeed())
+			return key
+		}),
+		Entry("for a corrupted archive, and drops it", func(key ChartCacheKey, path string) ChartCacheKey {
+			Expect(os.WriteFile(path, []byte("tampered"), 0o644)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(path).NotTo(BeAnExistingFile())
+			})
+			return key
+		}),
+	)
+
+	It("evicts the least recently used archives above the maximum size", func() {
+		a := ChartCacheKey{Ref: "oci://registry.example.com/charts/a", Version: "1.0.0"}
+		b := ChartCacheKey{Ref: "oci://registry.example.com/charts/b", Version: "1.0.0"}
+		c := ChartCacheKey{Ref: "oci://registry.example.com/charts/c", Version: "1.0.0"}
+
+		use(put(a, writeArchive('a', 10)), time.Minute)
+		use(put(b, writeArchive('b', 10)), 2*time.Minute)
+		put(c, writeArchive('c', 10))
+
+		_, _, ok := cache.Get(b)
+		Expect(ok).To(BeFalse())
+		_, _, ok = cache.Get(a)
+		Expect(ok).To(BeTrue())
+		_, _, ok = cache.Get(c)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("keeps the archive just stored even above the maximum size", func() {
+		key := ChartCacheKey{Ref: "oci://registry.example.com/charts/large", Version: "1.0.0"}
+		put(key, writeArchive('l', 50))
+
+		_, _, ok := cache.Get(key)
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -0,0 +1,68 @@
+package helm
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+var _ = Describe("Resolving charts", func() {
+	Describe("resolveChart", func() {
+		var (
+			reg   *testRegistry
+			cache *ChartCache
+			spec  ReleaseSpec
+		)
+
+		BeforeEach(func() {
+			reg = newTestRegistry()
+
+			dep := &chart.Chart{
+				Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "dep", Version: "0.1.0"},
+			}
+			depPath, err := chartutil.Save(dep, GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+			reg.pushChart("charts/dep", "0.1.0", readArchive(depPath), nil)
+
+			// The archive lists a dependency without shipping it in charts/.
+			ch, err := loader.Load(newChartArchive("0.1.0", "parent"))
+			Expect(err).NotTo(HaveOccurred())
+			ch.Metadata.Dependencies = []*chart.Dependency{{
+				Name: "dep", Version: "0.1.0", Repository: reg.ref("charts"),
+			}}
+			path, err := chartutil.Save(ch, GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+			reg.pushChart("charts/ext", "0.1.0", readArchive(path), nil)
+
+			cache, err = NewChartCache(GinkgoT().TempDir(), 0)
+			Expect(err).NotTo(HaveOccurred())
+			spec = ReleaseSpec{Name: "ext", ChartRef: reg.ref("charts/ext"), Version: "0.1.0"}
+		})
+
+		It("refuses a chart with missing dependencies", func() {
+			_, _, err := reg.client(cache).resolveChart(context.Background(),
+				&action.ChartPathOptions{Version: "0.1.0"}, spec)
+			Expect(err).To(MatchError(ContainSubstring("missing dependencies")))
+		})
+
+		It("downloads missing dependencies when requested, leaving the cached archive alone", func() {
+			spec.Options.DependencyUpdate = true
+			c := reg.client(cache)
+
+			ch, path, err := c.resolveChart(context.Background(), &action.ChartPathOptions{Version: "0.1.0"}, spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ch.Dependencies()).To(HaveLen(1))
+			Expect(ch.Dependencies()[0].Name()).To(Equal("dep"))
+			Expect(chartDigest(ch)).NotTo(BeEmpty())
+
+			cached, err := loader.Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cached.Dependencies()).To(BeEmpty())
+		})
+	})
+})
@@ -2,21 +2,49 @@ package helm
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 )
 
 type ReleaseStatus string
 
 const (
-	StatusDeployed ReleaseStatus = "deployed"
-	StatusFailed   ReleaseStatus = "failed"
+	StatusUnknown         ReleaseStatus = "unknown"
+	StatusDeployed        ReleaseStatus = "deployed"
+	StatusUninstalled     ReleaseStatus = "uninstalled"
+	StatusSuperseded      ReleaseStatus = "superseded"
+	StatusFailed          ReleaseStatus = "failed"
+	StatusUninstalling    ReleaseStatus = "uninstalling"
+	StatusPendingInstall  ReleaseStatus = "pending-install"
+	StatusPendingUpgrade  ReleaseStatus = "pending-upgrade"
+	StatusPendingRollback ReleaseStatus = "pending-rollback"
 )
 
+// IsLocked reports whether another Helm operation holds the release.
+func (s ReleaseStatus) IsLocked() bool {
+	switch s {
+	case StatusPendingInstall, StatusPendingUpgrade, StatusPendingRollback, StatusUninstalling:
+		return true
+	}
+	return false
+}
+
+// ErrReleaseNotFound is returned by GetRelease when the release has no
+// history at all.
+var ErrReleaseNotFound = errors.New("helm release not found")
+
 type ReleaseInfo struct {
 	ChartName string
 	Version   string
 	Values    map[string]interface{}
 	Status    ReleaseStatus
 	Revision  int
+	// LastDeployed is when the latest revision was created.
+	LastDeployed time.Time
+	// ChartDigest is the digest of the chart the latest revision was
+	// installed from, empty for revisions not installed by the operator.
+	ChartDigest string
 }
 
 type ReleaseSpec struct {
@@ -24,11 +52,189 @@ type ReleaseSpec struct {
 	Namespace string
 	ChartRef  string
 	Version   string
-	Values    map[string]interface{}
+	// Digest pins the chart: the manifest digest for OCI references, the
+	// archive digest otherwise. A chart resolving to another digest fails
+	// with a DigestMismatchError.
+	Digest string
+	// Verify, when set, verifies the chart signature before rendering.
+	Verify  *Verification
+	Values  map[string]interface{}
+	Options ReleaseOptions
+	// RollbackOnFailure rolls back to the last deployed revision when an
+	// upgrade fails.
+	RollbackOnFailure bool
+	Recovery          RecoveryPolicy
+
+	// AppliedHash and AppliedRevision describe the last state this spec was
+	// rendered and applied at. While the desired state hash and the latest
+	// revision still match them, the dry-run render is skipped unless
+	// CheckDrift is set.
+	AppliedHash     string
+	AppliedRevision int
+	CheckDrift      bool
+
+	// resolvedDigest is the manifest digest the OCI tag resolved to when
+	// the desired state was hashed.
+	resolvedDigest string
+}
+
+type RecoveryStrategy string
+
+const (
+	// RecoveryRollback rolls a stuck release back to its last deployed
+	// revision, falling back to RecoveryReinstall when there is none.
+	RecoveryRollback RecoveryStrategy = "Rollback"
+	// RecoveryReinstall uninstalls a stuck release and installs it again.
+	RecoveryReinstall RecoveryStrategy = "Reinstall"
+)
+
+const DefaultStaleLockTimeout = 15 * time.Minute
+
+// RecoveryPolicy controls how releases left in a pending, uninstalling or
+// failed state are recovered.
+type RecoveryPolicy struct {
+	// StaleLockTimeout is the age after which a pending or uninstalling
+	// release is considered abandoned.
+	StaleLockTimeout time.Duration
+	Strategy         RecoveryStrategy
+}
+
+type ReleaseAction string
+
+const (
+	ActionInstalled  ReleaseAction = "Installed"
+	ActionUpgraded   ReleaseAction = "Upgraded"
+	ActionUnchanged  ReleaseAction = "Unchanged"
+	ActionRolledBack ReleaseAction = "RolledBack"
+	// ActionReinstalled is reported when a stuck release was uninstalled
+	// and installed again.
+	ActionReinstalled ReleaseAction = "Reinstalled"
+)
+
+// ReleaseResult describes what EnsureRelease did and the revision that
+// is running afterwards.
+type ReleaseResult struct {
+	Action               ReleaseAction
+	Revision             int
+	ChartVersion         string
+	PreviousChartVersion string
+	// AppVersion is the app version of the chart of the running revision.
+	AppVersion string
+	// ChartDigest is the digest of the chart the running revision was
+	// installed from.
+	ChartDigest string
+	// Verification describes how the chart was verified, nil when it was
+	// not.
+	Verification *VerificationResult
+	// Recovery describes the steps taken to recover a stuck release
+	// before the action, if any.
+	Recovery []string
+	// Hash is the desired state hash of the spec that was reconciled.
+	Hash string
+	// Rendered reports whether the chart was rendered and compared against
+	// the release, rather than skipped on a matching hash.
+	Rendered bool
+	// Diff is what an upgrade changed in the release manifest.
+	Diff *ManifestDiff
+}
+
+const (
+	DefaultTimeout    = 10 * time.Minute
+	DefaultMaxHistory = 10
+)
+
+// ReleaseOptions are applied uniformly to install, upgrade and the
+// dry-run upgrade used for change detection.
+type ReleaseOptions struct {
+	Timeout          time.Duration
+	Wait             bool
+	WaitForJobs      bool
+	Atomic           bool
+	SkipCRDs         bool
+	DisableHooks     bool
+	MaxHistory       int
+	Force            bool
+	CleanupOnFail    bool
+	CreateNamespace  bool
+	DependencyUpdate bool
+}
+
+func DefaultReleaseOptions() ReleaseOptions {
+	return ReleaseOptions{
+		Timeout:    DefaultTimeout,
+		Wait:       true,
+		MaxHistory: DefaultMaxHistory,
+	}
 }
 
 type HelmClient interface {
-	EnsureRelease(ctx context.Context, spec ReleaseSpec) error
+	EnsureRelease(ctx context.Context, spec ReleaseSpec) (*ReleaseResult, error)
 	DeleteRelease(ctx context.Context, name string) error
 	GetRelease(ctx context.Context, name string) (*ReleaseInfo, error)
+	PlanRelease(ctx context.Context, spec ReleaseSpec) (*ReleasePlan, error)
+}
+
+// UpgradeFailedError is returned when an upgrade fails. RolledBackTo is
+// the revision created by rolling back to the last deployed one, if any.
+type UpgradeFailedError struct {
+	Revision     int
+	ChartVersion string
+	RolledBackTo int
+	Err          error
+}
+
+func (e *UpgradeFailedError) Error() string {
+	if e.RolledBackTo > 0 {
+		return fmt.Sprintf("upgrade to chart version %s failed, rolled back to revision %d: %v",
+			e.ChartVersion, e.RolledBackTo, e.Err)
+	}
+	return fmt.Sprintf("upgrade to chart version %s failed: %v", e.ChartVersion, e.Err)
+}
+
+func (e *UpgradeFailedError) Unwrap() error {
+	return e.Err
+}
+
+// InstallFailedError is returned when Helm ran an install that failed.
+type InstallFailedError struct {
+	ChartVersion string
+	Err          error
+}
+
+func (e *InstallFailedError) Error() string {
+	return fmt.Sprintf("install of chart version %s failed: %v", e.ChartVersion, e.Err)
+}
+
+func (e *InstallFailedError) Unwrap() error {
+	return e.Err
+}
+
+// ReleaseLockedError is returned while another operation holds the
+// release and its lock is not old enough to be considered stale.
+type ReleaseLockedError struct {
+	Name   string
+	Status ReleaseStatus
+	Age    time.Duration
+}
+
+func (e *ReleaseLockedError) Error() string {
+	return fmt.Sprintf("release %q is %s since %s, another operation is in progress",
+		e.Name, e.Status, e.Age.Round(time.Second))
+}
+
+// DigestMismatchError is returned when a chart does not resolve to the
+// digest it is pinned to.
+type DigestMismatchError struct {
+	Ref      string
+	Version  string
+	Expected string
+	Actual   string
+}
+
+func (e *DigestMismatchError) Error() string {
+	ref := e.Ref
+	if e.Version != "" {
+		ref = fmt.Sprintf("%s:%s", e.Ref, e.Version)
+	}
+	return fmt.Sprintf("chart %s resolved to digest %s, expected %s", ref, e.Actual, e.Expected)
 }
@@ -0,0 +1,172 @@
+package helm
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/registry"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+)
+
+const (
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	cosignSignatureSuffix     = ".sig"
+)
+
+// cosignPayload is the part of a cosign simple signing payload that binds
+// the signature to an artifact.
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verifyCosign checks the signatures cosign stores for an OCI artifact
+// under the sha256-<digest>.sig tag against a PEM public key. One valid
+// signature for digest is enough. Keyless signatures and transparency log
+// entries are not checked.
+func (c *helmClient) verifyCosign(ctx context.Context, ref, digest string, keyPEM []byte) (string, error) {
+	pub, err := parsePublicKey(keyPEM)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+
+	repo, err := c.remoteRepository(ref)
+	if err != nil {
+		return "", err
+	}
+
+	tag := strings.Replace(digest, ":", "-", 1) + cosignSignatureSuffix
+	_, rc, err := repo.FetchReference(ctx, tag)
+	if err != nil {
+		return "", fmt.Errorf("no cosign signature found for %s: %w", digest, err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return "", err
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("invalid cosign signature manifest: %w", err)
+	}
+
+	var errs []error
+	for _, layer := range manifest.Layers {
+		sig, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+
+		payload, err := content.FetchAll(ctx, repo, layer)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := verifyCosignPayload(pub, payload, sig, digest); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return keyFingerprint(pub)
+	}
+
+	if len(errs) == 0 {
+		return "", fmt.Errorf("no cosign signature found for %s", digest)
+	}
+	return "", errors.Join(errs...)
+}
+
+func verifyCosignPayload(pub crypto.PublicKey, payload []byte, signature, digest string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, sum[:], sig) {
+			return errors.New("signature does not match the public key")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig); err != nil {
+			return errors.New("signature does not match the public key")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, sig) {
+			return errors.New("signature does not match the public key")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	var p cosignPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid signature payload: %w", err)
+	}
+	if signed := p.Critical.Image.DockerManifestDigest; signed != digest {
+		return fmt.Errorf("signature is for %s, not %s", signed, digest)
+	}
+
+	return nil
+}
+
+// remoteRepository opens the repository of an OCI chart reference with
+// the registry credentials Helm uses.
+func (c *helmClient) remoteRepository(ref string) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(strings.TrimPrefix(ref, registry.OCIScheme+"://"))
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := credentials.NewStore(c.settings.RegistryConfig, credentials.StoreOptions{})
+	if err != nil {
+		return nil, err
+	}
+	repo.Client = &auth.Client{
+		Client:     c.httpClient(),
+		Cache:      auth.NewCache(),
+		Credential: credentials.Credential(store),
+	}
+
+	return repo, nil
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// keyFingerprint identifies a public key by the sha256 of its DER form.
+func keyFingerprint(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
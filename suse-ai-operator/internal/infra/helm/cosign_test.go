@@ -0,0 +1,226 @@
+package helm
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const cosignPayloadMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+func newECDSAKey() crypto.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	return key
+}
+
+func newRSAKey() crypto.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+	return key
+}
+
+func newEd25519Key() crypto.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	return key
+}
+
+// publicKeyPEM returns the PEM encoded public key of key.
+func publicKeyPEM(key crypto.Signer) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// cosignSignature returns a cosign simple signing payload for digest and
+// its base64 signature made with key, as cosign sign stores them.
+func cosignSignature(key crypto.Signer, digest string) ([]byte, string) {
+	payload := []byte(fmt.Sprintf(
+		`{"critical":{"identity":{"docker-reference":"registry.example.com/charts/ext"},`+
+			`"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`,
+		digest))
+
+	var sig []byte
+	var err error
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		sig, err = key.Sign(rand.Reader, payload, crypto.Hash(0))
+	} else {
+		sum := sha256.Sum256(payload)
+		sig, err = key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	return payload, base64.StdEncoding.EncodeToString(sig)
+}
+
+// pushCosignSignature stores a signature of digest made with key under the
+// tag cosign uses for it.
+func (r *testRegistry) pushCosignSignature(repo, digest string, key crypto.Signer) {
+	payload, sig := cosignSignature(key, digest)
+	layer := r.descriptor(cosignPayloadMediaType, payload)
+	layer.Annotations = map[string]string{cosignSignatureAnnotation: sig}
+
+	r.pushManifest(repo, strings.Replace(digest, ":", "-", 1)+cosignSignatureSuffix, ocispec.Manifest{
+		Config: r.descriptor("application/vnd.oci.image.config.v1+json", []byte("{}")),
+		Layers: []ocispec.Descriptor{layer},
+	})
+}
+
+var _ = Describe("Cosign verification", func() {
+	const (
+		digest = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+		other  = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	)
+
+	DescribeTable("verifyCosignPayload",
+		func(newKey func() crypto.Signer, tamper func(key crypto.Signer, payload []byte, sig string) (crypto.PublicKey, []byte, string), expected string) {
+			key := newKey()
+			payload, sig := cosignSignature(key, digest)
+
+			pub, payload, sig := tamper(key, payload, sig)
+			err := verifyCosignPayload(pub, payload, sig, digest)
+			if expected == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expected)))
+			}
+		},
+		Entry("accepts a valid ECDSA signature", newECDSAKey, valid, ""),
+		Entry("accepts a valid RSA signature", newRSAKey, valid, ""),
+		Entry("accepts a valid ed25519 signature", newEd25519Key, valid, ""),
+		Entry("refuses an ECDSA signature made with another key", newECDSAKey, withKey(newECDSAKey),
+			"signature does not match the public key"),
+		Entry("refuses an RSA signature made with another key", newRSAKey, withKey(newRSAKey),
+			"signature does not match the public key"),
+		Entry("refuses an ed25519 signature made with another key", newEd25519Key, withKey(newEd25519Key),
+			"signature does not match the public key"),
+		Entry("refuses an ECDSA signature for another digest", newECDSAKey, signedFor(other),
+			"signature is for "+other),
+		Entry("refuses an RSA signature for another digest", newRSAKey, signedFor(other),
+			"signature is for "+other),
+		Entry("refuses an ed25519 signature for another digest", newEd25519Key, signedFor(other),
+			"signature is for "+other),
+		Entry("refuses a modified payload", newECDSAKey, modifiedPayload,
+			"signature does not match the public key"),
+		Entry("refuses a signature that is not base64", newECDSAKey, badBase64,
+			"invalid signature encoding"),
+	)
+
+	DescribeTable("parsePublicKey",
+		func(data []byte, expected string) {
+			_, err := parsePublicKey(data)
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("refuses data without a PEM block", []byte("not a key"), "no PEM block found"),
+		Entry("refuses a PEM block that is not a public key",
+			pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("garbage")}), "asn1"),
+	)
+
+	Describe("verifyCosign", func() {
+		var (
+			reg    *testRegistry
+			ref    string
+			key    crypto.Signer
+			pushed string
+		)
+
+		BeforeEach(func() {
+			reg = newTestRegistry()
+			ref = reg.ref("charts/ext")
+			key = newECDSAKey()
+			pushed = reg.pushChart("charts/ext", "0.1.0", readArchive(newChartArchive("0.1.0", "signed")), nil).String()
+		})
+
+		It("returns the fingerprint of the key of a valid signature", func() {
+			reg.pushCosignSignature("charts/ext", pushed, key)
+
+			signer, err := reg.client(nil).verifyCosign(context.Background(), ref, pushed, publicKeyPEM(key))
+			Expect(err).NotTo(HaveOccurred())
+			fingerprint, err := keyFingerprint(key.Public())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signer).To(Equal(fingerprint))
+		})
+
+		It("refuses a signature made with another key", func() {
+			reg.pushCosignSignature("charts/ext", pushed, newECDSAKey())
+
+			_, err := reg.client(nil).verifyCosign(context.Background(), ref, pushed, publicKeyPEM(key))
+			Expect(err).To(MatchError("signature does not match the public key"))
+		})
+
+		It("refuses a chart without a signature", func() {
+			_, err := reg.client(nil).verifyCosign(context.Background(), ref, pushed, publicKeyPEM(key))
+			Expect(err).To(MatchError(ContainSubstring("no cosign signature found for " + pushed)))
+		})
+
+		It("refuses an invalid public key", func() {
+			_, err := reg.client(nil).verifyCosign(context.Background(), ref, pushed, []byte("not a key"))
+			Expect(err).To(MatchError("invalid public key: no PEM block found"))
+		})
+
+		It("stops when the context is done", func() {
+			reg.pushCosignSignature("charts/ext", pushed, key)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := reg.client(nil).verifyCosign(ctx, ref, pushed, publicKeyPEM(key))
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("is reported by verifyChart as a verification error", func() {
+			reg.pushCosignSignature("charts/ext", pushed, newECDSAKey())
+
+			_, err := reg.client(nil).verifyChart(context.Background(), ref, "", pushed, nil,
+				&Verification{Provider: VerifyCosign, Key: publicKeyPEM(key)})
+			var verifyErr *VerificationError
+			Expect(err).To(BeAssignableToTypeOf(verifyErr))
+			Expect(err).To(MatchError(ContainSubstring("Cosign verification of chart " + ref + " failed")))
+		})
+
+		It("is only supported for OCI charts", func() {
+			_, err := reg.client(nil).verifyChart(context.Background(), "https://charts.example.com/ext-0.1.0.tgz",
+				"", pushed, nil, &Verification{Provider: VerifyCosign, Key: publicKeyPEM(key)})
+			Expect(err).To(MatchError(ContainSubstring("cosign signatures are only supported for OCI charts")))
+		})
+	})
+})
+
+func valid(key crypto.Signer, payload []byte, sig string) (crypto.PublicKey, []byte, string) {
+	return key.Public(), payload, sig
+}
+
+func withKey(newKey func() crypto.Signer) func(crypto.Signer, []byte, string) (crypto.PublicKey, []byte, string) {
+	return func(_ crypto.Signer, payload []byte, sig string) (crypto.PublicKey, []byte, string) {
+		return newKey().Public(), payload, sig
+	}
+}
+
+func signedFor(digest string) func(crypto.Signer, []byte, string) (crypto.PublicKey, []byte, string) {
+	return func(key crypto.Signer, _ []byte, _ string) (crypto.PublicKey, []byte, string) {
+		payload, sig := cosignSignature(key, digest)
+		return key.Public(), payload, sig
+	}
+}
+
+func modifiedPayload(key crypto.Signer, payload []byte, sig string) (crypto.PublicKey, []byte, string) {
+	return key.Public(), []byte(strings.Replace(string(payload), "registry.example.com", "evil.example.com", 1)), sig
+}
+
+func badBase64(key crypto.Signer, payload []byte, _ string) (crypto.PublicKey, []byte, string) {
+	return key.Public(), payload, "not base64!"
+}
@@ -0,0 +1,307 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"sigs.k8s.io/yaml"
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "Added"
+	ChangeRemoved ChangeType = "Removed"
+	ChangeChanged ChangeType = "Changed"
+)
+
+// redacted replaces Secret values in diffs.
+const redacted = "(redacted)"
+
+// FieldChange is a single changed field of an object. Old or New is empty
+// when the field was added or removed.
+type FieldChange struct {
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// ObjectChange is an object added, removed or changed by an upgrade.
+type ObjectChange struct {
+	Type       ChangeType    `json:"type"`
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Namespace  string        `json:"namespace,omitempty"`
+	Name       string        `json:"name"`
+	Fields     []FieldChange `json:"fields,omitempty"`
+}
+
+func (o ObjectChange) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// ManifestDiff is the resource level difference between two release
+// manifests. Values of Secret data are never included.
+type ManifestDiff struct {
+	Changes []ObjectChange `json:"changes"`
+}
+
+func (d *ManifestDiff) Empty() bool {
+	return d == nil || len(d.Changes) == 0
+}
+
+// Count returns the number of objects added, removed and changed.
+func (d *ManifestDiff) Count() (added, removed, changed int) {
+	if d == nil {
+		return 0, 0, 0
+	}
+	for _, c := range d.Changes {
+		switch c.Type {
+		case ChangeAdded:
+			added++
+		case ChangeRemoved:
+			removed++
+		case ChangeChanged:
+			changed++
+		}
+	}
+	return added, removed, changed
+}
+
+// Summary describes the diff in one line, listing at most a few objects.
+func (d *ManifestDiff) Summary() string {
+	const maxListed = 5
+
+	added, removed, changed := d.Count()
+	summary := fmt.Sprintf("%d added, %d changed, %d removed", added, changed, removed)
+	if d.Empty() {
+		return summary
+	}
+
+	names := make([]string, 0, maxListed)
+	for i, c := range d.Changes {
+		if i == maxListed {
+			names = append(names, fmt.Sprintf("and %d more", len(d.Changes)-maxListed))
+			break
+		}
+		names = append(names, fmt.Sprintf("%s %s", strings.ToLower(string(c.Type)), c))
+	}
+	return fmt.Sprintf("%s: %s", summary, strings.Join(names, ", "))
+}
+
+// String renders the full diff as text, one object per block.
+func (d *ManifestDiff) String() string {
+	if d.Empty() {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, c := range d.Changes {
+		fmt.Fprintf(&b, "%s %s (%s)\n", c.Type, c, c.APIVersion)
+		for _, f := range c.Fields {
+			switch {
+			case f.Old == "":
+				fmt.Fprintf(&b, "  + %s: %s\n", f.Path, f.New)
+			case f.New == "":
+				fmt.Fprintf(&b, "  - %s: %s\n", f.Path, f.Old)
+			default:
+				fmt.Fprintf(&b, "  ~ %s: %s -> %s\n", f.Path, f.Old, f.New)
+			}
+		}
+	}
+	return b.String()
+}
+
+// DiffManifests compares two rendered release manifests object by object.
+// Formatting and ordering differences are ignored.
+func DiffManifests(current, rendered string) (*ManifestDiff, error) {
+	oldObjs, err := parseManifest(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current manifest: %w", err)
+	}
+	newObjs, err := parseManifest(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rendered manifest: %w", err)
+	}
+
+	diff := &ManifestDiff{}
+
+	for _, key := range sortedKeys(oldObjs, newObjs) {
+		o, inOld := oldObjs[key]
+		n, inNew := newObjs[key]
+
+		change := ObjectChange{
+			APIVersion: key.APIVersion,
+			Kind:       key.Kind,
+			Namespace:  key.Namespace,
+			Name:       key.Name,
+		}
+
+		switch {
+		case !inOld:
+			change.Type = ChangeAdded
+		case !inNew:
+			change.Type = ChangeRemoved
+		default:
+			diffFields("", o, n, isSecret(key), &change.Fields)
+			if len(change.Fields) == 0 {
+				continue
+			}
+			change.Type = ChangeChanged
+		}
+
+		diff.Changes = append(diff.Changes, change)
+	}
+
+	return diff, nil
+}
+
+type objectKey struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+func (k objectKey) less(o objectKey) bool {
+	if k.Kind != o.Kind {
+		return k.Kind < o.Kind
+	}
+	if k.Namespace != o.Namespace {
+		return k.Namespace < o.Namespace
+	}
+	if k.Name != o.Name {
+		return k.Name < o.Name
+	}
+	return k.APIVersion < o.APIVersion
+}
+
+func isSecret(k objectKey) bool {
+	return k.Kind == "Secret" && k.APIVersion == "v1"
+}
+
+func parseManifest(manifest string) (map[objectKey]map[string]interface{}, error) {
+	objs := map[objectKey]map[string]interface{}{}
+
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		key := objectKey{
+			APIVersion: stringField(obj, "apiVersion"),
+			Kind:       stringField(obj, "kind"),
+		}
+		if md, ok := obj["metadata"].(map[string]interface{}); ok {
+			key.Namespace = stringField(md, "namespace")
+			key.Name = stringField(md, "name")
+		}
+
+		objs[key] = obj
+	}
+
+	return objs, nil
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func sortedKeys(a, b map[objectKey]map[string]interface{}) []objectKey {
+	keys := make([]objectKey, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	return keys
+}
+
+// diffFields appends the leaf fields that differ between a and b.
+func diffFields(path string, a, b interface{}, secret bool, out *[]FieldChange) {
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+
+	am, aIsMap := a.(map[string]interface{})
+	bm, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		keys := make([]string, 0, len(am)+len(bm))
+		for k := range am {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, ok := am[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			diffFields(joinPath(path, k), am[k], bm[k], secret, out)
+		}
+		return
+	}
+
+	al, aIsList := a.([]interface{})
+	bl, bIsList := b.([]interface{})
+	if aIsList && bIsList && len(al) == len(bl) {
+		for i := range al {
+			diffFields(fmt.Sprintf("%s[%d]", path, i), al[i], bl[i], secret, out)
+		}
+		return
+	}
+
+	change := FieldChange{Path: path, Old: formatValue(a), New: formatValue(b)}
+	if secret && isSecretData(path) {
+		if a != nil {
+			change.Old = redacted
+		}
+		if b != nil {
+			change.New = redacted
+		}
+	}
+	*out = append(*out, change)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func isSecretData(path string) bool {
+	return path == "data" || path == "stringData" ||
+		strings.HasPrefix(path, "data.") || strings.HasPrefix(path, "stringData.")
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,167 @@
+package helm
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const configMapManifest = `---
+# Source: ext/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ext
+  namespace: default
+data:
+  mode: production
+  replicas: "1"
+`
+
+const secretManifest = `---
+# Source: ext/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: ext
+  namespace: default
+  labels:
+    app: ext
+stringData:
+  password: hunter2
+`
+
+var _ = Describe("DiffManifests", func() {
+	DescribeTable("finds no changes",
+		func(current, rendered string) {
+			diff, err := DiffManifests(current, rendered)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Empty()).To(BeTrue())
+			Expect(diff.String()).To(BeEmpty())
+		},
+		Entry("for empty manifests", "", ""),
+		Entry("for the same manifest", configMapManifest, configMapManifest),
+		Entry("for reordered objects",
+			configMapManifest+secretManifest, secretManifest+configMapManifest),
+		Entry("for reformatted fields", configMapManifest, `apiVersion: v1
+kind: ConfigMap
+data: {replicas: "1", mode: production}
+metadata: {namespace: default, name: ext}
+`),
+	)
+
+	DescribeTable("reports the changed objects and fields",
+		func(current, rendered string, expected []ObjectChange) {
+			diff, err := DiffManifests(current, rendered)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Changes).To(Equal(expected))
+		},
+		Entry("for an added object", configMapManifest, configMapManifest+secretManifest,
+			[]ObjectChange{{Type: ChangeAdded, APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "ext"}}),
+		Entry("for a removed object", configMapManifest+secretManifest, secretManifest,
+			[]ObjectChange{{Type: ChangeRemoved, APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "ext"}}),
+		Entry("for changed, added and removed fields", configMapManifest, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ext
+  namespace: default
+data:
+  mode: debug
+  level: trace
+`,
+			[]ObjectChange{{
+				Type: ChangeChanged, APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "ext",
+				Fields: []FieldChange{
+					{Path: "data.level", New: "trace"},
+					{Path: "data.mode", Old: "production", New: "debug"},
+					{Path: "data.replicas", Old: "1"},
+				},
+			}}),
+		Entry("for a changed list item", `apiVersion: v1
+kind: Service
+metadata:
+  name: ext
+spec:
+  ports:
+  - port: 80
+  - port: 443
+`, `apiVersion: v1
+kind: Service
+metadata:
+  name: ext
+spec:
+  ports:
+  - port: 80
+  - port: 8443
+`,
+			[]ObjectChange{{
+				Type: ChangeChanged, APIVersion: "v1", Kind: "Service", Name: "ext",
+				Fields: []FieldChange{{Path: "spec.ports[1].port", Old: "443", New: "8443"}},
+			}}),
+	)
+
+	DescribeTable("redacts Secret data",
+		func(rendered string, expected []FieldChange) {
+			diff, err := DiffManifests(secretManifest, rendered)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Changes).To(HaveLen(1))
+			Expect(diff.Changes[0].Fields).To(Equal(expected))
+			Expect(diff.String()).NotTo(ContainSubstring("hunter2"))
+		},
+		Entry("for a changed value", `apiVersion: v1
+kind: Secret
+metadata:
+  name: ext
+  namespace: default
+  labels:
+    app: ext
+stringData:
+  password: correct-horse
+`, []FieldChange{{Path: "stringData.password", Old: redacted, New: redacted}}),
+		Entry("for removed values", `apiVersion: v1
+kind: Secret
+metadata:
+  name: ext
+  namespace: default
+  labels:
+    app: ext
+data:
+  password: aHVudGVyMg==
+`, []FieldChange{
+			{Path: "data", New: redacted},
+			{Path: "stringData", Old: redacted},
+		}),
+		Entry("but not its metadata", `apiVersion: v1
+kind: Secret
+metadata:
+  name: ext
+  namespace: default
+  labels:
+    app: other
+stringData:
+  password: hunter2
+`, []FieldChange{{Path: "metadata.labels.app", Old: "ext", New: "other"}}),
+	)
+
+	It("rejects a manifest that is not YAML", func() {
+		_, err := DiffManifests(configMapManifest, "kind: [")
+		Expect(err).To(MatchError(ContainSubstring("failed to parse rendered manifest")))
+	})
+})
+
+var _ = Describe("ManifestDiff", func() {
+	It("summarizes the changes", func() {
+		diff := &ManifestDiff{Changes: []ObjectChange{
+			{Type: ChangeAdded, Kind: "ConfigMap", Namespace: "default", Name: "a"},
+			{Type: ChangeChanged, Kind: "ClusterRole", Name: "b"},
+		}}
+		Expect(diff.Summary()).To(Equal("1 added, 1 changed, 0 removed: added ConfigMap/default/a, changed ClusterRole/b"))
+	})
+
+	It("lists at most five objects in the summary", func() {
+		diff := &ManifestDiff{}
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+			diff.Changes = append(diff.Changes, ObjectChange{Type: ChangeRemoved, Kind: "ConfigMap", Name: name})
+		}
+		Expect(diff.Summary()).To(HaveSuffix("removed ConfigMap/e, and 2 more"))
+	})
+})
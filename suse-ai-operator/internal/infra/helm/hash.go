@@ -0,0 +1,55 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// desiredState is everything that determines the rendered manifest of a
+// release. Changing any of it changes the hash.
+type desiredState struct {
+	ChartRef     string                 `json:"chartRef"`
+	Version      string                 `json:"version"`
+	Digest       string                 `json:"digest,omitempty"`
+	ChartVersion string                 `json:"chartVersion,omitempty"`
+	ChartDigest  string                 `json:"chartDigest,omitempty"`
+	Verify       *verifyState           `json:"verify,omitempty"`
+	Values       map[string]interface{} `json:"values"`
+	Options      ReleaseOptions         `json:"options"`
+}
+
+// verifyState identifies the verification key by digest, so the key
+// itself never ends up in the hash input.
+type verifyState struct {
+	Provider  VerificationProvider `json:"provider"`
+	KeyDigest string               `json:"keyDigest"`
+}
+
+// DesiredStateHash returns a stable hash of the chart reference, version,
+// digest, verification, values and options of spec, and of the chart
+// version and digest they currently resolve to.
+func DesiredStateHash(spec ReleaseSpec, resolved ResolvedChart) (string, error) {
+	state := desiredState{
+		ChartRef:     spec.ChartRef,
+		Version:      spec.Version,
+		Digest:       spec.Digest,
+		ChartVersion: resolved.Version,
+		ChartDigest:  resolved.Digest,
+		Values:       spec.Values,
+		Options:      spec.Options,
+	}
+	if v := spec.Verify; v != nil {
+		sum := sha256.Sum256(v.Key)
+		state.Verify = &verifyState{Provider: v.Provider, KeyDigest: hex.EncodeToString(sum[:])}
+	}
+
+	// encoding/json sorts map keys, so equal values hash equally.
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
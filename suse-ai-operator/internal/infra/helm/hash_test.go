@@ -0,0 +1,73 @@
+package helm
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DesiredStateHash", func() {
+	newSpec := func() ReleaseSpec {
+		return ReleaseSpec{
+			Name:     "ext",
+			ChartRef: "oci://registry.example.com/charts/ext",
+			Version:  "^1.0.0",
+			Values: map[string]interface{}{
+				"image":    map[string]interface{}{"tag": "1.0.0"},
+				"replicas": 1,
+			},
+			Verify:  &Verification{Provider: VerifyCosign, Key: []byte("key")},
+			Options: DefaultReleaseOptions(),
+		}
+	}
+	resolved := ResolvedChart{Version: "1.2.0", Digest: "sha256:0123"}
+
+	hash := func(spec ReleaseSpec, resolved ResolvedChart) string {
+		h, err := DesiredStateHash(spec, resolved)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(h).To(HavePrefix("sha256:"))
+		return h
+	}
+
+	DescribeTable("keeps the hash",
+		func(modify func(*ReleaseSpec)) {
+			spec := newSpec()
+			modify(&spec)
+			Expect(hash(spec, resolved)).To(Equal(hash(newSpec(), resolved)))
+		},
+		Entry("for the same spec", func(*ReleaseSpec) {}),
+		Entry("for values built in a different order", func(s *ReleaseSpec) {
+			s.Values = map[string]interface{}{}
+			s.Values["replicas"] = 1
+			s.Values["image"] = map[string]interface{}{"tag": "1.0.0"}
+		}),
+		Entry("for a different release name", func(s *ReleaseSpec) { s.Name = "other" }),
+	)
+
+	DescribeTable("changes the hash",
+		func(modify func(*ReleaseSpec, *ResolvedChart)) {
+			spec, changed := newSpec(), resolved
+			modify(&spec, &changed)
+			Expect(hash(spec, changed)).NotTo(Equal(hash(newSpec(), resolved)))
+		},
+		Entry("for another chart", func(s *ReleaseSpec, _ *ResolvedChart) {
+			s.ChartRef = "oci://registry.example.com/charts/other"
+		}),
+		Entry("for another version constraint", func(s *ReleaseSpec, _ *ResolvedChart) { s.Version = "~1.2.0" }),
+		Entry("for a pinned digest", func(s *ReleaseSpec, _ *ResolvedChart) { s.Digest = "sha256:4567" }),
+		Entry("for another value", func(s *ReleaseSpec, _ *ResolvedChart) { s.Values["replicas"] = 2 }),
+		Entry("for another option", func(s *ReleaseSpec, _ *ResolvedChart) { s.Options.Timeout = time.Minute }),
+		Entry("for another verification key", func(s *ReleaseSpec, _ *ResolvedChart) {
+			s.Verify = &Verification{Provider: VerifyCosign, Key: []byte("other")}
+		}),
+		Entry("without verification", func(s *ReleaseSpec, _ *ResolvedChart) { s.Verify = nil }),
+		Entry("for a newly published chart version", func(_ *ReleaseSpec, r *ResolvedChart) {
+			r.Version = "1.3.0"
+			r.Digest = "sha256:89ab"
+		}),
+		Entry("for a tag pushed again with another digest", func(_ *ReleaseSpec, r *ResolvedChart) {
+			r.Digest = "sha256:89ab"
+		}),
+	)
+})
@@ -3,6 +3,7 @@ package helm
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 
 	"github.com/SUSE/suse-ai-operator/internal/logging"
@@ -14,22 +15,41 @@ import (
 type helmClient struct {
 	settings *cli.EnvSettings
 	registry *registry.Client
-	locks    sync.Map
+	cache    *ChartCache
+	// transport is used for chart downloads when set.
+	transport *http.Transport
+	locks     sync.Map
 }
 
-func New(settings *cli.EnvSettings) (HelmClient, error) {
-	reg, err := registry.NewClient(
+// New creates a Helm client. cache may be nil to always download charts.
+func New(settings *cli.EnvSettings, cache *ChartCache, opts ...Option) (HelmClient, error) {
+	if cache != nil {
+		cache.ApplySettings(settings)
+	}
+
+	c := &helmClient{
+		settings: settings,
+		cache:    cache,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	regOpts := []registry.ClientOption{
 		registry.ClientOptDebug(settings.Debug),
 		registry.ClientOptCredentialsFile(settings.RegistryConfig),
-	)
+	}
+	if c.transport != nil {
+		regOpts = append(regOpts, registry.ClientOptHTTPClient(&http.Client{Transport: c.transport}))
+	}
+
+	reg, err := registry.NewClient(regOpts...)
 	if err != nil {
 		return nil, err
 	}
+	c.registry = reg
 
-	return &helmClient{
-		settings: settings,
-		registry: reg,
-	}, nil
+	return c, nil
 }
 
 func (c *helmClient) actionConfig(ctx context.Context, namespace string) (*action.Configuration, error) {
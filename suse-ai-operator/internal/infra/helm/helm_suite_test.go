@@ -0,0 +1,50 @@
+package helm
+
+import (
+	"io"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+func TestHelm(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Helm Suite")
+}
+
+// newTestConfiguration returns an action configuration keeping releases
+// in memory, holding one revision of release "ext" per status.
+func newTestConfiguration(statuses ...release.Status) *action.Configuration {
+	cfg := &action.Configuration{
+		Releases:     storage.Init(driver.NewMemory()),
+		KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+		Capabilities: chartutil.DefaultCapabilities,
+		Log:          func(string, ...interface{}) {},
+	}
+
+	for i, status := range statuses {
+		Expect(cfg.Releases.Create(&release.Release{
+			Name:      "ext",
+			Namespace: "default",
+			Version:   i + 1,
+			Info:      &release.Info{Status: status},
+			Chart: &chart.Chart{Metadata: &chart.Metadata{
+				APIVersion: chart.APIVersionV2,
+				Name:       "ext",
+				Version:    "1.0.0",
+			}},
+			Config: map[string]interface{}{},
+		})).To(Succeed())
+	}
+
+	return cfg
+}
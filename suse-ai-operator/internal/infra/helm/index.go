@@ -4,10 +4,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
 )
 
+const indexFetchTimeout = 30 * time.Second
+
 type IndexFile struct {
 	Entries map[string][]ChartVersion `yaml:"entries"`
 }
@@ -17,8 +23,18 @@ type ChartVersion struct {
 	Annotations map[string]string `yaml:"annotations"`
 }
 
-func FetchIndex(url string) (*IndexFile, error) {
-	resp, err := http.Get(url)
+func FetchIndex(url string, caBundle []byte) (index *IndexFile, err error) {
+	start := time.Now()
+	defer func() {
+		metrics.IndexFetchDuration.WithLabelValues(metrics.Result(err)).Observe(time.Since(start).Seconds())
+	}()
+
+	httpClient, err := certs.HTTPClient(caBundle, indexFetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -33,12 +49,12 @@ func FetchIndex(url string) (*IndexFile, error) {
 		return nil, err
 	}
 
-	var index IndexFile
-	if err := yaml.Unmarshal(data, &index); err != nil {
+	index = &IndexFile{}
+	if err := yaml.Unmarshal(data, index); err != nil {
 		return nil, err
 	}
 
-	return &index, nil
+	return index, nil
 }
 
 func FindAnnotations(
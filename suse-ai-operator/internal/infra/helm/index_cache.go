@@ -3,6 +3,8 @@ package helm
 import (
 	"sync"
 	"time"
+
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
 )
 
 type IndexCacheKey struct {
@@ -17,6 +19,8 @@ type IndexCacheEntry struct {
 type IndexCache struct {
 	mu    sync.Mutex
 	items map[IndexCacheKey]*IndexCacheEntry
+	// ttl is how long entries are served. Zero serves them forever.
+	ttl time.Duration
 }
 
 func NewIndexCache() *IndexCache {
@@ -30,7 +34,25 @@ func (c *IndexCache) Get(key IndexCacheKey) (*IndexCacheEntry, bool) {
 	defer c.mu.Unlock()
 
 	entry, ok := c.items[key]
-	return entry, ok
+	if ok && c.ttl > 0 && time.Since(entry.FetchedAt) >= c.ttl {
+		delete(c.items, key)
+		ok = false
+	}
+	if !ok {
+		metrics.IndexCacheRequests.WithLabelValues(metrics.ResultMiss).Inc()
+		return nil, false
+	}
+	metrics.IndexCacheRequests.WithLabelValues(metrics.ResultHit).Inc()
+	return entry, true
+}
+
+// SetTTL changes how long entries are served, including the ones already
+// cached.
+func (c *IndexCache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
 }
 
 func (c *IndexCache) Set(key IndexCacheKey, entry *IndexCacheEntry) {
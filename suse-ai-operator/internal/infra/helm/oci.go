@@ -0,0 +1,164 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/registry"
+
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+// ResolvedChart is the chart a release spec currently resolves to.
+type ResolvedChart struct {
+	Version string
+	// Digest is the manifest digest of OCI charts, empty otherwise.
+	Digest string
+}
+
+// resolveChartVersion resolves the version of an OCI chart to a tag and
+// the manifest digest the tag points at, so a chart published under a
+// version range or a re-pushed tag changes the desired state hash. An
+// HTTP chart is identified by its URL and version as they are.
+func (c *helmClient) resolveChartVersion(ctx context.Context, spec ReleaseSpec) (ResolvedChart, error) {
+	if !registry.IsOCI(spec.ChartRef) {
+		return ResolvedChart{Version: spec.Version}, nil
+	}
+	if spec.Version == "" && spec.Digest != "" {
+		return ResolvedChart{Digest: spec.Digest}, nil
+	}
+
+	tag, err := c.resolveTag(strings.TrimPrefix(spec.ChartRef, registry.OCIScheme+"://"), spec.Version)
+	if err != nil {
+		return ResolvedChart{}, err
+	}
+
+	repo, err := c.remoteRepository(spec.ChartRef)
+	if err != nil {
+		return ResolvedChart{}, err
+	}
+	// Tags store "+" of chart versions as "_".
+	desc, err := repo.Resolve(ctx, strings.ReplaceAll(tag, "+", "_"))
+	if registryNotFound(err) {
+		return ResolvedChart{}, &ChartNotFoundError{Ref: spec.ChartRef, Version: tag, Err: err}
+	}
+	if err != nil {
+		return ResolvedChart{}, fmt.Errorf("failed to resolve chart %s:%s: %w", spec.ChartRef, tag, err)
+	}
+
+	return ResolvedChart{Version: tag, Digest: desc.Digest.String()}, nil
+}
+
+// locateOCIChart pulls a chart from an OCI registry and returns the path
+// of the archive and the manifest digest it was pulled at. Without a
+// version and with an expected digest the chart is pulled by digest.
+// Otherwise the version is resolved to a tag, and the digest of the tag
+// must match expected when set, so a re-pushed tag is never installed.
+// A cached archive is only used when its digest matches latest, the
+// digest the tag was resolved to, when set.
+func (c *helmClient) locateOCIChart(ref, version, expected, latest string) (string, string, error) {
+	repo := strings.TrimPrefix(ref, registry.OCIScheme+"://")
+
+	var pullRef string
+	var key ChartCacheKey
+	if version == "" && expected != "" {
+		pullRef = repo + "@" + expected
+		key = ChartCacheKey{Ref: ref, Version: expected}
+	} else {
+		tag, err := c.resolveTag(repo, version)
+		if err != nil {
+			return "", "", err
+		}
+		version = tag
+		pullRef = repo + ":" + tag
+		key = ChartCacheKey{Ref: ref, Version: tag}
+	}
+
+	if c.cache != nil {
+		entry, path, ok := c.cache.Get(key)
+		if ok && entry.ManifestDigest != "" && (expected == "" || entry.ManifestDigest == expected) &&
+			(latest == "" || entry.ManifestDigest == latest) {
+			return path, entry.ManifestDigest, nil
+		}
+	}
+
+	start := time.Now()
+	res, err := c.registry.Pull(pullRef)
+	var size int64
+	if err == nil {
+		size = int64(len(res.Chart.Data))
+	}
+	observePull(metrics.SourceOCI, start, size, err)
+	if registryNotFound(err) {
+		return "", "", &ChartNotFoundError{Ref: ref, Version: version, Err: err}
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to pull chart %s: %w", pullRef, err)
+	}
+
+	digest := res.Manifest.Digest
+	if expected != "" && digest != expected {
+		return "", "", &DigestMismatchError{Ref: ref, Version: version, Expected: expected, Actual: digest}
+	}
+
+	path, err := c.writeArchive(res.Chart.Data)
+	if err != nil {
+		return "", "", err
+	}
+
+	if c.cache != nil {
+		if _, cached, err := c.cache.Put(key, path, digest); err == nil {
+			path = cached
+		}
+	}
+
+	return path, digest, nil
+}
+
+// resolveTag returns the tag of repo matching version, which may be a
+// semver constraint. An empty version selects the highest tag.
+func (c *helmClient) resolveTag(repo, version string) (string, error) {
+	if Cacheable(version) {
+		return version, nil
+	}
+
+	tags, err := c.registry.Tags(repo)
+	if registryNotFound(err) {
+		return "", &ChartNotFoundError{Ref: repo, Version: version, Err: err}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to list tags of %s: %w", repo, err)
+	}
+	if len(tags) == 0 {
+		return "", &ChartNotFoundError{Ref: repo, Version: version, Err: errors.New("no tags found")}
+	}
+
+	tag, err := registry.GetTagMatchingVersionOrConstraint(tags, version)
+	if err != nil && strings.HasPrefix(err.Error(), "Could not locate a version matching") {
+		// Any other error is an invalid version constraint.
+		return "", &ChartNotFoundError{Ref: repo, Version: version, Err: err}
+	}
+	return tag, err
+}
+
+// writeArchive stores a pulled chart archive in Helm's repository cache,
+// named by its content so repeated pulls overwrite each other.
+func (c *helmClient) writeArchive(data []byte) (string, error) {
+	if err := os.MkdirAll(c.settings.RepositoryCache, 0o755); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	path := filepath.Join(c.settings.RepositoryCache, hex.EncodeToString(sum[:])+".tgz")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write chart archive: %w", err)
+	}
+	return path, nil
+}
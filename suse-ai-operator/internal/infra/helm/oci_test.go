@@ -0,0 +1,142 @@
+package helm
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+var _ = Describe("Locating charts", func() {
+	const zeroDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+	chartValue := func(path string) string {
+		ch, err := loader.Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ch.Templates).To(HaveLen(1))
+		return string(ch.Templates[0].Data)
+	}
+
+	Describe("locateOCIChart", func() {
+		var (
+			reg    *testRegistry
+			ref    string
+			pushed string
+		)
+
+		BeforeEach(func() {
+			reg = newTestRegistry()
+			ref = reg.ref("charts/ext")
+			pushed = reg.pushChart("charts/ext", "0.1.0", readArchive(newChartArchive("0.1.0", "first")), nil).String()
+		})
+
+		It("pulls a chart matching the pinned digest", func() {
+			path, digest, err := reg.client(nil).locateOCIChart(ref, "0.1.0", pushed, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(Equal(pushed))
+			Expect(chartValue(path)).To(ContainSubstring("first"))
+		})
+
+		It("pulls by digest without a version", func() {
+			path, digest, err := reg.client(nil).locateOCIChart(ref, "", pushed, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(Equal(pushed))
+			Expect(chartValue(path)).To(ContainSubstring("first"))
+		})
+
+		It("resolves a version range to the highest matching tag", func() {
+			reg.pushChart("charts/ext", "0.2.0", readArchive(newChartArchive("0.2.0", "second")), nil)
+
+			path, _, err := reg.client(nil).locateOCIChart(ref, "^0.1.0", "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chartValue(path)).To(ContainSubstring("first"))
+		})
+
+		It("refuses a chart not matching the pinned digest", func() {
+			_, _, err := reg.client(nil).locateChart(&action.ChartPathOptions{Version: "0.1.0"}, ref, zeroDigest, "")
+
+			var mismatch *DigestMismatchError
+			Expect(errors.As(err, &mismatch)).To(BeTrue())
+			Expect(mismatch.Ref).To(Equal(ref))
+			Expect(mismatch.Version).To(Equal("0.1.0"))
+			Expect(mismatch.Expected).To(Equal(zeroDigest))
+			Expect(mismatch.Actual).To(Equal(pushed))
+		})
+
+		It("reports a missing version as not found", func() {
+			_, _, err := reg.client(nil).locateOCIChart(ref, "0.3.0", "", "")
+			Expect(IsChartNotFound(err)).To(BeTrue())
+		})
+
+		It("pulls a moved tag again instead of using the cached archive", func() {
+			cache, err := NewChartCache(GinkgoT().TempDir(), 25)
+			Expect(err).NotTo(HaveOccurred())
+			c := reg.client(cache)
+
+			_, digest, err := c.locateOCIChart(ref, "0.1.0", "", pushed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(Equal(pushed))
+
+			moved := reg.pushChart("charts/ext", "0.1.0", readArchive(newChartArchive("0.1.0", "moved")), nil).String()
+			Expect(moved).NotTo(Equal(pushed))
+
+			By("using the cached archive while the tag was not resolved again")
+			path, digest, err := c.locateOCIChart(ref, "0.1.0", "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(Equal(pushed))
+			Expect(chartValue(path)).To(ContainSubstring("first"))
+
+			By("pulling the chart when the tag resolves to another digest")
+			path, digest, err = c.locateOCIChart(ref, "0.1.0", "", moved)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(Equal(moved))
+			Expect(chartValue(path)).To(ContainSubstring("moved"))
+		})
+	})
+
+	Describe("resolveChartVersion", func() {
+		It("resolves a tag to the digest it currently points at", func() {
+			reg := newTestRegistry()
+			c := reg.client(nil)
+			spec := ReleaseSpec{ChartRef: reg.ref("charts/ext"), Version: "0.1.0"}
+
+			first := reg.pushChart("charts/ext", "0.1.0", readArchive(newChartArchive("0.1.0", "first")), nil)
+			resolved, err := c.resolveChartVersion(context.Background(), spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved).To(Equal(ResolvedChart{Version: "0.1.0", Digest: first.String()}))
+
+			moved := reg.pushChart("charts/ext", "0.1.0", readArchive(newChartArchive("0.1.0", "moved")), nil)
+			resolved, err = c.resolveChartVersion(context.Background(), spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.Digest).To(Equal(moved.String()))
+		})
+	})
+
+	Describe("locateChart", func() {
+		It("returns the digest of a local archive matching the pinned digest", func() {
+			archive := newChartArchive("0.1.0", "local")
+			expected, _, err := fileDigest(archive)
+			Expect(err).NotTo(HaveOccurred())
+
+			path, digest, err := (&helmClient{}).locateChart(&action.ChartPathOptions{}, archive, expected, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(Equal(expected))
+			Expect(chartValue(path)).To(ContainSubstring("local"))
+		})
+
+		It("refuses a local archive not matching the pinned digest", func() {
+			archive := newChartArchive("0.1.0", "local")
+
+			_, _, err := (&helmClient{}).locateChart(&action.ChartPathOptions{Version: "0.1.0"}, archive, zeroDigest, "")
+
+			var mismatch *DigestMismatchError
+			Expect(errors.As(err, &mismatch)).To(BeTrue())
+			Expect(mismatch.Expected).To(Equal(zeroDigest))
+			Expect(mismatch.Actual).To(HavePrefix("sha256:"))
+			Expect(err).To(MatchError(ContainSubstring(":0.1.0 resolved to digest")))
+		})
+	})
+})
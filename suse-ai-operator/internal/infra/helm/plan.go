@@ -0,0 +1,121 @@
+package helm
+
+import (
+	"context"
+	"errors"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// ReleasePlan describes what EnsureRelease would do for a spec, computed
+// with dry runs only.
+type ReleasePlan struct {
+	// Action is ActionInstalled, ActionUpgraded or ActionUnchanged.
+	Action ReleaseAction
+	// Revision is the current revision, zero when not installed.
+	Revision            int
+	ChartVersion        string
+	CurrentChartVersion string
+	// ChartDigest is the digest of the chart that was rendered.
+	ChartDigest string
+	// Manifest is the rendered manifest that would be applied.
+	Manifest string
+	Diff     *ManifestDiff
+}
+
+// PlanRelease renders spec with a dry-run install or upgrade and diffs it
+// against the current release. Helm validates the chart's kubeVersion
+// constraint and ownership of existing resources during the dry run, so
+// an incompatible chart fails to plan. Nothing is written to the cluster.
+func (c *helmClient) PlanRelease(ctx context.Context, spec ReleaseSpec) (*ReleasePlan, error) {
+	log := logging.FromContext(ctx, "helm").WithValues(
+		logging.KeyName, spec.Name,
+		logging.KeyNamespace, spec.Namespace,
+		logging.KeyVersion, spec.Version,
+	)
+
+	unlock := c.lockRelease(spec.Name)
+	defer unlock()
+
+	cfg, err := c.actionConfig(ctx, spec.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := c.GetRelease(ctx, spec.Name)
+	if err != nil && !errors.Is(err, ErrReleaseNotFound) {
+		return nil, err
+	}
+
+	if info == nil || info.Status == StatusUninstalled {
+		log.Info("Planning Helm install")
+		rel, err := c.renderInstall(ctx, cfg, spec, info != nil)
+		if err != nil {
+			return nil, err
+		}
+		return newReleasePlan(ActionInstalled, rel, info, "")
+	}
+
+	log.Info("Planning Helm upgrade", "revision", info.Revision)
+
+	current, err := currentManifest(cfg, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := c.renderUpgrade(ctx, cfg, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return newReleasePlan(ActionUpgraded, rel, info, current)
+}
+
+func (c *helmClient) renderInstall(
+	ctx context.Context,
+	cfg *action.Configuration,
+	spec ReleaseSpec,
+	replace bool,
+) (*release.Release, error) {
+	install := action.NewInstall(cfg)
+	install.ReleaseName = spec.Name
+	install.Namespace = spec.Namespace
+	install.Version = spec.Version
+	install.SetRegistryClient(c.registry)
+	install.Replace = replace
+	applyInstallOptions(install, spec.Options)
+	install.DryRun = true
+
+	ch, _, err := c.resolveChart(ctx, &install.ChartPathOptions, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return install.RunWithContext(ctx, ch, spec.Values)
+}
+
+func newReleasePlan(act ReleaseAction, rel *release.Release, info *ReleaseInfo, current string) (*ReleasePlan, error) {
+	diff, err := DiffManifests(current, rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ReleasePlan{
+		Action:       act,
+		ChartVersion: rel.Chart.Metadata.Version,
+		ChartDigest:  chartDigest(rel.Chart),
+		Manifest:     rel.Manifest,
+		Diff:         diff,
+	}
+	if info != nil {
+		plan.Revision = info.Revision
+		plan.CurrentChartVersion = info.Version
+	}
+	if act == ActionUpgraded && diff.Empty() {
+		plan.Action = ActionUnchanged
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,174 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/storage/driver"
+
+	"github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// needsRecovery reports whether a release in status s cannot simply be
+// upgraded.
+func needsRecovery(s ReleaseStatus) bool {
+	switch s {
+	case StatusDeployed, StatusSuperseded:
+		return false
+	}
+	return true
+}
+
+// recoverRelease brings a release out of a pending, uninstalling, failed
+// or uninstalled state. It either returns a final result (the release was
+// reinstalled), or nil so the caller proceeds with a regular upgrade, plus
+// the steps it took.
+func (c *helmClient) recoverRelease(
+	ctx context.Context,
+	cfg *action.Configuration,
+	spec ReleaseSpec,
+	info *ReleaseInfo,
+) (*ReleaseResult, []string, error) {
+	log := logging.FromContext(ctx, "helm").WithValues(
+		logging.KeyName, spec.Name,
+		logging.KeyNamespace, spec.Namespace,
+		"status", info.Status,
+		"revision", info.Revision,
+	)
+
+	deployed, err := cfg.Releases.Deployed(spec.Name)
+	if err != nil && !errors.Is(err, driver.ErrNoDeployedReleases) && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, nil, err
+	}
+	hasDeployed := deployed != nil
+
+	switch {
+	case info.Status.IsLocked():
+		age := time.Since(info.LastDeployed)
+		if age < staleLockTimeout(spec) {
+			return nil, nil, &ReleaseLockedError{Name: spec.Name, Status: info.Status, Age: age}
+		}
+		log.Info("Release lock is stale, recovering", "age", age.Round(time.Second))
+
+		if info.Status != StatusUninstalling && hasDeployed && spec.Recovery.Strategy != RecoveryReinstall {
+			step, err := c.rollbackTo(ctx, cfg, spec, deployed.Version)
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, []string{
+				fmt.Sprintf("release was %s for %s", info.Status, age.Round(time.Second)),
+				step,
+			}, nil
+		}
+
+		return c.reinstall(ctx, cfg, spec, fmt.Sprintf("release was %s for %s", info.Status, age.Round(time.Second)))
+
+	case info.Status == StatusFailed && hasDeployed && spec.Recovery.Strategy != RecoveryReinstall:
+		// Helm upgrades a failed release on top of its last deployed revision.
+		logging.Debug(log).Info("Latest revision failed, upgrading from last deployed revision",
+			"deployedRevision", deployed.Version)
+		return nil, []string{
+			fmt.Sprintf("revision %d failed, upgrading from deployed revision %d", info.Revision, deployed.Version),
+		}, nil
+
+	case info.Status == StatusFailed:
+		return c.reinstall(ctx, cfg, spec, fmt.Sprintf("revision %d failed with no deployed revision", info.Revision))
+
+	case info.Status == StatusUninstalled:
+		log.Info("Release was uninstalled with history kept, installing again")
+		rel, err := c.install(ctx, cfg, spec, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		result := releaseResult(ActionInstalled, rel, "")
+		result.Recovery = []string{"release was uninstalled, installed it again"}
+		return result, result.Recovery, nil
+
+	default:
+		return nil, nil, fmt.Errorf("release %q is in unexpected status %q", spec.Name, info.Status)
+	}
+}
+
+func (c *helmClient) rollbackTo(
+	ctx context.Context,
+	cfg *action.Configuration,
+	spec ReleaseSpec,
+	revision int,
+) (string, error) {
+	log := logging.FromContext(ctx, "helm").WithValues(
+		logging.KeyName, spec.Name,
+		logging.KeyNamespace, spec.Namespace,
+	)
+
+	log.Info("Rolling back release", "revision", revision)
+
+	rb := action.NewRollback(cfg)
+	rb.Version = revision
+	rb.Timeout = spec.Options.Timeout
+	rb.Wait = spec.Options.Wait || spec.Options.Atomic
+	rb.WaitForJobs = spec.Options.WaitForJobs
+	rb.DisableHooks = spec.Options.DisableHooks
+	rb.Force = spec.Options.Force
+	rb.CleanupOnFail = spec.Options.CleanupOnFail
+	rb.MaxHistory = spec.Options.MaxHistory
+
+	if err := rb.Run(spec.Name); err != nil {
+		log.Error(err, "Helm rollback failed")
+		return "", fmt.Errorf("rollback to revision %d failed: %w", revision, err)
+	}
+
+	return fmt.Sprintf("rolled back to deployed revision %d", revision), nil
+}
+
+func (c *helmClient) reinstall(
+	ctx context.Context,
+	cfg *action.Configuration,
+	spec ReleaseSpec,
+	cause string,
+) (*ReleaseResult, []string, error) {
+	log := logging.FromContext(ctx, "helm").WithValues(
+		logging.KeyName, spec.Name,
+		logging.KeyNamespace, spec.Namespace,
+	)
+
+	log.Info("Reinstalling release", "cause", cause)
+
+	uninstall := action.NewUninstall(cfg)
+	uninstall.DeletionPropagation = "foreground"
+	uninstall.Wait = true
+	uninstall.Timeout = spec.Options.Timeout
+	uninstall.DisableHooks = spec.Options.DisableHooks
+	uninstall.IgnoreNotFound = true
+
+	if _, err := uninstall.Run(spec.Name); err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		log.Error(err, "Failed to uninstall release for reinstall")
+		return nil, nil, fmt.Errorf("uninstall before reinstall failed: %w", err)
+	}
+
+	steps := []string{cause, "uninstalled release"}
+
+	rel, err := c.install(ctx, cfg, spec, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := releaseResult(ActionReinstalled, rel, "")
+	result.Recovery = append(steps, fmt.Sprintf("installed revision %d", rel.Version))
+	return result, result.Recovery, nil
+}
+
+// staleLockTimeout never declares a lock stale before the operation that
+// holds it could have timed out on its own.
+func staleLockTimeout(spec ReleaseSpec) time.Duration {
+	timeout := spec.Recovery.StaleLockTimeout
+	if timeout <= 0 {
+		timeout = DefaultStaleLockTimeout
+	}
+	if floor := spec.Options.Timeout + time.Minute; timeout < floor {
+		timeout = floor
+	}
+	return timeout
+}
@@ -0,0 +1,89 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/release"
+)
+
+var _ = Describe("Release recovery", func() {
+	DescribeTable("needsRecovery",
+		func(status ReleaseStatus, expected bool) {
+			Expect(needsRecovery(status)).To(Equal(expected))
+		},
+		Entry("deployed", StatusDeployed, false),
+		Entry("superseded", StatusSuperseded, false),
+		Entry("failed", StatusFailed, true),
+		Entry("uninstalled", StatusUninstalled, true),
+		Entry("uninstalling", StatusUninstalling, true),
+		Entry("pending install", StatusPendingInstall, true),
+		Entry("pending upgrade", StatusPendingUpgrade, true),
+		Entry("pending rollback", StatusPendingRollback, true),
+		Entry("unknown", StatusUnknown, true),
+	)
+
+	DescribeTable("staleLockTimeout",
+		func(lockTimeout, timeout, expected time.Duration) {
+			spec := ReleaseSpec{Recovery: RecoveryPolicy{StaleLockTimeout: lockTimeout}}
+			spec.Options.Timeout = timeout
+			Expect(staleLockTimeout(spec)).To(Equal(expected))
+		},
+		Entry("defaults when unset", time.Duration(0), 5*time.Minute, DefaultStaleLockTimeout),
+		Entry("uses the policy timeout", 30*time.Minute, 5*time.Minute, 30*time.Minute),
+		Entry("outlasts the operation timeout", 5*time.Minute, 10*time.Minute, 11*time.Minute),
+	)
+
+	DescribeTable("recoverRelease",
+		func(
+			status ReleaseStatus,
+			age time.Duration,
+			strategy RecoveryStrategy,
+			expectedSteps []string,
+			statuses ...release.Status,
+		) {
+			cfg := newTestConfiguration(statuses...)
+			spec := ReleaseSpec{
+				Name:      "ext",
+				Namespace: "default",
+				Recovery:  RecoveryPolicy{StaleLockTimeout: 15 * time.Minute, Strategy: strategy},
+			}
+			info := &ReleaseInfo{
+				Status:       status,
+				Revision:     len(statuses),
+				LastDeployed: time.Now().Add(-age),
+			}
+
+			result, steps, err := (&helmClient{}).recoverRelease(context.Background(), cfg, spec, info)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+			Expect(steps).To(HaveLen(len(expectedSteps)))
+			for i, step := range expectedSteps {
+				Expect(steps[i]).To(MatchRegexp(step))
+			}
+		},
+		Entry("upgrades a failed release from the deployed revision",
+			StatusFailed, time.Hour, RecoveryRollback,
+			[]string{"^revision 2 failed, upgrading from deployed revision 1$"},
+			release.StatusDeployed, release.StatusFailed),
+		Entry("rolls back a stale pending upgrade",
+			StatusPendingUpgrade, time.Hour, RecoveryRollback,
+			[]string{"^release was pending-upgrade for 1h0m0s$", "^rolled back to deployed revision 1$"},
+			release.StatusDeployed, release.StatusPendingUpgrade),
+	)
+
+	It("refuses to recover a release locked by a running operation", func() {
+		cfg := newTestConfiguration(release.StatusDeployed, release.StatusPendingUpgrade)
+		spec := ReleaseSpec{Name: "ext", Recovery: RecoveryPolicy{StaleLockTimeout: 15 * time.Minute}}
+		info := &ReleaseInfo{Status: StatusPendingUpgrade, Revision: 2, LastDeployed: time.Now().Add(-time.Minute)}
+
+		_, _, err := (&helmClient{}).recoverRelease(context.Background(), cfg, spec, info)
+
+		var locked *ReleaseLockedError
+		Expect(errors.As(err, &locked)).To(BeTrue())
+		Expect(locked.Status).To(Equal(StatusPendingUpgrade))
+	})
+})
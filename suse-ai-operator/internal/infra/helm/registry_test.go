@@ -0,0 +1,207 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
+	"sigs.k8s.io/yaml"
+)
+
+// testRegistry is an in-memory OCI registry serving the read side of the
+// distribution API over TLS: manifests, blobs and tag lists.
+type testRegistry struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	blobs     map[digest.Digest][]byte
+	manifests map[string][]byte
+	tags      map[string][]string
+}
+
+func newTestRegistry() *testRegistry {
+	r := &testRegistry{
+		blobs:     map[digest.Digest][]byte{},
+		manifests: map[string][]byte{},
+		tags:      map[string][]string{},
+	}
+	r.server = httptest.NewTLSServer(http.HandlerFunc(r.serve))
+	DeferCleanup(r.server.Close)
+	return r
+}
+
+// ref returns the oci:// reference of repo in the registry.
+func (r *testRegistry) ref(repo string) string {
+	return fmt.Sprintf("%s://%s/%s", registry.OCIScheme, r.server.Listener.Addr(), repo)
+}
+
+// client returns a Helm client trusting the registry certificate, with
+// its caches in a temporary directory.
+func (r *testRegistry) client(cache *ChartCache) *helmClient {
+	dir := GinkgoT().TempDir()
+	settings := cli.New()
+	settings.RepositoryCache = filepath.Join(dir, "repository")
+	settings.RegistryConfig = filepath.Join(dir, "registry.json")
+
+	transport := r.server.Client().Transport.(*http.Transport).Clone()
+	c, err := New(settings, cache, WithTransport(transport))
+	Expect(err).NotTo(HaveOccurred())
+	return c.(*helmClient)
+}
+
+func (r *testRegistry) pushBlob(data []byte) digest.Digest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := digest.FromBytes(data)
+	r.blobs[d] = data
+	return d
+}
+
+// pushManifest stores manifest in repo under tag, when set, and returns
+// its digest.
+func (r *testRegistry) pushManifest(repo, tag string, manifest ocispec.Manifest) digest.Digest {
+	manifest.Versioned = specs.Versioned{SchemaVersion: 2}
+	manifest.MediaType = ocispec.MediaTypeImageManifest
+	data, err := json.Marshal(manifest)
+	Expect(err).NotTo(HaveOccurred())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := digest.FromBytes(data)
+	r.manifests[repo+"@"+d.String()] = data
+	if tag != "" {
+		if _, ok := r.manifests[repo+":"+tag]; !ok {
+			r.tags[repo] = append(r.tags[repo], tag)
+		}
+		r.manifests[repo+":"+tag] = data
+	}
+	return d
+}
+
+// pushChart stores archive as chart version tag of repo, with a
+// provenance layer when prov is set, and returns the manifest digest.
+func (r *testRegistry) pushChart(repo, tag string, archive, prov []byte) digest.Digest {
+	config, err := json.Marshal(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "ext", Version: tag})
+	Expect(err).NotTo(HaveOccurred())
+
+	layers := []ocispec.Descriptor{r.descriptor(registry.ChartLayerMediaType, archive)}
+	if prov != nil {
+		layers = append(layers, r.descriptor(registry.ProvLayerMediaType, prov))
+	}
+	return r.pushManifest(repo, tag, ocispec.Manifest{
+		Config: r.descriptor(registry.ConfigMediaType, config),
+		Layers: layers,
+	})
+}
+
+func (r *testRegistry) descriptor(mediaType string, data []byte) ocispec.Descriptor {
+	return ocispec.Descriptor{MediaType: mediaType, Digest: r.pushBlob(data), Size: int64(len(data))}
+}
+
+func (r *testRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case req.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+
+	case strings.HasSuffix(path, "/tags/list"):
+		repo := strings.TrimSuffix(path, "/tags/list")
+		writeRegistryResponse(w, req, "application/json", must(json.Marshal(map[string]any{
+			"name": repo,
+			"tags": r.tags[repo],
+		})))
+
+	case strings.Contains(path, "/manifests/"):
+		repo, ref, _ := strings.Cut(path, "/manifests/")
+		sep := ":"
+		if strings.Contains(ref, ":") {
+			sep = "@"
+		}
+		data, ok := r.manifests[repo+sep+ref]
+		if !ok {
+			writeRegistryError(w, "MANIFEST_UNKNOWN")
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(data).String())
+		writeRegistryResponse(w, req, ocispec.MediaTypeImageManifest, data)
+
+	case strings.Contains(path, "/blobs/"):
+		_, ref, _ := strings.Cut(path, "/blobs/")
+		data, ok := r.blobs[digest.Digest(ref)]
+		if !ok {
+			writeRegistryError(w, "BLOB_UNKNOWN")
+			return
+		}
+		writeRegistryResponse(w, req, "application/octet-stream", data)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeRegistryResponse(w http.ResponseWriter, req *http.Request, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+func writeRegistryError(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = fmt.Fprintf(w, `{"errors":[{"code":%q,"message":"not found"}]}`, code)
+}
+
+func must(data []byte, err error) []byte {
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
+
+// newChartArchive writes a chart archive of version with a ConfigMap
+// template holding data to a temporary directory and returns its path.
+func newChartArchive(version, data string) string {
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "ext", Version: version},
+		Templates: []*chart.File{{
+			Name: "templates/configmap.yaml",
+			Data: must(yaml.Marshal(map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": "ext"},
+				"data":       map[string]any{"value": data},
+			})),
+		}},
+	}
+
+	path, err := chartutil.Save(ch, GinkgoT().TempDir())
+	Expect(err).NotTo(HaveOccurred())
+	return path
+}
+
+func readArchive(path string) []byte {
+	data, err := os.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
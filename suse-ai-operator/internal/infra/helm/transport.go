@@ -0,0 +1,107 @@
+package helm
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	"github.com/SUSE/suse-ai-operator/internal/metrics"
+)
+
+// Option configures a Helm client.
+type Option func(*helmClient)
+
+// WithTransport downloads charts, provenance files and signatures through
+// transport instead of Helm's default, which reads the proxy environment
+// once per process.
+func WithTransport(transport *http.Transport) Option {
+	return func(c *helmClient) {
+		c.transport = transport
+	}
+}
+
+// getters returns the Helm getters, with HTTP downloads going through the
+// client transport when set.
+func (c *helmClient) getters() getter.Providers {
+	providers := getter.All(c.settings)
+	if c.transport == nil {
+		return providers
+	}
+
+	for i, p := range providers {
+		if !p.Provides("https") {
+			continue
+		}
+		providers[i] = getter.Provider{
+			Schemes: p.Schemes,
+			New: func(options ...getter.Option) (getter.Getter, error) {
+				return getter.NewHTTPGetter(append(options, getter.WithTransport(c.transport))...)
+			},
+		}
+	}
+	return providers
+}
+
+// httpClient returns the client used for registry requests.
+func (c *helmClient) httpClient() *http.Client {
+	if c.transport == nil {
+		return retry.DefaultClient
+	}
+	return &http.Client{Transport: retry.NewTransport(c.transport)}
+}
+
+// downloadArchive downloads the chart archive at ref into the repository
+// cache, like ChartPathOptions.LocateChart but with the client getters.
+func (c *helmClient) downloadArchive(opts *action.ChartPathOptions, ref string) (path string, err error) {
+	start := time.Now()
+	defer func() {
+		var size int64
+		if err == nil {
+			if info, statErr := os.Stat(path); statErr == nil {
+				size = info.Size()
+			}
+		}
+		observePull(metrics.SourceHTTP, start, size, err)
+	}()
+
+	if c.transport == nil {
+		return opts.LocateChart(ref, c.settings)
+	}
+
+	dl := downloader.ChartDownloader{
+		Out:     io.Discard,
+		Getters: c.getters(),
+		Options: []getter.Option{
+			getter.WithPassCredentialsAll(opts.PassCredentialsAll),
+			getter.WithBasicAuth(opts.Username, opts.Password),
+		},
+		RepositoryConfig: c.settings.RepositoryConfig,
+		RepositoryCache:  c.settings.RepositoryCache,
+		RegistryClient:   c.registry,
+	}
+
+	if err := os.MkdirAll(c.settings.RepositoryCache, 0o755); err != nil {
+		return "", err
+	}
+
+	filename, _, err := dl.DownloadTo(ref, opts.Version, c.settings.RepositoryCache)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(filename)
+}
+
+// observePull records the duration and size of a chart download.
+func observePull(source string, start time.Time, size int64, err error) {
+	metrics.ChartPullDuration.WithLabelValues(source, metrics.Result(err)).Observe(time.Since(start).Seconds())
+	if err == nil {
+		metrics.ChartPullBytes.WithLabelValues(source).Add(float64(size))
+	}
+}
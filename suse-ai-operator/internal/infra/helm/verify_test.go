@@ -0,0 +1,135 @@
+package helm
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/openpgp"       // nolint:staticcheck
+	"golang.org/x/crypto/openpgp/armor" // nolint:staticcheck
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+// newSigner returns an OpenPGP entity with a private key and its public
+// keyring, armored when armored is set.
+func newSigner(armored bool) (*openpgp.Entity, []byte) {
+	entity, err := openpgp.NewEntity("Chart Signer", "", "signer@example.com", nil)
+	Expect(err).NotTo(HaveOccurred())
+
+	var keyring bytes.Buffer
+	if !armored {
+		Expect(entity.Serialize(&keyring)).To(Succeed())
+		return entity, keyring.Bytes()
+	}
+
+	w, err := armor.Encode(&keyring, openpgp.PublicKeyType, nil)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(entity.Serialize(w)).To(Succeed())
+	Expect(w.Close()).To(Succeed())
+	return entity, keyring.Bytes()
+}
+
+// signChart returns the provenance file of the archive at path signed by
+// entity.
+func signChart(entity *openpgp.Entity, path string) []byte {
+	prov, err := (&provenance.Signatory{Entity: entity}).ClearSign(path)
+	Expect(err).NotTo(HaveOccurred())
+	return []byte(prov)
+}
+
+var _ = Describe("Provenance verification", func() {
+	DescribeTable("readKeyRing",
+		func(armored bool) {
+			entity, keyring := newSigner(armored)
+
+			ring, err := readKeyRing(keyring)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ring).To(HaveLen(1))
+			Expect(ring[0].PrimaryKey.KeyId).To(Equal(entity.PrimaryKey.KeyId))
+			Expect(entityName(ring[0])).To(Equal(
+				"Chart Signer <signer@example.com> (" + entity.PrimaryKey.KeyIdString() + ")"))
+		},
+		Entry("reads an armored keyring", true),
+		Entry("reads a binary keyring", false),
+	)
+
+	DescribeTable("readKeyRing refuses",
+		func(data []byte) {
+			_, err := readKeyRing(data)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("a broken armored keyring", []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nbroken\n")),
+		Entry("data that is not a keyring", []byte("not a keyring")),
+	)
+
+	Describe("verifyProvenance", func() {
+		var (
+			reg     *testRegistry
+			ref     string
+			entity  *openpgp.Entity
+			keyring []byte
+			archive string
+			ch      *chart.Chart
+		)
+
+		BeforeEach(func() {
+			reg = newTestRegistry()
+			ref = reg.ref("charts/ext")
+			entity, keyring = newSigner(true)
+			archive = newChartArchive("0.1.0", "signed")
+
+			var err error
+			ch, err = loader.Load(archive)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the identity that signed a valid provenance file", func() {
+			pushed := reg.pushChart("charts/ext", "0.1.0", readArchive(archive), signChart(entity, archive))
+
+			signer, err := reg.client(nil).verifyProvenance(ref, archive, pushed.String(), ch, keyring)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(signer).To(Equal(entityName(entity)))
+		})
+
+		It("refuses a tampered provenance file", func() {
+			prov := strings.Replace(string(signChart(entity, archive)), "name: ext", "name: evil", 1)
+			pushed := reg.pushChart("charts/ext", "0.1.0", readArchive(archive), []byte(prov))
+
+			_, err := reg.client(nil).verifyProvenance(ref, archive, pushed.String(), ch, keyring)
+			Expect(err).To(MatchError(ContainSubstring("invalid signature")))
+		})
+
+		It("refuses a provenance file of another archive", func() {
+			other := newChartArchive("0.1.0", "other")
+			pushed := reg.pushChart("charts/ext", "0.1.0", readArchive(archive), signChart(entity, other))
+
+			_, err := reg.client(nil).verifyProvenance(ref, archive, pushed.String(), ch, keyring)
+			Expect(err).To(MatchError(ContainSubstring("sha256 sum does not match")))
+		})
+
+		It("refuses a provenance file signed by another key", func() {
+			other, _ := newSigner(true)
+			pushed := reg.pushChart("charts/ext", "0.1.0", readArchive(archive), signChart(other, archive))
+
+			_, err := reg.client(nil).verifyProvenance(ref, archive, pushed.String(), ch, keyring)
+			Expect(err).To(MatchError(ContainSubstring("signature made by unknown entity")))
+		})
+
+		It("refuses a chart without a provenance file", func() {
+			pushed := reg.pushChart("charts/ext", "0.1.0", readArchive(archive), nil)
+
+			_, err := reg.client(nil).verifyProvenance(ref, archive, pushed.String(), ch, keyring)
+			Expect(err).To(MatchError(ContainSubstring("failed to pull provenance")))
+		})
+
+		It("refuses an invalid keyring", func() {
+			pushed := reg.pushChart("charts/ext", "0.1.0", readArchive(archive), signChart(entity, archive))
+
+			_, err := reg.client(nil).verifyProvenance(ref, archive, pushed.String(), ch, []byte("not a keyring"))
+			Expect(err).To(MatchError(ContainSubstring("invalid keyring")))
+		})
+	})
+})
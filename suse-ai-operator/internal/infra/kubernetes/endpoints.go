@@ -0,0 +1,40 @@
+package kubernetes
+
+import (
+	"context"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReadyEndpoints counts the ready endpoints across all EndpointSlices
+// backing the given Service.
+func ReadyEndpoints(
+	ctx context.Context,
+	c client.Client,
+	namespace, serviceName string,
+) (int, error) {
+
+	var list discoveryv1.EndpointSliceList
+	if err := c.List(
+		ctx,
+		&list,
+		client.InNamespace(namespace),
+		client.MatchingLabels{
+			discoveryv1.LabelServiceName: serviceName,
+		},
+	); err != nil {
+		return 0, err
+	}
+
+	ready := 0
+	for _, slice := range list.Items {
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+				ready++
+			}
+		}
+	}
+
+	return ready, nil
+}
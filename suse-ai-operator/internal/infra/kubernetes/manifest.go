@@ -0,0 +1,35 @@
+package kubernetes
+
+import (
+	"sort"
+
+	"helm.sh/helm/v3/pkg/releaseutil"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ServicesFromManifest returns the Services declared in a rendered Helm
+// manifest, sorted by name. Services without a namespace get namespace.
+func ServicesFromManifest(manifest, namespace string) ([]corev1.Service, error) {
+	var items []corev1.Service
+
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		var svc corev1.Service
+		if err := yaml.Unmarshal([]byte(doc), &svc); err != nil {
+			return nil, err
+		}
+		if svc.Kind != "Service" || svc.APIVersion != "v1" {
+			continue
+		}
+		if svc.Namespace == "" {
+			svc.Namespace = namespace
+		}
+		items = append(items, svc)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	return items, nil
+}
@@ -3,32 +3,94 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func ServiceForHelmRelease(
+const LabelInstance = "app.kubernetes.io/instance"
+
+type ServiceNotFoundError struct {
+	Namespace string
+	Selector  string
+}
+
+func (e *ServiceNotFoundError) Error() string {
+	return fmt.Sprintf("no service matching %s found in namespace %q", e.Selector, e.Namespace)
+}
+
+func ServiceByName(
 	ctx context.Context,
 	c client.Client,
-	namespace, releaseName string,
+	namespace, name string,
 ) (*corev1.Service, error) {
 
+	var svc corev1.Service
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &svc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, &ServiceNotFoundError{
+				Namespace: namespace,
+				Selector:  fmt.Sprintf("name %q", name),
+			}
+		}
+		return nil, err
+	}
+
+	return &svc, nil
+}
+
+// ServicesBySelector returns the Services matching selector, sorted by name
+// so callers picking the first one get a deterministic result.
+func ServicesBySelector(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	selector labels.Selector,
+) ([]corev1.Service, error) {
+
 	var list corev1.ServiceList
 	if err := c.List(
 		ctx,
 		&list,
 		client.InNamespace(namespace),
-		client.MatchingLabels{
-			"app.kubernetes.io/instance": releaseName,
-		},
+		client.MatchingLabelsSelector{Selector: selector},
 	); err != nil {
 		return nil, err
 	}
 
 	if len(list.Items) == 0 {
-		return nil, fmt.Errorf("no service found for release %q", releaseName)
+		return nil, &ServiceNotFoundError{
+			Namespace: namespace,
+			Selector:  fmt.Sprintf("selector %q", selector.String()),
+		}
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+
+	return list.Items, nil
+}
+
+func ServiceForHelmRelease(
+	ctx context.Context,
+	c client.Client,
+	namespace, releaseName string,
+) (*corev1.Service, error) {
+
+	items, err := ServicesBySelector(
+		ctx,
+		c,
+		namespace,
+		labels.SelectorFromSet(labels.Set{LabelInstance: releaseName}),
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	return &list.Items[0], nil
+	return &items[0], nil
 }
@@ -0,0 +1,99 @@
+package pluginserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SUSE/suse-ai-operator/internal/infra/certs"
+)
+
+const DefaultProbeTimeout = 5 * time.Second
+
+type Prober struct {
+	client *http.Client
+}
+
+func NewProber(client *http.Client) *Prober {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultProbeTimeout}
+	}
+	return &Prober{client: client}
+}
+
+type ProbeError struct {
+	URL    string
+	Status int
+	Err    error
+}
+
+func (e *ProbeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("probe %s failed: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("probe %s returned HTTP %d", e.URL, e.Status)
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// Probe issues a GET against url and returns the request latency.
+// Any response below 400 counts as serving. A non-empty caBundle is
+// trusted in addition to the system roots.
+func (p *Prober) Probe(ctx context.Context, url string, caBundle []byte) (time.Duration, error) {
+	httpClient := p.client
+	if len(caBundle) > 0 {
+		var err error
+		httpClient, err = certs.HTTPClient(caBundle, p.client.Timeout)
+		if err != nil {
+			return 0, &ProbeError{URL: url, Err: err}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, &ProbeError{URL: url, Err: err}
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return latency, &ProbeError{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return latency, &ProbeError{URL: url, Status: resp.StatusCode}
+	}
+
+	return latency, nil
+}
+
+// ProbeExtension checks that the server exposes both the plugin assets
+// and the Helm repository index Rancher reads metadata from.
+func (p *Prober) ProbeExtension(
+	ctx context.Context,
+	pluginEndpoint string,
+	svcURL string,
+	caBundle []byte,
+) (time.Duration, error) {
+
+	var total time.Duration
+	for _, url := range []string{
+		pluginEndpoint + "/",
+		svcURL + "/index.yaml",
+	} {
+		latency, err := p.Probe(ctx, url, caBundle)
+		total += latency
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
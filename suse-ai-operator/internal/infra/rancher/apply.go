@@ -0,0 +1,122 @@
+package rancher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	logging "github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// FieldOwner is the field manager the operator applies Rancher objects
+// with. It owns only the fields the operator sets; status and fields
+// defaulted or written by Rancher stay with their managers.
+const FieldOwner = "suse-ai-operator"
+
+// legacyFieldManager is the manager of fields written with
+// CreateOrUpdate by earlier operator versions: the binary name.
+const legacyFieldManager = "manager"
+
+// apply server-side applies obj, which holds only the fields the operator
+// owns, and reports whether it was created, updated or left unchanged.
+// Fields another manager owns with a different value are not overwritten;
+// a *FieldConflictError is returned instead.
+func (m *Manager) apply(ctx context.Context, obj *unstructured.Unstructured) (Action, error) {
+	log := logging.FromContext(ctx, "rancher.apply").
+		WithValues(
+			"kind", obj.GetKind(),
+			logging.KeyName, obj.GetName(),
+		)
+
+	resourceVersion, err := m.upgradeManagedFields(ctx, obj)
+	if err != nil {
+		return "", err
+	}
+
+	logging.Trace(log).Info("Applying object", "fieldManager", FieldOwner)
+
+	err = m.client.Apply(ctx, client.ApplyConfigurationFromUnstructured(obj), client.FieldOwner(FieldOwner))
+	if err == nil {
+		switch {
+		case resourceVersion == "":
+			return ActionCreated, nil
+		case obj.GetResourceVersion() != resourceVersion:
+			return ActionUpdated, nil
+		}
+		return ActionUnchanged, nil
+	}
+
+	if conflict := fieldConflict(obj, err); conflict != nil {
+		log.Info("Fields are owned by another manager, not overwriting", "conflicts", conflict.Conflicts)
+		return "", conflict
+	}
+	return "", err
+}
+
+// upgradeManagedFields hands the fields written by earlier operator
+// versions with CreateOrUpdate over to FieldOwner, so the first apply
+// after an upgrade does not conflict with the operator's own writes. It
+// returns the resource version of the existing object, empty when there
+// is none.
+func (m *Manager) upgradeManagedFields(ctx context.Context, obj *unstructured.Unstructured) (string, error) {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+
+	err := m.client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(existing, sets.New(legacyFieldManager), FieldOwner)
+	if err != nil || patch == nil {
+		return existing.GetResourceVersion(), err
+	}
+
+	logging.Debug(logging.FromContext(ctx, "rancher.apply")).Info(
+		"Moving fields written by CreateOrUpdate to the apply field manager",
+		"kind", obj.GetKind(),
+		logging.KeyName, obj.GetName(),
+	)
+	if err := m.client.Patch(ctx, existing, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return "", err
+	}
+	return existing.GetResourceVersion(), nil
+}
+
+// fieldConflict returns the conflicts err reports for obj, or nil when
+// err is not an apply conflict.
+func fieldConflict(obj *unstructured.Unstructured, err error) *FieldConflictError {
+	var status apierrors.APIStatus
+	if !apierrors.IsConflict(err) || !errors.As(err, &status) {
+		return nil
+	}
+
+	conflict := &FieldConflictError{
+		Kind:      obj.GetKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+	}
+	if details := status.Status().Details; details != nil {
+		for _, cause := range details.Causes {
+			if cause.Type != metav1.CauseTypeFieldManagerConflict {
+				continue
+			}
+			conflict.Conflicts = append(conflict.Conflicts, fmt.Sprintf("%s (%s)", cause.Field, cause.Message))
+		}
+	}
+	if len(conflict.Conflicts) == 0 {
+		return nil
+	}
+	return conflict
+}
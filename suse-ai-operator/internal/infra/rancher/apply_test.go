@@ -0,0 +1,166 @@
+package rancher
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newTestClusterRepo returns a ClusterRepo pointing at url.
+func newTestClusterRepo(url string) *unstructured.Unstructured {
+	repo := &unstructured.Unstructured{}
+	repo.SetAPIVersion("catalog.cattle.io/v1")
+	repo.SetKind("ClusterRepo")
+	repo.SetName("ext")
+	Expect(unstructured.SetNestedField(repo.Object, url, "spec", "url")).To(Succeed())
+	return repo
+}
+
+var _ = Describe("Server-side apply", func() {
+	Describe("fieldConflict", func() {
+		obj := newTestClusterRepo("https://ext.default.svc")
+		gr := schema.GroupResource{Group: "catalog.cattle.io", Resource: "clusterrepos"}
+
+		conflictErr := func(causes ...metav1.StatusCause) error {
+			return apierrors.NewApplyConflict(causes, "Apply failed")
+		}
+
+		It("lists the fields owned by other managers", func() {
+			err := conflictErr(
+				metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Field:   ".spec.url",
+					Message: `conflict with "rancher"`,
+				},
+				metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Field:   ".spec.caBundle",
+					Message: `conflict with "kubectl-edit" using catalog.cattle.io/v1`,
+				},
+			)
+
+			conflict := fieldConflict(obj, err)
+			Expect(conflict).NotTo(BeNil())
+			Expect(conflict.Kind).To(Equal("ClusterRepo"))
+			Expect(conflict.Name).To(Equal("ext"))
+			Expect(conflict.Conflicts).To(Equal([]string{
+				`.spec.url (conflict with "rancher")`,
+				`.spec.caBundle (conflict with "kubectl-edit" using catalog.cattle.io/v1)`,
+			}))
+			Expect(conflict.Error()).To(Equal(`ClusterRepo ext has fields owned by another manager: ` +
+				`.spec.url (conflict with "rancher"); .spec.caBundle (conflict with "kubectl-edit" using catalog.cattle.io/v1)`))
+		})
+
+		It("finds the conflict in a wrapped error", func() {
+			err := conflictErr(metav1.StatusCause{
+				Type: metav1.CauseTypeFieldManagerConflict, Field: ".spec.url", Message: `conflict with "rancher"`,
+			})
+
+			Expect(fieldConflict(obj, errors.Join(errors.New("apply failed"), err))).NotTo(BeNil())
+		})
+
+		DescribeTable("returns nil without field manager conflicts",
+			func(err error) {
+				Expect(fieldConflict(obj, err)).To(BeNil())
+			},
+			Entry("for no error", nil),
+			Entry("for another error", errors.New("connection refused")),
+			Entry("for a not found error", apierrors.NewNotFound(gr, "ext")),
+			Entry("for a resource version conflict", apierrors.NewConflict(gr, "ext", errors.New("object was modified"))),
+			Entry("for a conflict with other causes", conflictErr(metav1.StatusCause{
+				Type: metav1.CauseTypeFieldValueInvalid, Field: ".spec.url",
+			})),
+		)
+	})
+
+	Describe("upgradeManagedFields", func() {
+		var (
+			ctx context.Context
+			c   client.Client
+			mgr *Manager
+		)
+
+		setUp := func(objs ...client.Object) {
+			scheme := runtime.NewScheme()
+			c = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objs...).
+				WithReturnManagedFields().
+				Build()
+			mgr = NewManager(c, scheme, nil)
+		}
+
+		managers := func() map[string]metav1.ManagedFieldsOperationType {
+			existing := newTestClusterRepo("")
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), existing)).To(Succeed())
+
+			ops := map[string]metav1.ManagedFieldsOperationType{}
+			for _, entry := range existing.GetManagedFields() {
+				ops[entry.Manager] = entry.Operation
+			}
+			return ops
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+		})
+
+		It("reports a missing object", func() {
+			setUp()
+
+			resourceVersion, err := mgr.upgradeManagedFields(ctx, newTestClusterRepo("https://ext.default.svc"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resourceVersion).To(BeEmpty())
+		})
+
+		It("moves the fields written by CreateOrUpdate to the apply field manager", func() {
+			existing := newTestClusterRepo("https://ext.default.svc")
+			existing.SetManagedFields([]metav1.ManagedFieldsEntry{{
+				Manager:    legacyFieldManager,
+				Operation:  metav1.ManagedFieldsOperationUpdate,
+				APIVersion: "catalog.cattle.io/v1",
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{".":{},"f:url":{}}}`)},
+			}})
+			setUp(existing)
+			Expect(managers()).To(HaveKeyWithValue(legacyFieldManager, metav1.ManagedFieldsOperationUpdate))
+
+			resourceVersion, err := mgr.upgradeManagedFields(ctx, newTestClusterRepo("https://ext.default.svc"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resourceVersion).NotTo(BeEmpty())
+
+			Expect(managers()).To(Equal(map[string]metav1.ManagedFieldsOperationType{
+				FieldOwner: metav1.ManagedFieldsOperationApply,
+			}))
+		})
+
+		It("leaves fields of other managers alone", func() {
+			existing := newTestClusterRepo("https://ext.default.svc")
+			existing.SetManagedFields([]metav1.ManagedFieldsEntry{{
+				Manager:    "rancher",
+				Operation:  metav1.ManagedFieldsOperationUpdate,
+				APIVersion: "catalog.cattle.io/v1",
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{".":{},"f:url":{}}}`)},
+			}})
+			setUp(existing)
+
+			_, err := mgr.upgradeManagedFields(ctx, newTestClusterRepo("https://ext.default.svc"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(managers()).To(Equal(map[string]metav1.ManagedFieldsOperationType{
+				"rancher": metav1.ManagedFieldsOperationUpdate,
+			}))
+		})
+	})
+
+})
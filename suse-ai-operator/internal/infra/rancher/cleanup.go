@@ -7,11 +7,13 @@ import (
 	logging "github.com/SUSE/suse-ai-operator/internal/logging"
 )
 
+// Cleanup deletes the UIPlugin and ClusterRepo of ext and returns the
+// objects it deleted. Objects already gone are not reported.
 func (m *Manager) Cleanup(
 	ctx context.Context,
 	ext *v1alpha1.InstallAIExtension,
 	namespace string,
-) error {
+) ([]Change, error) {
 	log := logging.FromContext(ctx, "rancher.cleanup").
 		WithValues(
 			logging.KeyExtension, ext.Name,
@@ -19,21 +21,31 @@ func (m *Manager) Cleanup(
 
 	log.Info("Cleaning up Rancher resources")
 	if ext == nil {
-		return nil
+		return nil, nil
 	}
 
-	if err := m.deleteUIPlugin(ctx, ext, namespace); err != nil {
-		return err
+	var changes []Change
+
+	change, err := m.deleteUIPlugin(ctx, ext, namespace)
+	if err != nil {
+		return changes, err
+	}
+	if change != nil {
+		changes = append(changes, *change)
 	}
 	logging.Debug(log).Info("Deleting UIPlugin")
 
 	if ext.Spec.Helm != nil {
-		if err := m.deleteClusterRepo(ctx, ext); err != nil {
-			return err
+		change, err := m.deleteClusterRepo(ctx, ext)
+		if err != nil {
+			return changes, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
 		}
 		logging.Debug(log).Info("Deleting ClusterRepo")
 	}
 
 	log.Info("Rancher cleanup completed")
-	return nil
+	return changes, nil
 }
@@ -2,11 +2,11 @@ package rancher
 
 import (
 	"context"
+	"encoding/base64"
 
 	"github.com/SUSE/suse-ai-operator/api/v1alpha1"
 	logging "github.com/SUSE/suse-ai-operator/internal/logging"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -14,7 +14,8 @@ func (m *Manager) ensureClusterRepo(
 	ctx context.Context,
 	ext *v1alpha1.InstallAIExtension,
 	svcURL string,
-) error {
+	caBundle []byte,
+) (Change, error) {
 	log := logging.FromContext(ctx, "rancher.clusterrepo").
 		WithValues(
 			logging.KeyExtension, ext.Name,
@@ -23,30 +24,52 @@ func (m *Manager) ensureClusterRepo(
 
 	log.Info("Ensuring ClusterRepo")
 
+	repo := newClusterRepo(ext)
+	logging.Trace(log).Info(
+		"Setting ClusterRepo URL",
+		"url", svcURL,
+	)
+	if err := setClusterRepoSpec(repo, svcURL, caBundle); err != nil {
+		return Change{}, err
+	}
+
+	action, err := m.apply(ctx, repo)
+	if err != nil {
+		return Change{}, err
+	}
+
+	logging.Debug(log).Info("ClusterRepo ensured", "action", action)
+	return newChange(repo, action), nil
+}
+
+func newClusterRepo(ext *v1alpha1.InstallAIExtension) *unstructured.Unstructured {
 	repo := &unstructured.Unstructured{}
 	repo.SetAPIVersion("catalog.cattle.io/v1")
 	repo.SetKind("ClusterRepo")
 	repo.SetName(ext.Spec.Helm.Name)
+	return repo
+}
 
-	_, err := ctrl.CreateOrUpdate(ctx, m.client, repo, func() error {
-		logging.Trace(log).Info(
-			"Setting ClusterRepo URL",
-			"url", svcURL,
-		)
-		return unstructured.SetNestedField(repo.Object, svcURL, "spec", "url")
-	})
-	if err != nil {
+func setClusterRepoSpec(repo *unstructured.Unstructured, svcURL string, caBundle []byte) error {
+	if err := unstructured.SetNestedField(repo.Object, svcURL, "spec", "url"); err != nil {
 		return err
 	}
-
-	logging.Debug(log).Info("ClusterRepo ensured")
-	return nil
+	// An applied object without caBundle releases the field, which
+	// removes it unless another manager also sets it.
+	if len(caBundle) == 0 {
+		return nil
+	}
+	return unstructured.SetNestedField(
+		repo.Object,
+		base64.StdEncoding.EncodeToString(caBundle),
+		"spec", "caBundle",
+	)
 }
 
 func (m *Manager) deleteClusterRepo(
 	ctx context.Context,
 	ext *v1alpha1.InstallAIExtension,
-) error {
+) (*Change, error) {
 	log := logging.FromContext(ctx, "rancher.clusterrepo").
 		WithValues(
 			logging.KeyExtension, ext.Name,
@@ -55,22 +78,20 @@ func (m *Manager) deleteClusterRepo(
 
 	log.Info("Deleting ClusterRepo")
 
-	repo := &unstructured.Unstructured{}
-	repo.SetAPIVersion("catalog.cattle.io/v1")
-	repo.SetKind("ClusterRepo")
-	repo.SetName(ext.Spec.Helm.Name)
+	repo := newClusterRepo(ext)
 
 	err := m.client.Delete(ctx, repo)
 	if client.IgnoreNotFound(err) == nil {
 		logging.Debug(log).Info("ClusterRepo already deleted or not found")
-		return nil
+		return nil, nil
 	}
 
 	if err != nil {
 		log.Error(err, "Failed to delete ClusterRepo")
-		return err
+		return nil, err
 	}
 
 	log.Info("ClusterRepo deleted")
-	return nil
+	change := newChange(repo, ActionDeleted)
+	return &change, nil
 }
@@ -1,6 +1,9 @@
 package rancher
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type DependencyNotReadyError struct {
 	Dependency string
@@ -9,3 +12,21 @@ type DependencyNotReadyError struct {
 func (e *DependencyNotReadyError) Error() string {
 	return fmt.Sprintf("dependency %q is not ready", e.Dependency)
 }
+
+// FieldConflictError reports fields of a Rancher object the operator
+// sets that another field manager owns with a different value.
+type FieldConflictError struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Conflicts []string
+}
+
+func (e *FieldConflictError) Error() string {
+	name := e.Name
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + e.Name
+	}
+	return fmt.Sprintf("%s %s has fields owned by another manager: %s",
+		e.Kind, name, strings.Join(e.Conflicts, "; "))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -22,16 +23,51 @@ type Manager struct {
 	indexCache *helm.IndexCache
 }
 
-func NewManager(c client.Client, s *runtime.Scheme) *Manager {
-	return &Manager{client: c, scheme: s, indexCache: helm.NewIndexCache()}
+// NewManager creates a Manager. indexCache may be shared between managers,
+// nil caches index files for the lifetime of this one only.
+func NewManager(c client.Client, s *runtime.Scheme, indexCache *helm.IndexCache) *Manager {
+	if indexCache == nil {
+		indexCache = helm.NewIndexCache()
+	}
+	return &Manager{client: c, scheme: s, indexCache: indexCache}
+}
+
+// Action is what the operator did to a Rancher object.
+type Action string
+
+const (
+	ActionCreated   Action = "Created"
+	ActionUpdated   Action = "Updated"
+	ActionUnchanged Action = "Unchanged"
+	ActionDeleted   Action = "Deleted"
+)
+
+// Change describes the action taken on one Rancher object.
+type Change struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Action    Action
 }
 
+func newChange(obj *unstructured.Unstructured, action Action) Change {
+	return Change{
+		Kind:      obj.GetKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Action:    action,
+	}
+}
+
+// Ensure applies the ClusterRepo and UIPlugin of ext and returns what
+// was done to each, including the changes made before an error.
 func (m *Manager) Ensure(
 	ctx context.Context,
 	ext *v1alpha1.InstallAIExtension,
 	svcURL string,
+	caBundle []byte,
 	namespace string,
-) error {
+) ([]Change, error) {
 
 	log := logging.FromContext(ctx, "rancher").
 		WithValues(
@@ -43,17 +79,23 @@ func (m *Manager) Ensure(
 
 	if err := m.CheckCRDs(ctx, requiredCRDs); err != nil {
 		logging.Debug(log).Info("Rancher CRDs not ready yet")
-		return err
+		return nil, err
 	}
 
-	if err := m.ensureClusterRepo(ctx, ext, svcURL); err != nil {
-		return err
+	var changes []Change
+
+	change, err := m.ensureClusterRepo(ctx, ext, svcURL, caBundle)
+	if err != nil {
+		return changes, err
 	}
+	changes = append(changes, change)
 
-	if err := m.ensureUIPlugin(ctx, ext, svcURL, namespace); err != nil {
-		return err
+	change, err = m.ensureUIPlugin(ctx, ext, svcURL, caBundle, namespace)
+	if err != nil {
+		return changes, err
 	}
+	changes = append(changes, change)
 
 	log.Info("Rancher resources ensured")
-	return nil
+	return changes, nil
 }
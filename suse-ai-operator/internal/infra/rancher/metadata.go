@@ -21,6 +21,7 @@ func buildExtensionMetadata(
 	ctx context.Context,
 	indexCache *helm.IndexCache,
 	repoURL string,
+	caBundle []byte,
 	extensionName string,
 	version string,
 	userMeta map[string]string,
@@ -34,7 +35,7 @@ func buildExtensionMetadata(
 
 	logging.Debug(log).Info("Resolving extension metadata from Helm index")
 
-	index, err := getOrFetchIndex(ctx, indexCache, repoURL)
+	index, err := getOrFetchIndex(ctx, indexCache, repoURL, caBundle)
 	if err != nil {
 		log.Error(err, "Failed to load Helm index")
 		return nil, err
@@ -69,6 +70,7 @@ func getOrFetchIndex(
 	ctx context.Context,
 	cache *helm.IndexCache,
 	repoURL string,
+	caBundle []byte,
 ) (*helm.IndexFile, error) {
 
 	key := helm.IndexCacheKey{RepoURL: repoURL}
@@ -79,7 +81,7 @@ func getOrFetchIndex(
 
 	indexURL := fmt.Sprintf("%s/index.yaml", repoURL)
 
-	index, err := helm.FetchIndex(indexURL)
+	index, err := helm.FetchIndex(indexURL, caBundle)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,59 @@
+package rancher
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	logging "github.com/SUSE/suse-ai-operator/internal/logging"
+)
+
+// Plan returns the ClusterRepo and UIPlugin Ensure would write, without
+// writing them. When the extension server cannot be reached yet, metadata
+// from index.yaml is left out and reported as a warning.
+func (m *Manager) Plan(
+	ctx context.Context,
+	ext *v1alpha1.InstallAIExtension,
+	svcURL string,
+	caBundle []byte,
+	namespace string,
+) ([]*unstructured.Unstructured, []string, error) {
+
+	log := logging.FromContext(ctx, "rancher").
+		WithValues(
+			logging.KeyExtension, ext.Name,
+		)
+
+	logging.Debug(log).Info("Planning Rancher resources")
+
+	var warnings []string
+
+	repo := newClusterRepo(ext)
+	if err := setClusterRepoSpec(repo, svcURL, caBundle); err != nil {
+		return nil, nil, err
+	}
+
+	metadata, err := buildExtensionMetadata(
+		ctx,
+		m.indexCache,
+		svcURL,
+		caBundle,
+		ext.Spec.Extension.Name,
+		ext.Spec.Extension.Version,
+		ext.Spec.Extension.Metadata,
+	)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("UIPlugin metadata from index.yaml not resolved: %v", err))
+		metadata = mergeMetadata(map[string]string{}, ext.Spec.Extension.Metadata, ext.Spec.Extension.Name)
+	}
+
+	ui := newUIPlugin(ext)
+	ui.SetNamespace(namespace)
+	if err := setUIPluginSpec(ui, ext, svcURL, metadata); err != nil {
+		return nil, nil, err
+	}
+
+	return []*unstructured.Unstructured{repo, ui}, warnings, nil
+}
@@ -0,0 +1,14 @@
+package rancher
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRancher(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Rancher Suite")
+}
@@ -2,12 +2,11 @@ package rancher
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
 	logging "github.com/SUSE/suse-ai-operator/internal/logging"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -15,18 +14,16 @@ func (m *Manager) ensureUIPlugin(
 	ctx context.Context,
 	ext *v1alpha1.InstallAIExtension,
 	svcURL string,
+	caBundle []byte,
 	namespace string,
-) error {
+) (Change, error) {
 	log := logging.FromContext(ctx, "rancher.uiplugin").
 		WithValues(
 			logging.KeyExtension, ext.Spec.Extension.Name,
 			logging.KeyVersion, ext.Spec.Extension.Version,
 		)
 
-	ui := &unstructured.Unstructured{}
-	ui.SetAPIVersion("catalog.cattle.io/v1")
-	ui.SetKind("UIPlugin")
-	ui.SetName(ext.Spec.Extension.Name)
+	ui := newUIPlugin(ext)
 
 	ui.SetNamespace(namespace)
 
@@ -35,56 +32,61 @@ func (m *Manager) ensureUIPlugin(
 		"namespace", namespace,
 	)
 
-	_, err := ctrl.CreateOrUpdate(ctx, m.client, ui, func() error {
-		if err := unstructured.SetNestedField(ui.Object, ext.Spec.Extension.Name, "spec", "plugin", "name"); err != nil {
-			return err
-		}
-		if err := unstructured.SetNestedField(ui.Object, ext.Spec.Extension.Version, "spec", "plugin", "version"); err != nil {
-			return err
-		}
-		pluginEndpoint := fmt.Sprintf("%s/plugin/%s-%s", svcURL, ext.Spec.Extension.Name, ext.Spec.Extension.Version)
-		if err := unstructured.SetNestedField(ui.Object, pluginEndpoint, "spec", "plugin", "endpoint"); err != nil {
-			return err
-		}
+	metadata := ext.Spec.Extension.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
 
-		logging.Trace(log).Info(
-			"Configuring UIPlugin spec",
-			"endpoint", pluginEndpoint,
-		)
+	metadata, err := buildExtensionMetadata(
+		ctx,
+		m.indexCache,
+		svcURL,
+		caBundle,
+		ext.Spec.Extension.Name,
+		ext.Spec.Extension.Version,
+		metadata,
+	)
+	if err != nil {
+		return Change{}, err
+	}
 
-		metadata := ext.Spec.Extension.Metadata
-		if metadata == nil {
-			metadata = map[string]string{}
-		}
+	logging.Trace(log).Info(
+		"Configuring UIPlugin spec",
+		"endpoint", installaiextension.PluginEndpoint(svcURL, ext.Spec.Extension.Name, ext.Spec.Extension.Version),
+	)
 
-		metadata, err := buildExtensionMetadata(
-			ctx,
-			m.indexCache,
-			svcURL,
-			ext.Spec.Extension.Name,
-			ext.Spec.Extension.Version,
-			metadata,
-		)
+	if err := setUIPluginSpec(ui, ext, svcURL, metadata); err != nil {
+		return Change{}, err
+	}
 
-		if err != nil {
-			return err
+	// Rancher only refetches a cached plugin when it is dropped from its
+	// cache. A new reconcile-at value sets noCache until the reconcile is
+	// recorded. The next apply leaves it out, which removes it, so the
+	// plugin is fetched and cached again.
+	at := ext.Annotations[v1alpha1.AnnotationReconcileAt]
+	if at != "" {
+		ui.SetAnnotations(map[string]string{v1alpha1.AnnotationReconcileAt: at})
+	}
+	if at != "" && at != ext.Status.LastHandledReconcileAt {
+		if err := unstructured.SetNestedField(ui.Object, true, "spec", "plugin", "noCache"); err != nil {
+			return Change{}, err
 		}
+	}
 
-		return unstructured.SetNestedStringMap(ui.Object, metadata, "spec", "plugin", "metadata")
-	})
+	action, err := m.apply(ctx, ui)
 	if err != nil {
-		return err
+		return Change{}, err
 	}
 
-	logging.Debug(log).Info("UIPlugin ensured")
-	return nil
+	logging.Debug(log).Info("UIPlugin ensured", "action", action)
+	return newChange(ui, action), nil
 }
 
 func (m *Manager) deleteUIPlugin(
 	ctx context.Context,
 	ext *v1alpha1.InstallAIExtension,
 	namespace string,
-) error {
+) (*Change, error) {
 	log := logging.FromContext(ctx, "rancher.uiplugin").
 		WithValues(
 			logging.KeyExtension, ext.Spec.Extension.Name,
@@ -96,23 +98,48 @@ func (m *Manager) deleteUIPlugin(
 		logging.KeyNamespace, namespace,
 	)
 
-	ui := &unstructured.Unstructured{}
-	ui.SetAPIVersion("catalog.cattle.io/v1")
-	ui.SetKind("UIPlugin")
-	ui.SetName(ext.Spec.Extension.Name)
+	ui := newUIPlugin(ext)
 	ui.SetNamespace(namespace)
 
 	err := m.client.Delete(ctx, ui)
 	if client.IgnoreNotFound(err) == nil {
 		logging.Debug(log).Info("UIPlugin already deleted or not found")
-		return nil
+		return nil, nil
 	}
 
 	if err != nil {
 		log.Error(err, "Failed to delete UIPlugin")
-		return err
+		return nil, err
 	}
 
 	log.Info("UIPlugin deleted")
-	return nil
+	change := newChange(ui, ActionDeleted)
+	return &change, nil
+}
+
+func newUIPlugin(ext *v1alpha1.InstallAIExtension) *unstructured.Unstructured {
+	ui := &unstructured.Unstructured{}
+	ui.SetAPIVersion("catalog.cattle.io/v1")
+	ui.SetKind("UIPlugin")
+	ui.SetName(ext.Spec.Extension.Name)
+	return ui
+}
+
+func setUIPluginSpec(
+	ui *unstructured.Unstructured,
+	ext *v1alpha1.InstallAIExtension,
+	svcURL string,
+	metadata map[string]string,
+) error {
+	if err := unstructured.SetNestedField(ui.Object, ext.Spec.Extension.Name, "spec", "plugin", "name"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(ui.Object, ext.Spec.Extension.Version, "spec", "plugin", "version"); err != nil {
+		return err
+	}
+	pluginEndpoint := installaiextension.PluginEndpoint(svcURL, ext.Spec.Extension.Name, ext.Spec.Extension.Version)
+	if err := unstructured.SetNestedField(ui.Object, pluginEndpoint, "spec", "plugin", "endpoint"); err != nil {
+		return err
+	}
+	return unstructured.SetNestedStringMap(ui.Object, metadata, "spec", "plugin", "metadata")
 }
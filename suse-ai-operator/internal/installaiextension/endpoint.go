@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/SUSE/suse-ai-operator/api/v1alpha1"
 )
 
 func EndpointFromGitRepo(
@@ -36,14 +39,115 @@ func EndpointFromGitRepo(
 	), nil
 }
 
-func ServiceEndpoint(svc *corev1.Service) (name, namespace string, port int32, error error) {
+type PortNotFoundError struct {
+	Service string
+	Port    string
+}
+
+func (e *PortNotFoundError) Error() string {
+	return fmt.Sprintf("service %s has no port %s", e.Service, e.Port)
+}
+
+// AnnotationTLS marks a Service whose ports all serve HTTPS.
+const AnnotationTLS = "ai-platform.suse.com/tls"
+
+// ServiceEndpoint returns the address of svc for the requested port.
+// When port is nil, the port named "https", then the one named "http",
+// is preferred over the first declared port. A Service declaring both is
+// served over HTTPS unless the "http" port is requested explicitly.
+func ServiceEndpoint(
+	svc *corev1.Service,
+	port *intstr.IntOrString,
+) (name, namespace string, portNumber int32, err error) {
 	if svc == nil {
 		return "", "", 0, fmt.Errorf("service is nil")
 	}
 
 	if len(svc.Spec.Ports) == 0 {
-		return "", "", 0, fmt.Errorf("service %s has no ports", svc.Name)
+		return "", "", 0, &PortNotFoundError{Service: svc.Name, Port: "(none declared)"}
+	}
+
+	selected, err := selectServicePort(svc, port)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	return svc.Name, svc.Namespace, selected.Port, nil
+}
+
+func selectServicePort(
+	svc *corev1.Service,
+	port *intstr.IntOrString,
+) (*corev1.ServicePort, error) {
+
+	if port == nil {
+		for _, name := range []string{"https", "http"} {
+			for i := range svc.Spec.Ports {
+				if svc.Spec.Ports[i].Name == name {
+					return &svc.Spec.Ports[i], nil
+				}
+			}
+		}
+		return &svc.Spec.Ports[0], nil
 	}
 
-	return svc.Name, svc.Namespace, svc.Spec.Ports[0].Port, nil
+	for i := range svc.Spec.Ports {
+		p := &svc.Spec.Ports[i]
+		switch port.Type {
+		case intstr.Int:
+			if p.Port == port.IntVal {
+				return p, nil
+			}
+		case intstr.String:
+			if p.Name == port.StrVal {
+				return p, nil
+			}
+		}
+	}
+
+	return nil, &PortNotFoundError{Service: svc.Name, Port: port.String()}
+}
+
+// PluginEndpoint returns the URL Rancher loads the plugin from.
+func PluginEndpoint(svcURL, pluginName, version string) string {
+	return fmt.Sprintf("%s/plugin/%s-%s", svcURL, pluginName, version)
+}
+
+// ServiceScheme returns "https" when the given port of svc serves TLS,
+// detected from the port name, its appProtocol or the AnnotationTLS
+// annotation on the Service.
+func ServiceScheme(svc *corev1.Service, port int32) string {
+	if svc.Annotations[AnnotationTLS] == "true" {
+		return "https"
+	}
+
+	for _, p := range svc.Spec.Ports {
+		if p.Port != port {
+			continue
+		}
+		if p.Name == "https" || (p.AppProtocol != nil && *p.AppProtocol == "https") {
+			return "https"
+		}
+	}
+
+	return "http"
+}
+
+// CABundleRef returns the Secret and key holding the CA bundle clients
+// must trust to reach the extension server.
+func CABundleRef(tls *v1alpha1.ExtensionTLSSpec) (name, key string, ok bool) {
+	switch {
+	case tls == nil:
+		return "", "", false
+	case tls.CABundleSecretRef != nil:
+		key = tls.CABundleSecretRef.Key
+		if key == "" {
+			key = "ca.crt"
+		}
+		return tls.CABundleSecretRef.Name, key, true
+	case tls.Certificate != nil:
+		return tls.Certificate.SecretName, "ca.crt", true
+	default:
+		return "", "", false
+	}
 }
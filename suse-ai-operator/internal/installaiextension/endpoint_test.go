@@ -0,0 +1,123 @@
+package installaiextension
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("selectServicePort", func() {
+	service := func(ports ...corev1.ServicePort) *corev1.Service {
+		svc := &corev1.Service{}
+		svc.Name = "ui"
+		svc.Spec.Ports = ports
+		return svc
+	}
+	metricsPort := corev1.ServicePort{Name: "metrics", Port: 9090}
+	httpPort := corev1.ServicePort{Name: "http", Port: 8080}
+	httpsPort := corev1.ServicePort{Name: "https", Port: 8443}
+
+	DescribeTable("selects the port",
+		func(svc *corev1.Service, port *intstr.IntOrString, expected int32) {
+			selected, err := selectServicePort(svc, port)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selected.Port).To(Equal(expected))
+		},
+		Entry("prefers https when no port is requested",
+			service(metricsPort, httpPort, httpsPort), nil, int32(8443)),
+		Entry("falls back to http when no port is requested",
+			service(metricsPort, httpPort), nil, int32(8080)),
+		Entry("falls back to the first port when none is named http or https",
+			service(metricsPort), nil, int32(9090)),
+		Entry("prefers https over an http port declared first",
+			service(httpPort, httpsPort), nil, int32(8443)),
+		Entry("keeps a requested http port when https is declared",
+			service(httpsPort, httpPort), intOrString(intstr.FromString("http")), int32(8080)),
+		Entry("matches a port number",
+			service(httpPort, httpsPort), intOrString(intstr.FromInt32(8080)), int32(8080)),
+		Entry("matches a port name",
+			service(httpPort, metricsPort), intOrString(intstr.FromString("metrics")), int32(9090)),
+	)
+
+	DescribeTable("reports a missing port",
+		func(port intstr.IntOrString) {
+			_, err := selectServicePort(service(httpPort), &port)
+
+			var notFound *PortNotFoundError
+			Expect(err).To(BeAssignableToTypeOf(notFound))
+			Expect(err.Error()).To(Equal("service ui has no port " + port.String()))
+		},
+		Entry("by number", intstr.FromInt32(8443)),
+		Entry("by name", intstr.FromString("https")),
+		Entry("by a number given as a name", intstr.FromString("8080")),
+	)
+})
+
+func intOrString(v intstr.IntOrString) *intstr.IntOrString {
+	return &v
+}
+
+var _ = Describe("ServiceScheme", func() {
+	https := "https"
+	http := "http"
+
+	DescribeTable("chooses the scheme",
+		func(annotations map[string]string, port corev1.ServicePort, expected string) {
+			svc := &corev1.Service{}
+			svc.Annotations = annotations
+			svc.Spec.Ports = []corev1.ServicePort{{Name: "metrics", Port: 9090}, port}
+			Expect(ServiceScheme(svc, port.Port)).To(Equal(expected))
+		},
+		Entry("uses http for a plain port", nil,
+			corev1.ServicePort{Name: "web", Port: 8080}, "http"),
+		Entry("uses https for a port named https", nil,
+			corev1.ServicePort{Name: "https", Port: 8443}, "https"),
+		Entry("uses https for an https appProtocol", nil,
+			corev1.ServicePort{Name: "web", Port: 8443, AppProtocol: &https}, "https"),
+		Entry("uses http for an http appProtocol", nil,
+			corev1.ServicePort{Name: "web", Port: 8080, AppProtocol: &http}, "http"),
+		Entry("uses https for a Service annotated as TLS",
+			map[string]string{AnnotationTLS: "true"},
+			corev1.ServicePort{Name: "http", Port: 8080}, "https"),
+		Entry("ignores a TLS annotation that is not true",
+			map[string]string{AnnotationTLS: "false"},
+			corev1.ServicePort{Name: "http", Port: 8080}, "http"),
+	)
+
+	It("only looks at the selected port", func() {
+		svc := &corev1.Service{}
+		svc.Spec.Ports = []corev1.ServicePort{{Name: "https", Port: 8443}, {Name: "http", Port: 8080}}
+		Expect(ServiceScheme(svc, 8080)).To(Equal("http"))
+	})
+})
+
+var _ = Describe("CABundleRef", func() {
+	DescribeTable("returns the CA bundle Secret",
+		func(tls *v1alpha1.ExtensionTLSSpec, name, key string, ok bool) {
+			gotName, gotKey, gotOK := CABundleRef(tls)
+			Expect(gotOK).To(Equal(ok))
+			Expect(gotName).To(Equal(name))
+			Expect(gotKey).To(Equal(key))
+		},
+		Entry("none without TLS", nil, "", "", false),
+		Entry("none without a bundle or certificate", &v1alpha1.ExtensionTLSSpec{}, "", "", false),
+		Entry("the referenced Secret key",
+			&v1alpha1.ExtensionTLSSpec{CABundleSecretRef: &v1alpha1.SecretKeySelector{Name: "ca", Key: "bundle.pem"}},
+			"ca", "bundle.pem", true),
+		Entry("ca.crt of the referenced Secret by default",
+			&v1alpha1.ExtensionTLSSpec{CABundleSecretRef: &v1alpha1.SecretKeySelector{Name: "ca"}},
+			"ca", "ca.crt", true),
+		Entry("ca.crt of the issued certificate",
+			&v1alpha1.ExtensionTLSSpec{Certificate: &v1alpha1.ServingCertificateSpec{SecretName: "ui-tls"}},
+			"ui-tls", "ca.crt", true),
+		Entry("the referenced Secret over the issued certificate",
+			&v1alpha1.ExtensionTLSSpec{
+				CABundleSecretRef: &v1alpha1.SecretKeySelector{Name: "ca"},
+				Certificate:       &v1alpha1.ServingCertificateSpec{SecretName: "ui-tls"},
+			},
+			"ca", "ca.crt", true),
+	)
+})
@@ -0,0 +1,14 @@
+package installaiextension
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestInstallAIExtension(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "InstallAIExtension Suite")
+}
@@ -0,0 +1,14 @@
+package installaiextension
+
+import (
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+// Namespace returns the namespace ext is installed in: the namespace its
+// release was deployed to, or defaultNamespace before the first release.
+func Namespace(ext *aiplatformv1alpha1.InstallAIExtension, defaultNamespace string) string {
+	if d := ext.Status.Deployed; d != nil && d.Namespace != "" {
+		return d.Namespace
+	}
+	return defaultNamespace
+}
@@ -0,0 +1,40 @@
+package installaiextension
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+// SetPhase sets the phase and message of ext and reports whether the phase
+// changed. A change is recorded with its time and reason in
+// status.phaseHistory, which keeps the last MaxPhaseHistory transitions.
+func SetPhase(ext *aiplatformv1alpha1.InstallAIExtension, phase, reason, message string) bool {
+	ext.Status.Message = message
+	if ext.Status.Phase == phase {
+		return false
+	}
+
+	now := metav1.Now()
+	ext.Status.Phase = phase
+	ext.Status.LastPhaseTransitionTime = &now
+
+	history := append(ext.Status.PhaseHistory, aiplatformv1alpha1.PhaseTransition{
+		Phase:   phase,
+		Reason:  reason,
+		Message: message,
+		Time:    now,
+	})
+	if n := len(history) - aiplatformv1alpha1.MaxPhaseHistory; n > 0 {
+		history = history[n:]
+	}
+	ext.Status.PhaseHistory = history
+	return true
+}
+
+// UpgradeRolledBack reports whether the upgrade to the current generation
+// of ext failed and was rolled back.
+func UpgradeRolledBack(ext *aiplatformv1alpha1.InstallAIExtension) bool {
+	failed := ext.Status.FailedUpgrade
+	return failed != nil && failed.ObservedGeneration == ext.Generation
+}
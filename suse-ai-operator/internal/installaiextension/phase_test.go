@@ -0,0 +1,54 @@
+package installaiextension
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("SetPhase", func() {
+	It("records a phase change", func() {
+		ext := &aiplatformv1alpha1.InstallAIExtension{}
+
+		Expect(SetPhase(ext, aiplatformv1alpha1.PhaseInstalling, "InstallStarted", "Installing chart")).To(BeTrue())
+		Expect(ext.Status.Phase).To(Equal(aiplatformv1alpha1.PhaseInstalling))
+		Expect(ext.Status.Message).To(Equal("Installing chart"))
+		Expect(ext.Status.LastPhaseTransitionTime).NotTo(BeNil())
+		Expect(ext.Status.PhaseHistory).To(HaveLen(1))
+		Expect(ext.Status.PhaseHistory[0].Phase).To(Equal(aiplatformv1alpha1.PhaseInstalling))
+		Expect(ext.Status.PhaseHistory[0].Reason).To(Equal("InstallStarted"))
+		Expect(ext.Status.PhaseHistory[0].Message).To(Equal("Installing chart"))
+		Expect(ext.Status.PhaseHistory[0].Time).To(Equal(*ext.Status.LastPhaseTransitionTime))
+	})
+
+	It("only updates the message when the phase is unchanged", func() {
+		ext := &aiplatformv1alpha1.InstallAIExtension{}
+		SetPhase(ext, aiplatformv1alpha1.PhaseInstalling, "InstallStarted", "Installing chart")
+		transition := ext.Status.LastPhaseTransitionTime
+
+		Expect(SetPhase(ext, aiplatformv1alpha1.PhaseInstalling, "InstallStarted", "Waiting for pods")).To(BeFalse())
+		Expect(ext.Status.Message).To(Equal("Waiting for pods"))
+		Expect(ext.Status.LastPhaseTransitionTime).To(BeIdenticalTo(transition))
+		Expect(ext.Status.PhaseHistory).To(HaveLen(1))
+	})
+
+	DescribeTable("keeps the last transitions",
+		func(transitions, expected int) {
+			ext := &aiplatformv1alpha1.InstallAIExtension{}
+			for i := range transitions {
+				SetPhase(ext, fmt.Sprintf("Phase%d", i), "", "")
+			}
+
+			Expect(ext.Status.PhaseHistory).To(HaveLen(expected))
+			for i, t := range ext.Status.PhaseHistory {
+				Expect(t.Phase).To(Equal(fmt.Sprintf("Phase%d", transitions-expected+i)))
+			}
+		},
+		Entry("below the limit", 3, 3),
+		Entry("at the limit", aiplatformv1alpha1.MaxPhaseHistory, aiplatformv1alpha1.MaxPhaseHistory),
+		Entry("above the limit", aiplatformv1alpha1.MaxPhaseHistory+5, aiplatformv1alpha1.MaxPhaseHistory),
+	)
+})
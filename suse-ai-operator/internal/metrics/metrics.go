@@ -0,0 +1,253 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "suse_ai_operator"
+
+const (
+	LabelExtension    = "extension"
+	LabelResult       = "result"
+	LabelOperation    = "operation"
+	LabelSource       = "source"
+	LabelName         = "name"
+	LabelVersion      = "version"
+	LabelChartVersion = "chart_version"
+)
+
+const (
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Helm operations reported in HelmOperationDuration.
+const (
+	OperationInstall   = "install"
+	OperationUpgrade   = "upgrade"
+	OperationUninstall = "uninstall"
+)
+
+// Chart sources reported in the chart pull metrics.
+const (
+	SourceOCI  = "oci"
+	SourceHTTP = "http"
+)
+
+var (
+	HealthProbeDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "extension_health_probe_duration_seconds",
+			Help:      "Latency of extension plugin endpoint health probes.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{LabelExtension},
+	)
+
+	HealthProbeFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "extension_health_probe_failures_total",
+			Help:      "Number of failed extension plugin endpoint health probes.",
+		},
+		[]string{LabelExtension},
+	)
+
+	HealthProbeTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "extension_health_last_probe_timestamp_seconds",
+			Help:      "Unix time of the last extension plugin endpoint health probe.",
+		},
+		[]string{LabelExtension},
+	)
+
+	ExtensionHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "extension_healthy",
+			Help:      "Whether the extension plugin endpoint is healthy (1) or not (0).",
+		},
+		[]string{LabelExtension},
+	)
+
+	ChartCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chart_cache_requests_total",
+			Help:      "Chart cache lookups by result (hit or miss).",
+		},
+		[]string{LabelResult},
+	)
+
+	ChartCacheEvictions = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chart_cache_evictions_total",
+			Help:      "Number of chart archives evicted from the cache.",
+		},
+	)
+
+	ChartCacheIntegrityFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chart_cache_integrity_failures_total",
+			Help:      "Number of cached chart archives dropped because their digest did not match.",
+		},
+	)
+
+	ChartCacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "chart_cache_size_bytes",
+			Help:      "Total size of the chart archives in the cache.",
+		},
+	)
+
+	HelmOperationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "helm_operation_duration_seconds",
+			Help:      "Duration of Helm installs, upgrades and uninstalls by outcome (success or failure).",
+			Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+		},
+		[]string{LabelExtension, LabelOperation, LabelResult},
+	)
+
+	ChartPullDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "chart_pull_duration_seconds",
+			Help:      "Duration of chart downloads from OCI registries and HTTP repositories, by outcome.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{LabelSource, LabelResult},
+	)
+
+	ChartPullBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chart_pull_bytes_total",
+			Help:      "Size of the chart archives downloaded.",
+		},
+		[]string{LabelSource},
+	)
+
+	IndexFetchDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "index_fetch_duration_seconds",
+			Help:      "Latency of fetching extension index.yaml files, by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{LabelResult},
+	)
+
+	IndexCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "index_cache_requests_total",
+			Help:      "Index cache lookups by result (hit or miss).",
+		},
+		[]string{LabelResult},
+	)
+
+	ExtensionInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "extension_info",
+			Help:      "Always 1, labeled with the extension and chart version that are registered.",
+		},
+		[]string{LabelName, LabelVersion, LabelChartVersion},
+	)
+
+	ExtensionReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "extension_ready",
+			Help:      "Whether the extension is in the Ready phase (1) or not (0).",
+		},
+		[]string{LabelExtension},
+	)
+
+	DriftCorrections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "extension_drift_corrections_total",
+			Help:      "Number of upgrades that reverted changes made to a release outside the operator.",
+		},
+		[]string{LabelExtension},
+	)
+
+	CompatibilityCheckFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "extension_compatibility_check_failures_total",
+			Help:      "Number of installs, upgrades and plans refused because the chart's kubeVersion excludes the cluster.",
+		},
+		[]string{LabelExtension},
+	)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		HealthProbeDuration,
+		HealthProbeFailures,
+		HealthProbeTimestamp,
+		ExtensionHealthy,
+		ChartCacheRequests,
+		ChartCacheEvictions,
+		ChartCacheIntegrityFailures,
+		ChartCacheSize,
+		HelmOperationDuration,
+		ChartPullDuration,
+		ChartPullBytes,
+		IndexFetchDuration,
+		IndexCacheRequests,
+		ExtensionInfo,
+		ExtensionReady,
+		DriftCorrections,
+		CompatibilityCheckFailures,
+	)
+}
+
+// DeleteExtension drops all series labeled with the given extension.
+func DeleteExtension(name string) {
+	labels := prometheus.Labels{LabelExtension: name}
+	HealthProbeDuration.DeletePartialMatch(labels)
+	HealthProbeFailures.DeletePartialMatch(labels)
+	HealthProbeTimestamp.DeletePartialMatch(labels)
+	ExtensionHealthy.DeletePartialMatch(labels)
+	HelmOperationDuration.DeletePartialMatch(labels)
+	ExtensionReady.DeletePartialMatch(labels)
+	DriftCorrections.DeletePartialMatch(labels)
+	CompatibilityCheckFailures.DeletePartialMatch(labels)
+	ExtensionInfo.DeletePartialMatch(prometheus.Labels{LabelName: name})
+}
+
+// SetExtensionInfo replaces the extension_info series of the extension.
+func SetExtensionInfo(name, version, chartVersion string) {
+	ExtensionInfo.DeletePartialMatch(prometheus.Labels{LabelName: name})
+	ExtensionInfo.WithLabelValues(name, version, chartVersion).Set(1)
+}
+
+// SetExtensionReady records whether the extension is in the Ready phase.
+func SetExtensionReady(name string, ready bool) {
+	value := 0.0
+	if ready {
+		value = 1
+	}
+	ExtensionReady.WithLabelValues(name).Set(value)
+}
+
+// Result returns ResultSuccess for a nil err and ResultFailure otherwise.
+func Result(err error) string {
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigMapKey is the ConfigMap key holding the policy as YAML.
+const ConfigMapKey = "policy.yaml"
+
+// Loader combines the policy set with flags, the one in the
+// OperatorConfig and the one stored in a ConfigMap. The ConfigMap is read
+// on every Load, so edits apply to the next admission request and
+// reconcile without a restart.
+type Loader struct {
+	Reader client.Reader
+	// Static is the policy set with flags.
+	Static *Policy
+	// Dynamic returns the policy set in the OperatorConfig. It may be nil.
+	Dynamic func() *Policy
+	// ConfigMap names the policy ConfigMap. An empty name disables it.
+	ConfigMap types.NamespacedName
+}
+
+// Load returns the policy in effect. A configured ConfigMap that is
+// missing or invalid is an error, so charts are not admitted while the
+// policy cannot be read.
+func (l *Loader) Load(ctx context.Context) (*Policy, error) {
+	if l == nil {
+		return nil, nil
+	}
+	base := l.Static
+	if l.Dynamic != nil {
+		base = base.Merge(l.Dynamic())
+	}
+
+	if l.ConfigMap.Name == "" {
+		return base, nil
+	}
+
+	var cm corev1.ConfigMap
+	if err := l.Reader.Get(ctx, l.ConfigMap, &cm); err != nil {
+		return nil, fmt.Errorf("failed to read policy ConfigMap %s: %w", l.ConfigMap, err)
+	}
+
+	var p Policy
+	if err := yaml.UnmarshalStrict([]byte(cm.Data[ConfigMapKey]), &p); err != nil {
+		return nil, fmt.Errorf("invalid policy in ConfigMap %s: %w", l.ConfigMap, err)
+	}
+
+	return base.Merge(&p), nil
+}
+
+// Check loads the policy and checks ch against it.
+func (l *Loader) Check(ctx context.Context, ch Chart) error {
+	p, err := l.Load(ctx)
+	if err != nil {
+		return err
+	}
+	return p.Check(ch)
+}
+
+// ParseConfigMapRef parses a namespace/name reference. A bare name is
+// looked up in defaultNamespace.
+func ParseConfigMapRef(ref, defaultNamespace string) types.NamespacedName {
+	if ref == "" {
+		return types.NamespacedName{}
+	}
+	if ns, name, ok := strings.Cut(ref, "/"); ok {
+		return types.NamespacedName{Namespace: ns, Name: name}
+	}
+	return types.NamespacedName{Namespace: defaultNamespace, Name: ref}
+}
+
+// SplitList splits a comma separated flag value.
+func SplitList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
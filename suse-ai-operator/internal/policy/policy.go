@@ -0,0 +1,245 @@
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+)
+
+// Policy restricts where extension charts may be installed from. Empty
+// lists allow everything.
+type Policy struct {
+	// AllowedURLPrefixes lists prefixes of spec.helm.url that are allowed,
+	// such as oci://registry.suse.com/ai/charts/.
+	AllowedURLPrefixes []string `json:"allowedURLPrefixes,omitempty"`
+	// AllowedRegistries lists the registry or repository hosts that are
+	// allowed, with an optional port.
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// AllowedCharts lists the chart names that are allowed. Names may be
+	// shell patterns such as suse-ai-*.
+	AllowedCharts []string `json:"allowedCharts,omitempty"`
+	// RequireSignature lists the charts that must set spec.helm.verify.
+	RequireSignature []SignatureRule `json:"requireSignature,omitempty"`
+}
+
+// SignatureRule requires charts matching URLPrefix or Registry to be
+// verified. A rule without either applies to every chart.
+type SignatureRule struct {
+	URLPrefix string `json:"urlPrefix,omitempty"`
+	Registry  string `json:"registry,omitempty"`
+	// Provider, when set, is the verification provider the chart must use.
+	Provider string `json:"provider,omitempty"`
+}
+
+// Chart is what a policy is evaluated against.
+type Chart struct {
+	// URL is spec.helm.url.
+	URL string
+	// VerifyProvider is the provider of spec.helm.verify, or empty when
+	// the chart is not verified.
+	VerifyProvider string
+}
+
+// ExtensionChart describes the chart of ext. ext.Spec.Helm must be set.
+func ExtensionChart(ext *aiplatformv1alpha1.InstallAIExtension) Chart {
+	ch := Chart{URL: ext.Spec.Helm.URL}
+	if v := ext.Spec.Helm.Verify; v != nil {
+		ch.VerifyProvider = v.Provider
+	}
+	return ch
+}
+
+// ViolationError lists the rules of a policy a chart violates.
+type ViolationError struct {
+	URL        string
+	Violations []string
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("chart %s violates the operator policy: %s", e.URL, strings.Join(e.Violations, "; "))
+}
+
+// Empty reports whether the policy allows every chart.
+func (p *Policy) Empty() bool {
+	return p == nil || (len(p.AllowedURLPrefixes) == 0 &&
+		len(p.AllowedRegistries) == 0 &&
+		len(p.AllowedCharts) == 0 &&
+		len(p.RequireSignature) == 0)
+}
+
+// Merge returns a policy allowing what either p or other allows, and
+// requiring every signature either requires.
+func (p *Policy) Merge(other *Policy) *Policy {
+	if p == nil {
+		return other
+	}
+	if other == nil {
+		return p
+	}
+	return &Policy{
+		AllowedURLPrefixes: append(append([]string{}, p.AllowedURLPrefixes...), other.AllowedURLPrefixes...),
+		AllowedRegistries:  append(append([]string{}, p.AllowedRegistries...), other.AllowedRegistries...),
+		AllowedCharts:      append(append([]string{}, p.AllowedCharts...), other.AllowedCharts...),
+		RequireSignature:   append(append([]SignatureRule{}, p.RequireSignature...), other.RequireSignature...),
+	}
+}
+
+// Check returns a *ViolationError when ch is not allowed by the policy.
+// A chart must match one of the allowed URL prefixes or registries when
+// either list is set, and one of the allowed charts when that list is set.
+func (p *Policy) Check(ch Chart) error {
+	if p.Empty() {
+		return nil
+	}
+
+	u, err := url.Parse(ch.URL)
+	if err != nil {
+		return &ViolationError{URL: ch.URL, Violations: []string{fmt.Sprintf("invalid URL: %v", err)}}
+	}
+
+	var violations []string
+
+	if u.User != nil {
+		violations = append(violations, "URL must not contain credentials")
+	}
+
+	if len(p.AllowedURLPrefixes) > 0 || len(p.AllowedRegistries) > 0 {
+		if !p.sourceAllowed(u) {
+			violations = append(violations, fmt.Sprintf("registry %s and URL are not in the allowlist", u.Host))
+		}
+	}
+
+	if len(p.AllowedCharts) > 0 {
+		name := ChartName(u)
+		if !matchAny(p.AllowedCharts, name) {
+			violations = append(violations, fmt.Sprintf("chart %q is not in the allowlist", name))
+		}
+	}
+
+	for _, rule := range p.RequireSignature {
+		if !rule.matches(u) {
+			continue
+		}
+		switch {
+		case ch.VerifyProvider == "":
+			violations = append(violations, "charts from this source must set spec.helm.verify")
+		case rule.Provider != "" && !strings.EqualFold(rule.Provider, ch.VerifyProvider):
+			violations = append(violations, fmt.Sprintf("charts from this source must be verified with %s", rule.Provider))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ViolationError{URL: ch.URL, Violations: dedupe(violations)}
+}
+
+func (p *Policy) sourceAllowed(u *url.URL) bool {
+	for _, prefix := range p.AllowedURLPrefixes {
+		if hasURLPrefix(u, prefix) {
+			return true
+		}
+	}
+	for _, registry := range p.AllowedRegistries {
+		if strings.EqualFold(registry, u.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r SignatureRule) matches(u *url.URL) bool {
+	if r.URLPrefix != "" && !hasURLPrefix(u, r.URLPrefix) {
+		return false
+	}
+	if r.Registry != "" && !strings.EqualFold(r.Registry, u.Host) {
+		return false
+	}
+	return true
+}
+
+// hasURLPrefix reports whether u is under prefix: the scheme and host
+// must be equal, and the path of prefix must match the leading segments
+// of the cleaned path of u. The last segment of an OCI reference is
+// compared without its tag or digest. A prefix with credentials never
+// matches.
+func hasURLPrefix(u *url.URL, prefix string) bool {
+	p, err := url.Parse(prefix)
+	if err != nil || p.User != nil {
+		return false
+	}
+	if !strings.EqualFold(p.Scheme, u.Scheme) || !strings.EqualFold(p.Host, u.Host) {
+		return false
+	}
+
+	want := pathSegments(p.Path)
+	got := pathSegments(u.Path)
+	if len(want) > len(got) {
+		return false
+	}
+	for i, segment := range want {
+		if segment == got[i] {
+			continue
+		}
+		if u.Scheme == "oci" && i == len(got)-1 {
+			if j := strings.IndexAny(got[i], ":@"); j >= 0 && got[i][:j] == segment {
+				continue
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// pathSegments splits a cleaned absolute path into its segments.
+func pathSegments(p string) []string {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// archiveVersion matches the version suffix of a packaged chart archive.
+var archiveVersion = regexp.MustCompile(`-v?[0-9]+\.[0-9]+\.[0-9]+.*$`)
+
+// ChartName derives the chart name from a chart URL: the last path
+// element of an OCI reference, or the archive name without its version
+// for HTTP repositories.
+func ChartName(u *url.URL) string {
+	name := path.Base(u.Path)
+	if u.Scheme == "oci" {
+		if i := strings.IndexAny(name, ":@"); i >= 0 {
+			name = name[:i]
+		}
+		return name
+	}
+	name = strings.TrimSuffix(name, ".tgz")
+	return archiveVersion.ReplaceAllString(name, "")
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
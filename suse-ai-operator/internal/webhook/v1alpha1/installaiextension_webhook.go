@@ -0,0 +1,195 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/config"
+	"github.com/SUSE/suse-ai-operator/internal/installaiextension"
+	"github.com/SUSE/suse-ai-operator/internal/policy"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var installaiextensionlog = logf.Log.WithName("installaiextension-resource")
+
+// SetupInstallAIExtensionWebhookWithManager registers the webhook for InstallAIExtension in the manager.
+// selector limits validation to the extensions of the operator's shard, nil
+// validates all of them.
+func SetupInstallAIExtensionWebhookWithManager(
+	mgr ctrl.Manager,
+	policies *policy.Loader,
+	settings *config.Store,
+	selector labels.Selector,
+) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&aiplatformv1alpha1.InstallAIExtension{}).
+		WithValidator(&InstallAIExtensionCustomValidator{
+			Client:   mgr.GetClient(),
+			Policies: policies,
+			Settings: settings,
+			Selector: selector,
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-ai-platform-suse-com-v1alpha1-installaiextension,mutating=false,failurePolicy=fail,sideEffects=None,groups=ai-platform.suse.com,resources=installaiextensions,verbs=create;update,versions=v1alpha1,name=vinstallaiextension-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// InstallAIExtensionCustomValidator rejects extensions whose chart
+// violates the operator policy, and extensions naming a service account
+// the requesting user may not impersonate.
+type InstallAIExtensionCustomValidator struct {
+	Client   client.Client
+	Policies *policy.Loader
+	Settings *config.Store
+	// Selector skips extensions of other shards, which their own
+	// operator instance validates.
+	Selector labels.Selector
+}
+
+var _ webhook.CustomValidator = &InstallAIExtensionCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type InstallAIExtension.
+func (v *InstallAIExtensionCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ext, ok := obj.(*aiplatformv1alpha1.InstallAIExtension)
+	if !ok {
+		return nil, fmt.Errorf("expected a InstallAIExtension object but got %T", obj)
+	}
+	installaiextensionlog.V(1).Info("Validation for InstallAIExtension upon creation", "name", ext.GetName())
+
+	if !v.inShard(ext) {
+		return nil, nil
+	}
+	if err := v.validateServiceAccount(ctx, ext); err != nil {
+		return nil, err
+	}
+	return nil, v.validatePolicy(ctx, ext)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type InstallAIExtension.
+func (v *InstallAIExtensionCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	ext, ok := newObj.(*aiplatformv1alpha1.InstallAIExtension)
+	if !ok {
+		return nil, fmt.Errorf("expected a InstallAIExtension object for the newObj but got %T", newObj)
+	}
+	installaiextensionlog.V(1).Info("Validation for InstallAIExtension upon update", "name", ext.GetName())
+
+	// Let extensions admitted under an older policy be deleted.
+	if !ext.DeletionTimestamp.IsZero() || !v.inShard(ext) {
+		return nil, nil
+	}
+
+	// The service account and chart were checked when they were set, so
+	// other edits are allowed even if the policy changed since.
+	oldExt, ok := oldObj.(*aiplatformv1alpha1.InstallAIExtension)
+	if !ok || oldExt.Spec.ServiceAccountName != ext.Spec.ServiceAccountName {
+		if err := v.validateServiceAccount(ctx, ext); err != nil {
+			return nil, err
+		}
+	}
+	if ok && equality.Semantic.DeepEqual(oldExt.Spec.Helm, ext.Spec.Helm) {
+		return nil, nil
+	}
+	return nil, v.validatePolicy(ctx, ext)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type InstallAIExtension.
+func (v *InstallAIExtensionCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// inShard reports whether ext is validated by this operator instance.
+func (v *InstallAIExtensionCustomValidator) inShard(ext *aiplatformv1alpha1.InstallAIExtension) bool {
+	return v.Selector == nil || v.Selector.Matches(labels.Set(ext.Labels))
+}
+
+func (v *InstallAIExtensionCustomValidator) validatePolicy(ctx context.Context, ext *aiplatformv1alpha1.InstallAIExtension) error {
+	if ext.Spec.Helm == nil {
+		return nil
+	}
+	return v.Policies.Check(ctx, policy.ExtensionChart(ext))
+}
+
+// validateServiceAccount requires spec.serviceAccountName when the
+// operator does, and checks that the requesting user may impersonate the
+// service account, so naming one grants no more than the user already
+// has.
+func (v *InstallAIExtensionCustomValidator) validateServiceAccount(
+	ctx context.Context,
+	ext *aiplatformv1alpha1.InstallAIExtension,
+) error {
+	settings := v.Settings.Get()
+
+	name := ext.Spec.ServiceAccountName
+	if name == "" {
+		if settings.RequireServiceAccount {
+			return errors.New("spec.serviceAccountName is required by the operator")
+		}
+		return nil
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	namespace := installaiextension.Namespace(ext, settings.ExtensionNamespace)
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(req.UserInfo.Extra))
+	for k, val := range req.UserInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(val)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   req.UserInfo.Username,
+			UID:    req.UserInfo.UID,
+			Groups: req.UserInfo.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "impersonate",
+				Resource:  "serviceaccounts",
+				Name:      name,
+			},
+		},
+	}
+	if err := v.Client.Create(ctx, review); err != nil {
+		return fmt.Errorf("failed to check access to service account %s/%s: %w", namespace, name, err)
+	}
+
+	if !review.Status.Allowed {
+		return fmt.Errorf("user %q may not impersonate service account %s/%s named in spec.serviceAccountName",
+			req.UserInfo.Username, namespace, name)
+	}
+	return nil
+}
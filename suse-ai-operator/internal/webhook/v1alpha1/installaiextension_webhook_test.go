@@ -0,0 +1,171 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	aiplatformv1alpha1 "github.com/SUSE/suse-ai-operator/api/v1alpha1"
+	"github.com/SUSE/suse-ai-operator/internal/config"
+)
+
+var _ = Describe("InstallAIExtension Webhook", func() {
+	var (
+		ctx       context.Context
+		validator *InstallAIExtensionCustomValidator
+		reviews   []authorizationv1.SubjectAccessReviewSpec
+		allowed   bool
+		reviewErr error
+	)
+
+	newValidator := func(settings config.Settings) {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					review, ok := obj.(*authorizationv1.SubjectAccessReview)
+					if !ok {
+						return c.Create(ctx, obj, opts...)
+					}
+					reviews = append(reviews, review.Spec)
+					if reviewErr != nil {
+						return reviewErr
+					}
+					review.Status.Allowed = allowed
+					return nil
+				},
+			}).
+			Build()
+
+		validator = &InstallAIExtensionCustomValidator{
+			Client:   c,
+			Settings: config.NewStore(settings),
+		}
+	}
+
+	newExtension := func(serviceAccount string) *aiplatformv1alpha1.InstallAIExtension {
+		ext := &aiplatformv1alpha1.InstallAIExtension{ObjectMeta: metav1.ObjectMeta{Name: "ext"}}
+		ext.Spec.ServiceAccountName = serviceAccount
+		return ext
+	}
+
+	BeforeEach(func() {
+		reviews = nil
+		allowed = false
+		reviewErr = nil
+		ctx = admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{
+					Username: "alice",
+					UID:      "1234",
+					Groups:   []string{"developers"},
+					Extra:    map[string]authenticationv1.ExtraValue{"scopes": {"all"}},
+				},
+			},
+		})
+		newValidator(config.Settings{ExtensionNamespace: "ai"})
+	})
+
+	Describe("validateServiceAccount", func() {
+		It("allows a service account the user may impersonate", func() {
+			allowed = true
+
+			Expect(validator.validateServiceAccount(ctx, newExtension("installer"))).To(Succeed())
+			Expect(reviews).To(Equal([]authorizationv1.SubjectAccessReviewSpec{{
+				User:   "alice",
+				UID:    "1234",
+				Groups: []string{"developers"},
+				Extra:  map[string]authorizationv1.ExtraValue{"scopes": {"all"}},
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: "ai",
+					Verb:      "impersonate",
+					Resource:  "serviceaccounts",
+					Name:      "installer",
+				},
+			}}))
+		})
+
+		It("refuses a service account the user may not impersonate", func() {
+			err := validator.validateServiceAccount(ctx, newExtension("installer"))
+			Expect(err).To(MatchError(`user "alice" may not impersonate service account ai/installer ` +
+				`named in spec.serviceAccountName`))
+		})
+
+		It("checks the namespace the extension is deployed to", func() {
+			allowed = true
+			ext := newExtension("installer")
+			ext.Status.Deployed = &aiplatformv1alpha1.DeployedRelease{Namespace: "legacy"}
+
+			Expect(validator.validateServiceAccount(ctx, ext)).To(Succeed())
+			Expect(reviews).To(HaveLen(1))
+			Expect(reviews[0].ResourceAttributes.Namespace).To(Equal("legacy"))
+		})
+
+		It("fails when the access review cannot be created", func() {
+			reviewErr = errors.New("connection refused")
+
+			err := validator.validateServiceAccount(ctx, newExtension("installer"))
+			Expect(err).To(MatchError("failed to check access to service account ai/installer: connection refused"))
+		})
+
+		It("allows an extension without a service account", func() {
+			Expect(validator.validateServiceAccount(ctx, newExtension(""))).To(Succeed())
+			Expect(reviews).To(BeEmpty())
+		})
+
+		It("refuses an extension without a service account when the operator requires one", func() {
+			newValidator(config.Settings{ExtensionNamespace: "ai", RequireServiceAccount: true})
+
+			err := validator.validateServiceAccount(ctx, newExtension(""))
+			Expect(err).To(MatchError("spec.serviceAccountName is required by the operator"))
+			Expect(reviews).To(BeEmpty())
+		})
+	})
+
+	Describe("ValidateUpdate", func() {
+		It("checks the service account only when it changes", func() {
+			oldExt := newExtension("installer")
+			newExt := newExtension("installer")
+			newExt.Labels = map[string]string{"team": "ai"}
+
+			_, err := validator.ValidateUpdate(ctx, oldExt, newExt)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reviews).To(BeEmpty())
+
+			_, err = validator.ValidateUpdate(ctx, oldExt, newExtension("admin"))
+			Expect(err).To(MatchError(ContainSubstring("may not impersonate service account ai/admin")))
+			Expect(reviews).To(HaveLen(1))
+		})
+	})
+})